@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -9,12 +10,20 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"runtime/debug"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/naodEthiop/lalibela-cli/internal/audit"
+	"github.com/naodEthiop/lalibela-cli/internal/certs"
 	"github.com/naodEthiop/lalibela-cli/internal/cli"
 	"github.com/naodEthiop/lalibela-cli/internal/features"
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
 	"github.com/naodEthiop/lalibela-cli/internal/generator"
+	"github.com/naodEthiop/lalibela-cli/internal/secrets"
 	"github.com/naodEthiop/lalibela-cli/internal/ui"
 	"github.com/naodEthiop/lalibela-cli/internal/updater"
 	"github.com/naodEthiop/lalibela-cli/internal/utils"
@@ -175,14 +184,37 @@ func handleSubcommand(args []string) bool {
 
 	switch strings.ToLower(strings.TrimSpace(args[0])) {
 	case "add":
+		loadUserFeatures()
 		runAddCommand(args[1:])
 		return true
+	case "remove":
+		loadUserFeatures()
+		runRemoveCommand(args[1:])
+		return true
+	case "features":
+		loadUserFeatures()
+		runFeaturesCommand(args[1:])
+		return true
+	case "explain":
+		loadUserFeatures()
+		runExplainCommand(args[1:])
+		return true
 	case "help":
 		runHelpCommand(args[1:])
 		return true
 	case "run":
 		runRunCommand(args[1:])
 		return true
+	case "audit":
+		runAuditCommand(args[1:])
+		return true
+	case "secrets":
+		loadUserFeatures()
+		runSecretsCommand(args[1:])
+		return true
+	case "certs":
+		runCertsCommand(args[1:])
+		return true
 	case "update":
 		runUpdateCommand(args[1:])
 		return true
@@ -196,42 +228,1109 @@ func handleSubcommand(args []string) bool {
 		printVersion()
 		return true
 	default:
-		if strings.HasPrefix(strings.TrimSpace(args[0]), "-") {
-			return false
+		if strings.HasPrefix(strings.TrimSpace(args[0]), "-") {
+			return false
+		}
+		exitWithError(
+			fmt.Sprintf("Unknown command %q.", args[0]),
+			"Run 'lalibela help' to see available commands.",
+		)
+		return false
+	}
+}
+
+func runAddCommand(args []string) {
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	showHelp := fs.Bool("help", false, "Show add command help")
+	showHelpShort := fs.Bool("h", false, "Show add command help")
+	assumeYes := fs.Bool("yes", false, "Use defaults for options that were not passed")
+	assumeYesShort := fs.Bool("y", false, "Use defaults for options that were not passed")
+	onConflict := fs.String("on-conflict", "", "How to handle existing files: keep, overwrite or new")
+	upgrade := fs.Bool("upgrade", false, "Upgrade installed features to their current version")
+	requested, err := parseAddArgs(fs, args)
+	if err != nil {
+		exitWithError(
+			"Invalid arguments for 'add' command.",
+			fmt.Sprintf("Details: %v", err),
+			fmt.Sprintf("Supported features: %s", strings.Join(features.KnownFeatures(), ", ")),
+			"Run 'lalibela help add' or 'lalibela add <feature> --help' for usage.",
+		)
+	}
+	if *showHelp || *showHelpShort {
+		if len(requested) == 0 {
+			printAddHelp()
+		}
+		for _, feature := range requested {
+			printFeatureOptionsHelp(feature.name, feature.schema)
+		}
+		return
+	}
+	if len(requested) == 0 {
+		exitWithError(
+			"Feature name is required.",
+			"Usage: lalibela add <feature> [--option value]... [<feature> ...]",
+			fmt.Sprintf("Supported features: %s", strings.Join(features.KnownFeatures(), ", ")),
+		)
+	}
+	var policy shared.Resolution
+	if *onConflict != "" {
+		if policy, err = shared.ParseResolution(*onConflict); err != nil {
+			exitWithError(
+				"Invalid value for --on-conflict.",
+				fmt.Sprintf("Details: %v", err),
+			)
+		}
+	}
+	if *upgrade {
+		runUpgrade(requested, policy)
+		return
+	}
+	interactive := !*assumeYes && !*assumeYesShort && term.IsTerminal(int(os.Stdin.Fd()))
+	if interactive {
+		if err := promptFeatureOptions(requested); err != nil {
+			exitWithError(
+				"Could not read feature options.",
+				fmt.Sprintf("Details: %v", err),
+				"Pass options as flags or use --yes to accept defaults.",
+			)
+		}
+	}
+
+	projectRoot, err := os.Getwd()
+	if err != nil {
+		exitWithError(
+			"Could not determine current directory.",
+			fmt.Sprintf("Details: %v", err),
+		)
+	}
+
+	framework, err := features.DetectFramework(projectRoot)
+	if err != nil {
+		exitWithError(
+			"Could not detect project framework.",
+			fmt.Sprintf("Details: %v", err),
+			"Run this command from a generated Lalibela project root.",
+		)
+	}
+
+	requests := make([]features.FeatureRequest, 0, len(requested))
+	for _, feature := range requested {
+		requests = append(requests, features.FeatureRequest{Name: feature.name, Options: feature.values})
+	}
+	var resolve shared.Resolver
+	switch {
+	case policy != "":
+		resolve = func(shared.Conflict) (shared.Resolution, error) { return policy, nil }
+	case interactive:
+		// Invalid requests are reported by the install below.
+		if conflicts, err := features.Conflicts(projectRoot, framework, requests); err == nil && len(conflicts) > 0 {
+			decisions, err := promptConflictResolutions(conflicts)
+			if err != nil {
+				exitWithError(
+					"Could not read conflict resolutions.",
+					fmt.Sprintf("Details: %v", err),
+					"Use --on-conflict keep|overwrite|new to decide for every file.",
+				)
+			}
+			resolve = func(conflict shared.Conflict) (shared.Resolution, error) {
+				if resolution, ok := decisions[conflict.Path]; ok {
+					return resolution, nil
+				}
+				return shared.ResolveKeep, nil
+			}
+		}
+	}
+
+	spinner := ui.NewSpinner("Installing features...")
+	spinner.Start()
+	results, err := features.InstallFeaturesWithResolver(projectRoot, framework, requests, resolve, utils.RunCommand)
+	if err != nil {
+		spinner.StopError("Feature install failed")
+		exitWithError(
+			"Failed to install features.",
+			fmt.Sprintf("Details: %v", err),
+			"Run 'lalibela help add' for supported feature names.",
+		)
+	}
+
+	installed := 0
+	for _, result := range results {
+		if result.Installed {
+			installed++
+		}
+	}
+	if installed == 0 {
+		spinner.StopSuccess("No changes needed")
+	} else {
+		spinner.StopSuccess(fmt.Sprintf("Installed %d feature(s)", installed))
+	}
+	printInstallResults(results)
+	for _, result := range results {
+		if !result.Installed {
+			continue
+		}
+		if doc, err := features.Explain(projectRoot, result.Name, framework); err == nil {
+			printDocExcerpt(doc)
+		}
+	}
+	if installed > 0 {
+		fmt.Println("Next:")
+		fmt.Println("  go test ./...")
+	}
+}
+
+// runUpgrade re-renders the requested features with their current versions.
+// Modified files are settled by policy and kept when it is empty.
+func runUpgrade(requested []requestedFeature, policy shared.Resolution) {
+	names := make([]string, 0, len(requested))
+	for _, feature := range requested {
+		if len(feature.values) > 0 {
+			exitWithError(
+				"Feature options cannot be changed with --upgrade.",
+				fmt.Sprintf("Recorded options of %s are reused; remove and add the feature to change them.", feature.name),
+			)
+		}
+		names = append(names, feature.name)
+	}
+	projectRoot, err := os.Getwd()
+	if err != nil {
+		exitWithError(
+			"Could not determine current directory.",
+			fmt.Sprintf("Details: %v", err),
+		)
+	}
+
+	var resolve shared.Resolver
+	if policy != "" {
+		resolve = func(shared.Conflict) (shared.Resolution, error) { return policy, nil }
+	}
+	spinner := ui.NewSpinner("Upgrading features...")
+	spinner.Start()
+	results, err := features.UpgradeFeatures(projectRoot, names, resolve, utils.RunCommand)
+	if err != nil {
+		spinner.StopError("Feature upgrade failed")
+		exitWithError(
+			"Failed to upgrade features.",
+			fmt.Sprintf("Details: %v", err),
+			"Run 'lalibela features --outdated' to see which features can be upgraded.",
+		)
+	}
+	upgraded := 0
+	for _, result := range results {
+		if !result.UpToDate {
+			upgraded++
+		}
+	}
+	if upgraded == 0 {
+		spinner.StopSuccess("No changes needed")
+	} else {
+		spinner.StopSuccess(fmt.Sprintf("Upgraded %d feature(s)", upgraded))
+	}
+
+	fmt.Println(ui.SectionHeader("Features"))
+	for _, result := range results {
+		if result.UpToDate {
+			fmt.Printf("  %-18s %s %s\n", result.Name, ui.Dim(fmt.Sprintf("%-17s", "up to date")), result.To)
+			continue
+		}
+		notes := []string{result.From + " -> " + result.To}
+		if len(result.Updated) > 0 {
+			notes = append(notes, "updated "+strings.Join(result.Updated, ", "))
+		}
+		if len(result.Removed) > 0 {
+			notes = append(notes, "removed "+strings.Join(result.Removed, ", "))
+		}
+		if len(result.Added) > 0 {
+			notes = append(notes, "installed "+strings.Join(result.Added, ", "))
+		}
+		if len(result.ManualSteps) > 0 {
+			notes = append(notes, "manual wiring needed")
+		}
+		fmt.Printf("  %-18s %s %s\n", result.Name, ui.Green(fmt.Sprintf("%-17s", "upgraded")), strings.Join(notes, "; "))
+	}
+	for _, result := range results {
+		if len(result.StaleWiring) > 0 {
+			fmt.Println(ui.Yellow(fmt.Sprintf("Could not revert wiring of the previous %s that changed since install; remove it by hand:", result.Name)))
+			for _, edit := range result.StaleWiring {
+				fmt.Printf("    %s\n", edit)
+			}
+		}
+		if len(result.ManualSteps) > 0 {
+			fmt.Println(ui.Yellow(fmt.Sprintf("Manual wiring needed for %s:", result.Name)))
+			for _, step := range result.ManualSteps {
+				fmt.Printf("  -> %s\n", step)
+			}
+		}
+		for _, diff := range result.Diffs {
+			fmt.Println(ui.Yellow(fmt.Sprintf("%s was modified since install; the new version of %s differs:", diff.Path, result.Name)))
+			printDiff(diff.Diff)
+		}
+		for _, conflict := range result.Conflicts {
+			switch conflict.Resolution {
+			case shared.ResolveKeep:
+				fmt.Println(ui.Yellow(fmt.Sprintf("Kept %s (use --on-conflict overwrite or new to apply the new version).", conflict.Path)))
+			case shared.ResolveOverwrite:
+				fmt.Println(ui.Yellow(fmt.Sprintf("Overwrote %s with the new version.", conflict.Path)))
+			case shared.ResolveWriteNew:
+				fmt.Println(ui.Yellow(fmt.Sprintf("Kept %s; the new version was written to %s.", conflict.Path, conflict.NewPath)))
+			}
+		}
+	}
+}
+
+// requestedFeature is a feature named on the add command line together with
+// the option flags that followed it.
+type requestedFeature struct {
+	name   string
+	schema []shared.Option
+	values map[string]string
+}
+
+// parseAddArgs splits args into features, each followed by its own option
+// flags, as in "cors --origins https://a.example rate-limit --rps 50". The
+// add command's flags on fs are accepted anywhere.
+func parseAddArgs(fs *flag.FlagSet, args []string) ([]requestedFeature, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	var requested []requestedFeature
+	var problems []string
+	rest := fs.Args()
+	for len(rest) > 0 {
+		name := rest[0]
+		schema, unknownErr := features.FeatureOptions(name)
+		if unknownErr != nil {
+			// Keep going so every unknown name is reported at once.
+			problems = append(problems, unknownErr.Error())
+		}
+
+		values := make(map[string]string)
+		featureFlags := flag.NewFlagSet("add "+name, flag.ContinueOnError)
+		featureFlags.SetOutput(io.Discard)
+		fs.VisitAll(func(f *flag.Flag) {
+			featureFlags.Var(f.Value, f.Name, f.Usage)
+		})
+		for _, option := range schema {
+			featureFlags.Var(optionFlagValue{option: option, values: values}, option.Name, option.Description)
+		}
+		if err := featureFlags.Parse(rest[1:]); err != nil {
+			// The options of an unknown feature can't be told apart from the
+			// names that follow them, so parsing stops here.
+			if unknownErr == nil {
+				problems = append(problems, fmt.Sprintf("feature %q: %v", name, err))
+			}
+			break
+		}
+		if unknownErr == nil {
+			requested = append(requested, requestedFeature{name: name, schema: schema, values: values})
+		}
+		rest = featureFlags.Args()
+	}
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+	return requested, nil
+}
+
+// optionFlagValue is a flag.Value that checks and stores a feature option.
+// Bool options can be passed as --name without a value, like a flag.Bool.
+type optionFlagValue struct {
+	option shared.Option
+	values map[string]string
+}
+
+func (v optionFlagValue) String() string { return v.values[v.option.Name] }
+
+func (v optionFlagValue) Set(value string) error {
+	value = strings.TrimSpace(value)
+	if err := v.option.Check(value); err != nil {
+		return err
+	}
+	v.values[v.option.Name] = value
+	return nil
+}
+
+func (v optionFlagValue) IsBoolFlag() bool { return v.option.Type == shared.OptionBool }
+
+// promptFeatureOptions asks for each option that was not passed as a flag,
+// showing the default, and stores the answers on the requested features.
+func promptFeatureOptions(requested []requestedFeature) error {
+	reader := bufio.NewReader(os.Stdin)
+	for _, feature := range requested {
+		headerShown := false
+		for _, option := range feature.schema {
+			if _, ok := feature.values[option.Name]; ok {
+				continue
+			}
+			if !headerShown {
+				fmt.Println(ui.SectionHeader(feature.name + " options"))
+				headerShown = true
+			}
+			for {
+				fmt.Print(ui.Cyan(fmt.Sprintf("%s (%s) [%s]: ", option.Name, option.Description, option.Default)))
+				input, err := reader.ReadString('\n')
+				if err != nil {
+					return err
+				}
+				answer := strings.TrimSpace(input)
+				if answer == "" {
+					answer = option.Default
+				}
+				if err := option.Check(answer); err != nil {
+					fmt.Println(ui.Yellow(err.Error()))
+					continue
+				}
+				feature.values[option.Name] = answer
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// promptConflictResolutions asks how each existing file that differs from a
+// feature's version should be handled, returning the answers by path.
+func promptConflictResolutions(conflicts []shared.Conflict) (map[string]shared.Resolution, error) {
+	reader := bufio.NewReader(os.Stdin)
+	decisions := make(map[string]shared.Resolution, len(conflicts))
+	fmt.Println(ui.SectionHeader("Existing files"))
+	for _, conflict := range conflicts {
+		for {
+			fmt.Print(ui.Cyan(fmt.Sprintf("%s already exists (%s). [k]eep, [o]verwrite, write [n]ew, show [d]iff [k]: ", conflict.Path, conflict.Feature)))
+			input, err := reader.ReadString('\n')
+			if err != nil {
+				return nil, err
+			}
+			answer := strings.ToLower(strings.TrimSpace(input))
+			switch answer {
+			case "", "k", "keep":
+				decisions[conflict.Path] = shared.ResolveKeep
+			case "o", "overwrite":
+				decisions[conflict.Path] = shared.ResolveOverwrite
+			case "n", "new":
+				decisions[conflict.Path] = shared.ResolveWriteNew
+			case "d", "diff":
+				printDiff(shared.Diff(conflict.Path, conflict.Path+" ("+conflict.Feature+")", conflict.Existing, conflict.Proposed))
+				continue
+			default:
+				fmt.Println(ui.Yellow("Answer k, o, n or d."))
+				continue
+			}
+			break
+		}
+	}
+	return decisions, nil
+}
+
+// printDiff prints a unified diff with removed and added lines coloured.
+func printDiff(diff string) {
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			fmt.Println(ui.Bold(line))
+		case strings.HasPrefix(line, "@@"):
+			fmt.Println(ui.Cyan(line))
+		case strings.HasPrefix(line, "+"):
+			fmt.Println(ui.Green(line))
+		case strings.HasPrefix(line, "-"):
+			fmt.Println(ui.Red(line))
+		default:
+			fmt.Println(line)
+		}
+	}
+}
+
+// printInstallResults prints one row per feature, followed by any wiring the
+// user has to do by hand and how existing files were handled.
+func printInstallResults(results []features.InstallResult) {
+	fmt.Println(ui.SectionHeader("Features"))
+	for _, result := range results {
+		var status string
+		switch {
+		case result.Installed:
+			status = ui.Green(fmt.Sprintf("%-17s", "installed"))
+		case result.AlreadyPresent:
+			status = ui.Dim(fmt.Sprintf("%-17s", "already installed"))
+		default:
+			status = ui.Yellow(fmt.Sprintf("%-17s", "not supported"))
+		}
+
+		var notes []string
+		if result.RequiredBy != "" {
+			notes = append(notes, "required by "+result.RequiredBy)
+		}
+		if len(result.Options) > 0 {
+			names := make([]string, 0, len(result.Options))
+			for name := range result.Options {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				notes = append(notes, fmt.Sprintf("%s=%s", name, result.Options[name]))
+			}
+		}
+		if len(result.Wired) > 0 {
+			notes = append(notes, "wired into "+strings.Join(result.Wired, ", "))
+		}
+		if len(result.Env) > 0 {
+			notes = append(notes, "env "+strings.Join(result.Env, ", "))
+		}
+		if len(result.ManualSteps) > 0 {
+			notes = append(notes, "manual wiring needed")
+		}
+		if len(result.Conflicts) > 0 {
+			notes = append(notes, fmt.Sprintf("%d existing file(s)", len(result.Conflicts)))
+		}
+		fmt.Printf("  %-18s %s %s\n", result.Name, status, strings.Join(notes, "; "))
+	}
+
+	for _, result := range results {
+		for _, conflict := range result.Conflicts {
+			switch conflict.Resolution {
+			case shared.ResolveKeep:
+				fmt.Println(ui.Yellow(fmt.Sprintf("Kept existing %s; %s's version was skipped (use --on-conflict to change).", conflict.Path, result.Name)))
+			case shared.ResolveOverwrite:
+				fmt.Println(ui.Yellow(fmt.Sprintf("Overwrote %s with %s's version.", conflict.Path, result.Name)))
+			case shared.ResolveWriteNew:
+				fmt.Println(ui.Yellow(fmt.Sprintf("Kept existing %s; %s's version was written to %s.", conflict.Path, result.Name, conflict.NewPath)))
+			}
+		}
+	}
+
+	for _, result := range results {
+		if len(result.ManualSteps) == 0 {
+			continue
+		}
+		fmt.Println(ui.Yellow(fmt.Sprintf("Manual wiring needed for %s:", result.Name)))
+		for _, step := range result.ManualSteps {
+			fmt.Printf("  -> %s\n", step)
+		}
+	}
+}
+
+func runRemoveCommand(args []string) {
+	fs := flag.NewFlagSet("remove", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	force := fs.Bool("force", false, "Delete files even if they were modified, and remove features other features require")
+	cascade := fs.Bool("cascade", false, "Also remove installed features that require the named ones")
+	showHelp := fs.Bool("help", false, "Show remove command help")
+	showHelpShort := fs.Bool("h", false, "Show remove command help")
+	featureNames, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		exitWithError(
+			"Invalid arguments for 'remove' command.",
+			fmt.Sprintf("Details: %v", err),
+			"Run 'lalibela help remove' for usage.",
+		)
+	}
+	if *showHelp || *showHelpShort {
+		printRemoveHelp()
+		return
+	}
+	if len(featureNames) == 0 {
+		exitWithError(
+			"Feature name is required.",
+			"Usage: lalibela remove <feature>... [--cascade] [--force]",
+		)
+	}
+
+	projectRoot, err := os.Getwd()
+	if err != nil {
+		exitWithError(
+			"Could not determine current directory.",
+			fmt.Sprintf("Details: %v", err),
+		)
+	}
+
+	spinner := ui.NewSpinner("Removing feature...")
+	spinner.Start()
+	remove := features.RemoveFeatures
+	if *cascade {
+		remove = features.RemoveFeaturesCascade
+	}
+	results, err := remove(projectRoot, featureNames, *force, utils.RunCommand)
+	if err != nil {
+		spinner.StopError("Feature removal failed")
+		var dependents *features.DependentsError
+		if errors.As(err, &dependents) {
+			exitWithError(
+				"Other installed features require the features being removed.",
+				fmt.Sprintf("Details: %v", err),
+				"Remove them too, pass --cascade to remove them with it, or --force to remove it anyway.",
+			)
+		}
+		exitWithError(
+			"Failed to remove feature.",
+			fmt.Sprintf("Details: %v", err),
+			"Run 'lalibela help remove' for usage.",
+		)
+	}
+	spinner.StopSuccess("Feature removed")
+
+	for _, result := range results {
+		fmt.Printf("Feature '%s' removed.\n", result.Name)
+		for _, path := range result.Deleted {
+			fmt.Printf("  %s %s\n", ui.Green("-"), path)
+		}
+		if len(result.Env) > 0 {
+			fmt.Printf("  %s .env, .env.example: %s\n", ui.Green("-"), strings.Join(result.Env, ", "))
+		}
+		if result.Untracked {
+			fmt.Println(ui.Yellow("  No file ownership was recorded for this feature; its files were left in place."))
+		}
+		if len(result.StaleWiring) > 0 {
+			fmt.Println(ui.Yellow("  Could not revert wiring that changed since install; remove it by hand:"))
+			for _, edit := range result.StaleWiring {
+				fmt.Printf("    %s\n", edit)
+			}
+		}
+		if len(result.Edited) > 0 {
+			fmt.Println(ui.Yellow("  Files edited by the feature's installer were left as they are; undo the edits by hand:"))
+			for _, path := range result.Edited {
+				fmt.Printf("    %s\n", path)
+			}
+		}
+		if len(result.RequiredBy) > 0 {
+			fmt.Println(ui.Yellow(fmt.Sprintf("  Still required by %s, which may no longer build.", strings.Join(result.RequiredBy, ", "))))
+		}
+		if len(result.Modified) > 0 && !*force {
+			fmt.Println(ui.Yellow("  Kept files modified since install (use --force to delete them):"))
+			for _, path := range result.Modified {
+				fmt.Printf("    %s\n", path)
+			}
+		}
+	}
+}
+
+func runFeaturesCommand(args []string) {
+	fs := flag.NewFlagSet("features", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	asJSON := fs.Bool("json", false, "Print the catalog as JSON")
+	outdatedOnly := fs.Bool("outdated", false, "List installed features with newer versions available")
+	showHelp := fs.Bool("help", false, "Show features command help")
+	showHelpShort := fs.Bool("h", false, "Show features command help")
+	if err := fs.Parse(args); err != nil {
+		exitWithError(
+			"Invalid arguments for 'features' command.",
+			fmt.Sprintf("Details: %v", err),
+			"Run 'lalibela help features' for usage.",
+		)
+	}
+	if *showHelp || *showHelpShort {
+		printFeaturesHelp()
+		return
+	}
+	if fs.NArg() > 0 {
+		exitWithError(
+			fmt.Sprintf("Unexpected argument %q.", fs.Arg(0)),
+			"Usage: lalibela features [--outdated] [--json]",
+		)
+	}
+
+	projectRoot, err := os.Getwd()
+	if err != nil {
+		exitWithError(
+			"Could not determine current directory.",
+			fmt.Sprintf("Details: %v", err),
+		)
+	}
+	if *outdatedOnly {
+		outdated, err := features.Outdated(projectRoot)
+		if err != nil {
+			exitWithError(
+				"Could not read installed features.",
+				fmt.Sprintf("Details: %v", err),
+				"Run this command from a generated Lalibela project root.",
+			)
+		}
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(outdated); err != nil {
+				exitWithError(
+					"Could not encode outdated features.",
+					fmt.Sprintf("Details: %v", err),
+				)
+			}
+			return
+		}
+		printOutdatedFeatures(outdated)
+		return
+	}
+	catalog, err := features.Catalog(projectRoot)
+	if err != nil {
+		exitWithError(
+			"Could not build the feature catalog.",
+			fmt.Sprintf("Details: %v", err),
+		)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(catalog); err != nil {
+			exitWithError(
+				"Could not encode the feature catalog.",
+				fmt.Sprintf("Details: %v", err),
+			)
+		}
+		return
+	}
+	printFeatureCatalog(catalog)
+}
+
+func printOutdatedFeatures(outdated []features.OutdatedFeature) {
+	fmt.Println(ui.SectionHeader("Outdated Features"))
+	if len(outdated) == 0 {
+		fmt.Println("  All installed features are up to date.")
+		return
+	}
+	for _, feature := range outdated {
+		fmt.Printf("  %-18s %s -> %s\n", feature.Name, ui.Yellow(feature.Installed), ui.Green(feature.Available))
+	}
+	names := make([]string, 0, len(outdated))
+	for _, feature := range outdated {
+		names = append(names, feature.Name)
+	}
+	fmt.Println()
+	fmt.Println("Upgrade with:")
+	fmt.Printf("  lalibela add --upgrade %s\n", strings.Join(names, " "))
+}
+
+func printFeatureCatalog(catalog []features.CatalogEntry) {
+	fmt.Println(ui.SectionHeader("Feature Catalog"))
+	for _, entry := range catalog {
+		var tags []string
+		if entry.Installed {
+			tags = append(tags, ui.Green("installed"))
+		}
+		if entry.Default {
+			tags = append(tags, ui.Dim("default"))
+		}
+		header := ui.Bold(entry.Name) + " " + ui.Dim(entry.Version)
+		if len(tags) > 0 {
+			header += " [" + strings.Join(tags, ", ") + "]"
+		}
+		fmt.Println(header)
+		fmt.Printf("  %s\n", entry.Description)
+
+		support := make([]string, 0, len(entry.Compatibility))
+		for _, framework := range features.CatalogFrameworks() {
+			if entry.Compatibility[framework] {
+				support = append(support, ui.Green("✔ "+framework))
+			} else {
+				support = append(support, ui.Red("✖ "+framework))
+			}
+		}
+		fmt.Printf("  %-11s %s\n", "Frameworks:", strings.Join(support, "  "))
+		fmt.Printf("  %-11s %s\n", "Requires:", listOrDash(entry.Requires))
+		fmt.Printf("  %-11s %s\n", "Files:", listOrDash(entry.Files))
+		envNames := make([]string, 0, len(entry.EnvVars))
+		for _, envVar := range entry.EnvVars {
+			envNames = append(envNames, envVar.Name)
+		}
+		fmt.Printf("  %-11s %s\n", "Env vars:", listOrDash(envNames))
+		fmt.Println()
+	}
+}
+
+func listOrDash(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, ", ")
+}
+
+func runExplainCommand(args []string) {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	frameworkFlag := fs.String("framework", "", "Framework to explain wiring for")
+	showHelp := fs.Bool("help", false, "Show explain command help")
+	showHelpShort := fs.Bool("h", false, "Show explain command help")
+	names, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		exitWithError(
+			"Invalid arguments for 'explain' command.",
+			fmt.Sprintf("Details: %v", err),
+			"Run 'lalibela help explain' for usage.",
+		)
+	}
+	if *showHelp || *showHelpShort {
+		printExplainHelp()
+		return
+	}
+	if len(names) != 1 {
+		exitWithError(
+			"Exactly one feature name is required.",
+			"Usage: lalibela explain <feature> [--framework gin|echo|fiber|nethttp]",
+			fmt.Sprintf("Supported features: %s", strings.Join(features.KnownFeatures(), ", ")),
+		)
+	}
+
+	projectRoot, err := os.Getwd()
+	if err != nil {
+		exitWithError(
+			"Could not determine current directory.",
+			fmt.Sprintf("Details: %v", err),
+		)
+	}
+	framework := strings.ToLower(strings.TrimSpace(*frameworkFlag))
+	if framework == "" {
+		framework, err = features.DetectFramework(projectRoot)
+		if err != nil {
+			exitWithError(
+				"Could not detect project framework.",
+				fmt.Sprintf("Details: %v", err),
+				"Run this command from a Lalibela project or pass --framework.",
+			)
+		}
+	}
+	if !slices.Contains(features.CatalogFrameworks(), framework) {
+		exitWithError(
+			fmt.Sprintf("Unsupported framework %q.", framework),
+			"Supported frameworks: gin, echo, fiber, nethttp.",
+		)
+	}
+
+	doc, err := features.Explain(projectRoot, names[0], framework)
+	if err != nil {
+		exitWithError(
+			fmt.Sprintf("Could not explain feature %q.", names[0]),
+			fmt.Sprintf("Details: %v", err),
+			fmt.Sprintf("Supported features: %s", strings.Join(features.KnownFeatures(), ", ")),
+		)
+	}
+	printDoc(doc)
+}
+
+func printDoc(doc features.Doc) {
+	header := ui.Bold(ui.Cyan(doc.Name))
+	if doc.Installed {
+		header += " [" + ui.Green("installed") + "]"
+	}
+	fmt.Println(header)
+	fmt.Printf("  %s\n", doc.Summary)
+	fmt.Println()
+	fmt.Printf("Framework: %s %s\n", ui.FrameworkIcon(doc.Framework), ui.FrameworkLabel(doc.Framework))
+	if !doc.Compatible {
+		fmt.Println(ui.Yellow(fmt.Sprintf("⚠ Feature '%s' is not supported for %s.", doc.Name, doc.Framework)))
+		return
+	}
+	if len(doc.Requires) > 0 {
+		fmt.Printf("Requires:  %s\n", strings.Join(doc.Requires, ", "))
+	}
+	fmt.Println()
+
+	if len(doc.Options) > 0 {
+		fmt.Println(ui.SectionHeader("Options"))
+		for _, option := range doc.Options {
+			fmt.Printf("  --%-8s %s (%s)\n", option.Name, option.Description, doc.Values[option.Name])
+		}
+		fmt.Println()
+	}
+	fmt.Println(ui.SectionHeader("Generated files"))
+	for _, file := range doc.Files {
+		fmt.Printf("  %s\n", file)
+	}
+	fmt.Println()
+	if len(doc.EnvVars) > 0 {
+		fmt.Println(ui.SectionHeader("Environment variables"))
+		for _, envVar := range doc.EnvVars {
+			line := fmt.Sprintf("  %-12s %s", envVar.Name, envVar.Description)
+			if envVar.Default != "" {
+				line += fmt.Sprintf(" (default %s)", envVar.Default)
+			}
+			if envVar.Secret {
+				line += " " + ui.Yellow("[secret]")
+			}
+			fmt.Println(line)
+		}
+		fmt.Println()
+	}
+	if len(doc.Wiring) > 0 {
+		fmt.Println(ui.SectionHeader("Wiring"))
+		fmt.Println("  'lalibela add' makes these edits; apply them by hand if it could not:")
+		for _, step := range doc.Wiring {
+			fmt.Printf("  -> %s\n", step)
+		}
+		fmt.Println()
+	}
+	if doc.Usage != "" {
+		fmt.Println(ui.SectionHeader("Usage"))
+		printSnippet(doc.Usage)
+	}
+}
+
+// printDocExcerpt prints the parts of a feature's docs that matter right
+// after installing it: how to use it and which env vars to set.
+func printDocExcerpt(doc features.Doc) {
+	if doc.Usage == "" && len(doc.EnvVars) == 0 {
+		return
+	}
+	fmt.Println(ui.SectionHeader("Using " + doc.Name))
+	if doc.Usage != "" {
+		printSnippet(doc.Usage)
+	}
+	if len(doc.EnvVars) > 0 {
+		names := make([]string, 0, len(doc.EnvVars))
+		for _, envVar := range doc.EnvVars {
+			names = append(names, envVar.Name)
+		}
+		fmt.Printf("  Env vars: %s\n", strings.Join(names, ", "))
+	}
+	fmt.Printf("  %s\n", ui.Dim(fmt.Sprintf("More: lalibela explain %s", doc.Name)))
+}
+
+func printSnippet(snippet string) {
+	for _, line := range strings.Split(snippet, "\n") {
+		fmt.Printf("    %s\n", line)
+	}
+}
+
+func runAuditCommand(args []string) {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	asSARIF := fs.Bool("sarif", false, "Print the report as SARIF 2.1.0")
+	failOn := fs.String("fail-on", "high", "Exit with status 1 when a finding is at least this severe")
+	showHelp := fs.Bool("help", false, "Show audit command help")
+	showHelpShort := fs.Bool("h", false, "Show audit command help")
+	dirs, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		exitWithError(
+			"Invalid arguments for 'audit' command.",
+			fmt.Sprintf("Details: %v", err),
+			"Run 'lalibela help audit' for usage.",
+		)
+	}
+	if *showHelp || *showHelpShort {
+		printAuditHelp()
+		return
+	}
+	if len(dirs) > 1 {
+		exitWithError(
+			fmt.Sprintf("Unexpected argument %q for 'audit' command.", dirs[1]),
+			"Usage: lalibela audit [dir] [--json|--sarif] [--fail-on critical|high|medium|low|none]",
+		)
+	}
+	if *asJSON && *asSARIF {
+		exitWithError("--json and --sarif cannot be used together.")
+	}
+	var threshold audit.Severity
+	if value := strings.ToLower(strings.TrimSpace(*failOn)); value != "none" {
+		threshold, err = audit.ParseSeverity(value)
+		if err != nil {
+			exitWithError(
+				fmt.Sprintf("Invalid --fail-on value %q.", *failOn),
+				"Supported values: critical, high, medium, low, none.",
+			)
+		}
+	}
+
+	projectRoot := "."
+	if len(dirs) == 1 {
+		projectRoot = dirs[0]
+	}
+	report, err := audit.Run(projectRoot)
+	if err != nil {
+		exitWithError(
+			"Could not audit the project.",
+			fmt.Sprintf("Details: %v", err),
+			"Run this command from a Go project root or pass its directory.",
+		)
+	}
+
+	switch {
+	case *asJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	case *asSARIF:
+		err = report.WriteSARIF(os.Stdout, Version)
+	default:
+		printAuditReport(report)
+	}
+	if err != nil {
+		exitWithError(
+			"Could not encode the audit report.",
+			fmt.Sprintf("Details: %v", err),
+		)
+	}
+	if threshold != "" && report.Worst() != "" && report.Worst().AtLeast(threshold) {
+		os.Exit(1)
+	}
+}
+
+// auditSeverityLabels colors each severity's label in the audit report.
+var auditSeverityLabels = map[audit.Severity]func(string) string{
+	audit.SeverityCritical: func(text string) string { return ui.Bold(ui.Red(text)) },
+	audit.SeverityHigh:     ui.Red,
+	audit.SeverityMedium:   ui.Yellow,
+	audit.SeverityLow:      ui.Dim,
+}
+
+func printAuditReport(report audit.Report) {
+	fmt.Println(ui.SectionHeader("Audit"))
+	if len(report.Findings) == 0 {
+		fmt.Println("  " + ui.Green("No issues found."))
+		return
+	}
+	for _, finding := range report.Findings {
+		location := finding.File
+		if finding.Line > 0 {
+			location = fmt.Sprintf("%s:%d", finding.File, finding.Line)
+		}
+		label := fmt.Sprintf("%-10s", "["+strings.ToUpper(string(finding.Severity))+"]")
+		fmt.Printf("%s %s %s\n", auditSeverityLabels[finding.Severity](label), ui.Bold(location), ui.Dim(finding.Rule))
+		fmt.Printf("  %s\n", finding.Message)
+		fmt.Printf("  %s %s\n", ui.Cyan("fix:"), finding.Fix)
+		fmt.Println()
+	}
+	counts := make([]string, 0, len(audit.Severities()))
+	for _, severity := range audit.Severities() {
+		if count := report.Counts[severity]; count > 0 {
+			counts = append(counts, auditSeverityLabels[severity](fmt.Sprintf("%d %s", count, severity)))
 		}
+	}
+	fmt.Printf("%d issues: %s\n", len(report.Findings), strings.Join(counts, ", "))
+}
+
+func runSecretsCommand(args []string) {
+	if len(args) == 0 {
 		exitWithError(
-			fmt.Sprintf("Unknown command %q.", args[0]),
-			"Run 'lalibela help' to see available commands.",
+			"A secrets subcommand is required.",
+			"Usage: lalibela secrets rotate [NAME]... | lalibela secrets keygen [--alg RS256|EdDSA]",
+		)
+	}
+	switch strings.ToLower(strings.TrimSpace(args[0])) {
+	case "rotate":
+		runSecretsRotateCommand(args[1:])
+	case "keygen":
+		runSecretsKeygenCommand(args[1:])
+	case "-h", "--help", "help":
+		printSecretsHelp()
+	default:
+		exitWithError(
+			fmt.Sprintf("Unknown secrets subcommand %q.", args[0]),
+			"Supported subcommands: rotate, keygen",
+			"Run 'lalibela help secrets' for usage.",
 		)
-		return false
 	}
 }
 
-func runAddCommand(args []string) {
-	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+func runSecretsRotateCommand(args []string) {
+	fs := flag.NewFlagSet("secrets rotate", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
-	showHelp := fs.Bool("help", false, "Show add command help")
-	showHelpShort := fs.Bool("h", false, "Show add command help")
+	showHelp := fs.Bool("help", false, "Show secrets command help")
+	showHelpShort := fs.Bool("h", false, "Show secrets command help")
+	names, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		exitWithError(
+			"Invalid arguments for 'secrets rotate' command.",
+			fmt.Sprintf("Details: %v", err),
+			"Run 'lalibela help secrets' for usage.",
+		)
+	}
+	if *showHelp || *showHelpShort {
+		printSecretsHelp()
+		return
+	}
+	projectRoot := requireProjectRoot()
+
+	rotated, err := features.RotateSecrets(projectRoot, names)
+	if err != nil {
+		rotatable := make([]string, 0)
+		for _, envVar := range features.GeneratedSecrets() {
+			rotatable = append(rotatable, envVar.Name)
+		}
+		rotatable = append(rotatable, "JWT_PRIVATE_KEY_FILE")
+		exitWithError(
+			"Could not rotate secrets.",
+			fmt.Sprintf("Details: %v", err),
+			fmt.Sprintf("Rotatable names: %s", strings.Join(rotatable, ", ")),
+		)
+	}
+	fmt.Println(ui.SectionHeader("Rotated Secrets"))
+	for _, name := range rotated {
+		fmt.Printf("  %s %s\n", ui.Green("✔"), name)
+	}
+	fmt.Println()
+	fmt.Println("  .env has the new values; .env.example keeps its blank placeholders.")
+	fmt.Println(ui.Dim("  Restart the server to use them. Tokens and cookies signed with a replaced"))
+	fmt.Println(ui.Dim("  secret are no longer accepted; a replaced signing key stays in the JWKS."))
+}
+
+func runSecretsKeygenCommand(args []string) {
+	fs := flag.NewFlagSet("secrets keygen", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	alg := fs.String("alg", secrets.AlgEdDSA, "Signing algorithm: RS256 or EdDSA")
+	showHelp := fs.Bool("help", false, "Show secrets command help")
+	showHelpShort := fs.Bool("h", false, "Show secrets command help")
 	if err := fs.Parse(args); err != nil {
 		exitWithError(
-			"Invalid arguments for 'add' command.",
+			"Invalid arguments for 'secrets keygen' command.",
 			fmt.Sprintf("Details: %v", err),
-			"Run 'lalibela help add' for usage.",
+			"Run 'lalibela help secrets' for usage.",
 		)
 	}
 	if *showHelp || *showHelpShort {
-		printAddHelp()
+		printSecretsHelp()
 		return
 	}
-	if fs.NArg() != 1 {
+	if fs.NArg() > 0 {
 		exitWithError(
-			"Feature name is required.",
-			"Usage: lalibela add <feature>",
-			fmt.Sprintf("Supported features: %s", strings.Join(features.KnownFeatures(), ", ")),
+			fmt.Sprintf("Unexpected argument %q for 'secrets keygen' command.", fs.Arg(0)),
+			"Usage: lalibela secrets keygen [--alg RS256|EdDSA]",
+		)
+	}
+	algorithm := ""
+	for _, supported := range secrets.Algorithms() {
+		if strings.EqualFold(strings.TrimSpace(*alg), supported) {
+			algorithm = supported
+		}
+	}
+	if algorithm == "" {
+		exitWithError(
+			fmt.Sprintf("Unsupported algorithm %q.", *alg),
+			fmt.Sprintf("Supported algorithms: %s.", strings.Join(secrets.Algorithms(), ", ")),
+		)
+	}
+	projectRoot := requireProjectRoot()
+
+	key, err := features.GenerateSigningKey(projectRoot, algorithm)
+	if err != nil {
+		exitWithError(
+			"Could not generate a signing key.",
+			fmt.Sprintf("Details: %v", err),
+		)
+	}
+	values, err := shared.EnvFileValues(projectRoot)
+	if err != nil {
+		exitWithError(
+			"Could not read .env.",
+			fmt.Sprintf("Details: %v", err),
 		)
 	}
-	featureName := fs.Arg(0)
+	fmt.Println(ui.SectionHeader("Signing Key"))
+	fmt.Printf("  %s %s key %s\n", ui.Green("✔"), key.Alg, ui.Dim(key.ID))
+	fmt.Printf("  %-13s %s %s\n", "Private key:", values["JWT_PRIVATE_KEY_FILE"], ui.Dim("(0600, gitignored)"))
+	fmt.Printf("  %-13s %s\n", "Public JWKS:", values["JWT_JWKS_FILE"])
+	fmt.Println()
+	fmt.Println("  .env now sets JWT_PRIVATE_KEY_FILE, JWT_JWKS_FILE and JWT_KID: the auth")
+	fmt.Println("  feature verifies tokens against the JWKS and auth-flow signs with the key.")
+	fmt.Println(ui.Dim("  Replace the key later with 'lalibela secrets rotate JWT_PRIVATE_KEY_FILE'."))
+}
 
+// requireProjectRoot returns the current directory, which must be a project
+// root.
+func requireProjectRoot() string {
+	if _, err := os.Stat("go.mod"); err != nil {
+		exitWithError(
+			"Could not find go.mod in the current directory.",
+			"Run this command inside your generated project directory.",
+		)
+	}
 	projectRoot, err := os.Getwd()
 	if err != nil {
 		exitWithError(
@@ -239,47 +1338,121 @@ func runAddCommand(args []string) {
 			fmt.Sprintf("Details: %v", err),
 		)
 	}
+	return projectRoot
+}
 
-	framework, err := features.DetectFramework(projectRoot)
+func runCertsCommand(args []string) {
+	if len(args) == 0 {
+		exitWithError(
+			"A certs subcommand is required.",
+			"Usage: lalibela certs dev [--hosts NAME,...]",
+		)
+	}
+	switch strings.ToLower(strings.TrimSpace(args[0])) {
+	case "dev":
+		runCertsDevCommand(args[1:])
+	case "-h", "--help", "help":
+		printCertsHelp()
+	default:
+		exitWithError(
+			fmt.Sprintf("Unknown certs subcommand %q.", args[0]),
+			"Supported subcommands: dev",
+			"Run 'lalibela help certs' for usage.",
+		)
+	}
+}
+
+func runCertsDevCommand(args []string) {
+	fs := flag.NewFlagSet("certs dev", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	hostsFlag := fs.String("hosts", strings.Join(certs.DefaultHosts(), ","), "Comma-separated names and IP addresses the certificate covers")
+	showHelp := fs.Bool("help", false, "Show certs command help")
+	showHelpShort := fs.Bool("h", false, "Show certs command help")
+	if err := fs.Parse(args); err != nil {
+		exitWithError(
+			"Invalid arguments for 'certs dev' command.",
+			fmt.Sprintf("Details: %v", err),
+			"Run 'lalibela help certs' for usage.",
+		)
+	}
+	if *showHelp || *showHelpShort {
+		printCertsHelp()
+		return
+	}
+	if fs.NArg() > 0 {
+		exitWithError(
+			fmt.Sprintf("Unexpected argument %q for 'certs dev' command.", fs.Arg(0)),
+			"Usage: lalibela certs dev [--hosts NAME,...]",
+		)
+	}
+	hosts := make([]string, 0)
+	for _, host := range strings.Split(*hostsFlag, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) == 0 {
+		exitWithError(
+			"At least one host is required.",
+			"Example: lalibela certs dev --hosts localhost,127.0.0.1",
+		)
+	}
+	projectRoot := requireProjectRoot()
+	configRoot, err := lalibelaConfigRoot()
 	if err != nil {
 		exitWithError(
-			"Could not detect project framework.",
+			"Could not locate the development CA directory.",
 			fmt.Sprintf("Details: %v", err),
-			"Run this command from a generated Lalibela project root.",
 		)
 	}
 
-	spinner := ui.NewSpinner("Installing feature...")
-	spinner.Start()
-	result, err := features.InstallFeature(projectRoot, framework, featureName, utils.RunCommand)
+	created, err := certs.CreateDev(filepath.Join(configRoot, certs.Dir), filepath.Join(projectRoot, certs.Dir), hosts)
 	if err != nil {
-		spinner.StopError("Feature install failed")
 		exitWithError(
-			fmt.Sprintf("Failed to install feature %q.", featureName),
+			"Could not create the development certificate.",
 			fmt.Sprintf("Details: %v", err),
-			"Run 'lalibela help add' for supported feature names.",
 		)
 	}
-	if !result.Compatible {
-		spinner.StopError("Feature not compatible")
-		fmt.Printf("⚠ Feature '%s' not supported for %s\n", result.Name, framework)
-		return
+	if err := shared.EnsureGitignored(projectRoot, certs.Dir+"/*-key.pem"); err != nil {
+		exitWithError(
+			"Could not update .gitignore.",
+			fmt.Sprintf("Details: %v", err),
+		)
 	}
-	if result.AlreadyPresent {
-		spinner.StopSuccess("No changes needed")
-		fmt.Printf("Feature '%s' is already installed.\n", result.Name)
-		return
+	rel := func(path string) string {
+		if relPath, err := filepath.Rel(projectRoot, path); err == nil {
+			return filepath.ToSlash(relPath)
+		}
+		return path
 	}
-	spinner.StopSuccess("Feature installed")
-	fmt.Printf("Feature '%s' installed successfully.\n", result.Name)
-	fmt.Println("Next:")
-	fmt.Println("  go test ./...")
+
+	fmt.Println(ui.SectionHeader("Development Certificate"))
+	if created.CreatedCA {
+		fmt.Printf("  %s %-13s %s %s\n", ui.Green("✔"), "CA:", created.CAFile, ui.Dim("(new)"))
+	} else {
+		fmt.Printf("  %s %-13s %s %s\n", ui.Green("✔"), "CA:", created.CAFile, ui.Dim("(reused)"))
+	}
+	fmt.Printf("  %s %-13s %s\n", ui.Green("✔"), "Certificate:", rel(created.CertFile))
+	fmt.Printf("  %s %-13s %s %s\n", ui.Green("✔"), "Private key:", rel(created.KeyFile), ui.Dim("(0600, gitignored)"))
+	fmt.Printf("  %-15s %s\n", "Hosts:", strings.Join(created.Hosts, ", "))
+	fmt.Printf("  %-15s %s\n", "Expires:", created.NotAfter.Format("2006-01-02"))
+	fmt.Println()
+	if created.CreatedCA {
+		fmt.Println("  Trust the CA once so browsers accept the certificates of every project:")
+		for _, command := range certs.TrustCommands(runtime.GOOS, created.CAFile) {
+			fmt.Printf("    %s\n", command)
+		}
+		fmt.Println()
+	}
+	fmt.Println("  Serve HTTPS with 'lalibela run --https'.")
+	fmt.Println(ui.Dim("  Other runs enable it with TLS_CERT_FILE and TLS_KEY_FILE."))
 }
 
 func runRunCommand(args []string) {
 	fs := flag.NewFlagSet("run", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 	open := fs.Bool("open", false, "Open browser after server starts")
+	https := fs.Bool("https", false, "Serve HTTPS with the development certificate")
 	showHelp := fs.Bool("help", false, "Show run command help")
 	showHelpShort := fs.Bool("h", false, "Show run command help")
 	if err := fs.Parse(args); err != nil {
@@ -296,7 +1469,7 @@ func runRunCommand(args []string) {
 	if fs.NArg() > 0 {
 		exitWithError(
 			fmt.Sprintf("Unexpected argument %q for 'run' command.", fs.Arg(0)),
-			"Usage: lalibela run [--open]",
+			"Usage: lalibela run [--open] [--https]",
 		)
 	}
 	if _, err := os.Stat("go.mod"); err != nil {
@@ -316,6 +1489,9 @@ func runRunCommand(args []string) {
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
+	if *https {
+		cmd.Env = append(os.Environ(), httpsRunEnv()...)
+	}
 	if err := cmd.Run(); err != nil {
 		exitWithError(
 			"Project run failed.",
@@ -325,6 +1501,40 @@ func runRunCommand(args []string) {
 	}
 }
 
+// httpsRunEnv returns the variables that make the project serve HTTPS with
+// the development certificate, unless the environment already names one,
+// and redirect plain HTTP on the next port.
+func httpsRunEnv() []string {
+	if _, err := os.Stat("tls.go"); err != nil {
+		exitWithError(
+			"This project was generated without HTTPS support.",
+			"Generate a new project with this version of lalibela and copy its tls.go, main.go and startup.go.",
+		)
+	}
+	certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	if certFile == "" || keyFile == "" {
+		certFile = filepath.ToSlash(filepath.Join(certs.Dir, certs.CertFile))
+		keyFile = filepath.ToSlash(filepath.Join(certs.Dir, certs.KeyFile))
+	}
+	for _, path := range []string{certFile, keyFile} {
+		if _, err := os.Stat(path); err != nil {
+			exitWithError(
+				fmt.Sprintf("Could not find the TLS certificate file %s.", path),
+				"Create a development certificate with 'lalibela certs dev'.",
+			)
+		}
+	}
+	env := []string{"TLS_CERT_FILE=" + certFile, "TLS_KEY_FILE=" + keyFile}
+	if os.Getenv("TLS_REDIRECT_ADDR") == "" {
+		port := 8080
+		if parsed, err := strconv.Atoi(strings.TrimSpace(os.Getenv("PORT"))); err == nil && parsed > 0 && parsed < 65535 {
+			port = parsed
+		}
+		env = append(env, fmt.Sprintf("TLS_REDIRECT_ADDR=:%d", port+1))
+	}
+	return env
+}
+
 func runUninstallCommand(args []string) {
 	fs := flag.NewFlagSet("uninstall", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
@@ -495,13 +1705,25 @@ func runHelpCommand(args []string) {
 		exitWithError(
 			"Too many arguments for help command.",
 			"Usage: lalibela help [command]",
-			"Supported commands: add, run, uninstall",
+			"Supported commands: add, remove, features, explain, audit, secrets, certs, run, uninstall",
 		)
 	}
 
 	switch strings.ToLower(strings.TrimSpace(args[0])) {
 	case "add":
 		printAddHelp()
+	case "remove":
+		printRemoveHelp()
+	case "features":
+		printFeaturesHelp()
+	case "explain":
+		printExplainHelp()
+	case "audit":
+		printAuditHelp()
+	case "secrets":
+		printSecretsHelp()
+	case "certs":
+		printCertsHelp()
 	case "run":
 		printRunHelp()
 	case "uninstall":
@@ -511,7 +1733,7 @@ func runHelpCommand(args []string) {
 	default:
 		exitWithError(
 			fmt.Sprintf("Unknown help topic %q.", args[0]),
-			"Supported help topics: add, run, uninstall",
+			"Supported help topics: add, remove, features, explain, audit, secrets, certs, run, uninstall",
 		)
 	}
 }
@@ -581,6 +1803,12 @@ func printRootHelp() {
 	fmt.Println(ui.SectionHeader("Usage"))
 	fmt.Println("  lalibela [flags]")
 	fmt.Println("  lalibela add <feature> [flags]")
+	fmt.Println("  lalibela remove <feature>... [flags]")
+	fmt.Println("  lalibela features [--outdated] [--json]")
+	fmt.Println("  lalibela explain <feature> [flags]")
+	fmt.Println("  lalibela audit [dir] [flags]")
+	fmt.Println("  lalibela secrets rotate [NAME]... | keygen [flags]")
+	fmt.Println("  lalibela certs dev [flags]")
 	fmt.Println("  lalibela run [flags]")
 	fmt.Println("  lalibela uninstall [flags]")
 	fmt.Println("  lalibela help [command]")
@@ -605,7 +1833,14 @@ func printRootHelp() {
 	fmt.Println("  lalibela --yes")
 	fmt.Println("  lalibela -name myapi -framework gin -features \"Clean,Logger,JWT\"")
 	fmt.Println("  lalibela add postgres")
+	fmt.Println("  lalibela remove postgres")
+	fmt.Println("  lalibela features")
+	fmt.Println("  lalibela explain redis")
+	fmt.Println("  lalibela audit --sarif > audit.sarif")
+	fmt.Println("  lalibela secrets rotate JWT_SECRET")
+	fmt.Println("  lalibela certs dev")
 	fmt.Println("  lalibela run --open")
+	fmt.Println("  lalibela run --https")
 	fmt.Println("  lalibela uninstall --force")
 	fmt.Println("  lalibela help add")
 }
@@ -614,39 +1849,256 @@ func printAddHelp() {
 	fmt.Println(ui.Bold(ui.Cyan("Lalibela add")))
 	fmt.Println()
 	fmt.Println(ui.SectionHeader("Usage"))
-	fmt.Println("  lalibela add <feature>")
+	fmt.Println("  lalibela add <feature> [--option value]... [<feature> [--option value]...]...")
 	fmt.Println()
 	fmt.Println(ui.SectionHeader("Description"))
-	fmt.Println("  Installs a production feature into the current Lalibela project.")
+	fmt.Println("  Installs production features into the current Lalibela project.")
+	fmt.Println("  All features are validated before anything is written and installed")
+	fmt.Println("  together after the features they require. go mod tidy runs once at")
+	fmt.Println("  the end; if a feature or tidy fails, every change is rolled back,")
+	fmt.Println("  including go.mod, go.sum and .lalibela/features.json.")
+	fmt.Println("  Middleware, routes and graceful shutdown are wired into main.go and")
+	fmt.Println("  internal/routes/routes.go when the expected code is found.")
+	fmt.Println("  Options not passed as flags are prompted for, or take their defaults")
+	fmt.Println("  with --yes. Chosen values are recorded in .lalibela/features.json.")
+	fmt.Println("  Environment variables the features read are added to .env and")
+	fmt.Println("  .env.example when missing; secrets are left blank in .env.example.")
+	fmt.Println("  Files are written into the directories mapped in .lalibela/layout.json,")
+	fmt.Println("  for example {\"middleware\": \"internal/http/middleware\"}.")
+	fmt.Println("  Existing files that differ from a feature's version are never replaced")
+	fmt.Println("  silently: you are asked to keep, overwrite or write the new version")
+	fmt.Println("  next to them as <file>.lalibela-new. With --yes they are kept unless")
+	fmt.Println("  --on-conflict says otherwise.")
+	fmt.Println("  With --upgrade, installed features are re-rendered at their current")
+	fmt.Println("  version: files unchanged since install are updated, modified files")
+	fmt.Println("  are kept and their diff is shown.")
+	fmt.Println("  A feature that is not built in runs the executable")
+	fmt.Println("  lalibela-feature-<feature> on PATH, which plans and installs it over a")
+	fmt.Println("  JSON protocol on stdin and stdout.")
 	fmt.Println()
 	fmt.Println(ui.SectionHeader("Flags"))
-	fmt.Println("  -h, --help  Show add command help")
+	fmt.Println("  -y, --yes                 Use defaults for options that were not passed")
+	fmt.Println("      --on-conflict <mode>  Handle existing files: keep, overwrite or new")
+	fmt.Println("      --upgrade             Upgrade installed features to their current version")
+	fmt.Println("  -h, --help                Show add command help (after a feature: its options)")
 	fmt.Println()
 	fmt.Println(ui.SectionHeader("Supported features"))
 	fmt.Printf("  %s\n", strings.Join(features.KnownFeatures(), ", "))
 	fmt.Println()
+	fmt.Println(ui.SectionHeader("Feature options"))
+	for _, name := range features.KnownFeatures() {
+		schema, _ := features.FeatureOptions(name)
+		for _, option := range schema {
+			fmt.Printf("  %-11s --%-8s %s (default %s)\n", name, option.Name, option.Description, option.Default)
+		}
+	}
+	fmt.Println()
 	fmt.Println(ui.SectionHeader("Examples"))
 	fmt.Println("  lalibela add config")
-	fmt.Println("  lalibela add auth")
-	fmt.Println("  lalibela add redis")
+	fmt.Println("  lalibela add rate-limit --rps 50 --burst 100")
+	fmt.Println("  lalibela add cors --origins https://app.example.com")
+	fmt.Println("  lalibela add redis --addr cache:6379 --yes")
+	fmt.Println("  lalibela add cors logger redis")
+	fmt.Println("  lalibela add docker --yes --on-conflict new")
+	fmt.Println("  lalibela add --upgrade health logger")
+}
+
+func printFeatureOptionsHelp(featureName string, schema []shared.Option) {
+	fmt.Println(ui.Bold(ui.Cyan("Lalibela add " + featureName)))
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Usage"))
+	usage := "  lalibela add " + featureName
+	for _, option := range schema {
+		usage += fmt.Sprintf(" [--%s <%s>]", option.Name, option.Type)
+	}
+	fmt.Println(usage)
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Options"))
+	if len(schema) == 0 {
+		fmt.Println("  This feature has no options.")
+		return
+	}
+	for _, option := range schema {
+		fmt.Printf("  --%-8s %s (default %s)\n", option.Name, option.Description, option.Default)
+	}
+}
+
+func printRemoveHelp() {
+	fmt.Println(ui.Bold(ui.Cyan("Lalibela remove")))
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Usage"))
+	fmt.Println("  lalibela remove <feature>... [--cascade] [--force]")
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Description"))
+	fmt.Println("  Removes installed features from the current Lalibela project.")
+	fmt.Println("  Only files unchanged since install are deleted; modified files are kept.")
+	fmt.Println("  Wiring added to main.go and routes is reverted, and the variables the")
+	fmt.Println("  feature added to .env and .env.example are removed unless another")
+	fmt.Println("  installed feature reads them.")
+	fmt.Println("  A feature other installed features require is only removed with")
+	fmt.Println("  --cascade, which removes them too, or --force. A failed removal")
+	fmt.Println("  restores the project.")
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Flags"))
+	fmt.Println("  --cascade   Also remove installed features that require the named ones")
+	fmt.Println("  --force     Delete files even if they were modified since install,")
+	fmt.Println("              and remove features other features require")
+	fmt.Println("  -h, --help  Show remove command help")
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Examples"))
+	fmt.Println("  lalibela remove redis")
+	fmt.Println("  lalibela remove cors rate-limit --force")
+	fmt.Println("  lalibela remove auth --cascade")
+}
+
+func printFeaturesHelp() {
+	fmt.Println(ui.Bold(ui.Cyan("Lalibela features")))
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Usage"))
+	fmt.Println("  lalibela features [--outdated] [--json]")
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Description"))
+	fmt.Println("  Lists every feature with its description, framework support,")
+	fmt.Println("  required features, the files it writes and the env vars it reads.")
+	fmt.Println("  Run from a Lalibela project to also see which features are installed.")
+	fmt.Println("  With --outdated, lists installed features whose version is older than")
+	fmt.Println("  this CLI's, to upgrade with 'lalibela add --upgrade <feature>'.")
+	fmt.Println("  Features defined by a feature.json manifest in a subdirectory of")
+	fmt.Println("  ~/.lalibela/features are listed and installed like built-in ones.")
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Flags"))
+	fmt.Println("  --outdated  List installed features with newer versions available")
+	fmt.Println("  --json      Print the catalog as JSON")
+	fmt.Println("  -h, --help  Show features command help")
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Examples"))
+	fmt.Println("  lalibela features")
+	fmt.Println("  lalibela features --json")
+	fmt.Println("  lalibela features --outdated")
+}
+
+func printExplainHelp() {
+	fmt.Println(ui.Bold(ui.Cyan("Lalibela explain")))
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Usage"))
+	fmt.Println("  lalibela explain <feature> [--framework gin|echo|fiber|nethttp]")
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Description"))
+	fmt.Println("  Shows a feature's summary, generated files, environment variables,")
+	fmt.Println("  and how to wire and use it on the project's framework. Installed")
+	fmt.Println("  features are explained with the options they were installed with.")
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Flags"))
+	fmt.Println("  --framework  Explain for this framework instead of the detected one")
+	fmt.Println("  -h, --help   Show explain command help")
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Examples"))
+	fmt.Println("  lalibela explain redis")
+	fmt.Println("  lalibela explain rate-limit --framework fiber")
+}
+
+func printAuditHelp() {
+	fmt.Println(ui.Bold(ui.Cyan("Lalibela audit")))
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Usage"))
+	fmt.Println("  lalibela audit [dir] [--json|--sarif] [--fail-on critical|high|medium|low|none]")
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Description"))
+	fmt.Println("  Statically checks a Go project (default: the current directory) for")
+	fmt.Println("  security issues: weak secrets and .env files git does not ignore,")
+	fmt.Println("  sslmode=disable, wildcard CORS with credentials, servers without")
+	fmt.Println("  timeouts, JWTs read without verification or with unpinned algorithms,")
+	fmt.Println("  and InsecureSkipVerify. Findings are ranked by severity, each with a")
+	fmt.Println("  fix hint. Go test files are not audited.")
+	fmt.Println("  Exits with status 1 when a finding is at least as severe as --fail-on.")
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Flags"))
+	fmt.Println("  --json      Print the report as JSON")
+	fmt.Println("  --sarif     Print the report as SARIF 2.1.0 for code scanning")
+	fmt.Println("  --fail-on   Lowest severity that fails the command (default: high)")
+	fmt.Println("  -h, --help  Show audit command help")
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Examples"))
+	fmt.Println("  lalibela audit")
+	fmt.Println("  lalibela audit ./myapi --json")
+	fmt.Println("  lalibela audit --sarif --fail-on none > audit.sarif")
+}
+
+func printSecretsHelp() {
+	fmt.Println(ui.Bold(ui.Cyan("Lalibela secrets")))
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Usage"))
+	fmt.Println("  lalibela secrets rotate [NAME]...")
+	fmt.Println("  lalibela secrets keygen [--alg RS256|EdDSA]")
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Description"))
+	fmt.Println("  New projects and features fill secrets such as JWT_SECRET and")
+	fmt.Println("  SESSION_SECRET with random values in .env, which is gitignored.")
+	fmt.Println("  rotate gives the named secrets, or all of them, new random values in")
+	fmt.Println("  .env and leaves the placeholders in .env.example as they are.")
+	fmt.Println("  Credentials issued elsewhere, such as DB_PASSWORD, are never rotated.")
+	fmt.Println("  keygen writes a PEM private key for RS256 or EdDSA JWTs, readable by")
+	fmt.Println("  its owner only, and its public key to a JWKS file, and points")
+	fmt.Println("  JWT_PRIVATE_KEY_FILE, JWT_JWKS_FILE and JWT_KID in .env at them.")
+	fmt.Println("  Rotating JWT_PRIVATE_KEY_FILE replaces the key and keeps the old")
+	fmt.Println("  public key in the JWKS so issued tokens verify until they expire.")
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Flags"))
+	fmt.Println("  --alg       keygen algorithm: RS256 or EdDSA (default: EdDSA)")
+	fmt.Println("  -h, --help  Show secrets command help")
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Examples"))
+	fmt.Println("  lalibela secrets rotate")
+	fmt.Println("  lalibela secrets rotate JWT_SECRET SESSION_SECRET")
+	fmt.Println("  lalibela secrets keygen --alg RS256")
+	fmt.Println("  lalibela secrets rotate JWT_PRIVATE_KEY_FILE")
+}
+
+func printCertsHelp() {
+	fmt.Println(ui.Bold(ui.Cyan("Lalibela certs")))
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Usage"))
+	fmt.Println("  lalibela certs dev [--hosts NAME,...]")
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Description"))
+	fmt.Println("  dev creates a local certificate authority in ~/.lalibela/certs, unless")
+	fmt.Println("  a valid one exists, and a certificate it signs for localhost in the")
+	fmt.Println("  project's certs/localhost.pem. Every project reuses the same CA, so")
+	fmt.Println("  it is trusted once per machine with the printed command. Private keys")
+	fmt.Println("  are readable by their owner only; the project's is gitignored. Serve")
+	fmt.Println("  HTTPS with 'lalibela run --https'. The server reloads the certificate")
+	fmt.Println("  when its files change.")
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Flags"))
+	fmt.Println("  --hosts     Names and IP addresses to cover (default: localhost,127.0.0.1,::1)")
+	fmt.Println("  -h, --help  Show certs command help")
+	fmt.Println()
+	fmt.Println(ui.SectionHeader("Examples"))
+	fmt.Println("  lalibela certs dev")
+	fmt.Println("  lalibela certs dev --hosts localhost,127.0.0.1,myapi.test")
 }
 
 func printRunHelp() {
 	fmt.Println(ui.Bold(ui.Cyan("Lalibela run")))
 	fmt.Println()
 	fmt.Println(ui.SectionHeader("Usage"))
-	fmt.Println("  lalibela run [--open]")
+	fmt.Println("  lalibela run [--open] [--https]")
 	fmt.Println()
 	fmt.Println(ui.SectionHeader("Description"))
 	fmt.Println("  Runs the generated project using 'go run .'.")
+	fmt.Println("  With --https it serves HTTPS with the certificate from")
+	fmt.Println("  'lalibela certs dev', or the one TLS_CERT_FILE and TLS_KEY_FILE name,")
+	fmt.Println("  and redirects plain HTTP on the next port to it.")
 	fmt.Println()
 	fmt.Println(ui.SectionHeader("Flags"))
 	fmt.Println("  --open      Open browser after server startup")
+	fmt.Println("  --https     Serve HTTPS with the development certificate")
 	fmt.Println("  -h, --help  Show run command help")
 	fmt.Println()
 	fmt.Println(ui.SectionHeader("Examples"))
 	fmt.Println("  lalibela run")
 	fmt.Println("  lalibela run --open")
+	fmt.Println("  lalibela run --https --open")
 }
 
 func printUninstallHelp() {
@@ -763,6 +2215,23 @@ func printGenerationFailureAndExit(err error) {
 	}
 }
 
+// parseInterspersedFlags parses fs from args while allowing flags to appear
+// after positional arguments, and returns the positional arguments in order.
+func parseInterspersedFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0, len(args))
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
 func exitWithError(message string, suggestions ...string) {
 	fmt.Println(ui.Red("Error: " + message))
 	for _, suggestion := range suggestions {
@@ -847,6 +2316,19 @@ func isBinaryInUse(err error) bool {
 		strings.Contains(text, "being used by another process")
 }
 
+// loadUserFeatures registers the features defined by manifests under
+// ~/.lalibela/features. Problems are reported on stderr so they do not stop
+// commands that do not use the broken features, nor corrupt --json output.
+func loadUserFeatures() {
+	root, err := lalibelaConfigRoot()
+	if err != nil {
+		return
+	}
+	if _, err := features.LoadUserFeatures(filepath.Join(root, "features")); err != nil {
+		fmt.Fprintln(os.Stderr, ui.Yellow(fmt.Sprintf("Some features in %s were not loaded:\n%v", filepath.Join(root, "features"), err)))
+	}
+}
+
 func lalibelaConfigRoot() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
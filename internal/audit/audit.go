@@ -0,0 +1,249 @@
+package audit
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Severity ranks how urgently a finding should be fixed.
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityHigh     Severity = "high"
+	SeverityMedium   Severity = "medium"
+	SeverityLow      Severity = "low"
+)
+
+// Severities lists every severity, most severe first.
+func Severities() []Severity {
+	return []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow}
+}
+
+// rank orders severities; lower is more severe. Unknown severities rank last.
+func (s Severity) rank() int {
+	for i, severity := range Severities() {
+		if s == severity {
+			return i
+		}
+	}
+	return len(Severities())
+}
+
+// AtLeast reports whether s is as severe as other or more.
+func (s Severity) AtLeast(other Severity) bool {
+	return s.rank() <= other.rank()
+}
+
+// ParseSeverity returns the severity named by value.
+func ParseSeverity(value string) (Severity, error) {
+	severity := Severity(strings.ToLower(strings.TrimSpace(value)))
+	if severity.rank() == len(Severities()) {
+		return "", fmt.Errorf("unknown severity %q: must be critical, high, medium or low", value)
+	}
+	return severity, nil
+}
+
+// Rule describes one check.
+type Rule struct {
+	ID          string
+	Description string
+	// Severity is the rule's usual severity; findings may be more severe
+	// when their context makes them worse.
+	Severity Severity
+	// Fix is a hint on how to resolve a finding of the rule.
+	Fix string
+}
+
+// Rules lists every check the audit runs, keyed by rule ID.
+var Rules = map[string]Rule{
+	"weak-secret": {
+		ID:          "weak-secret",
+		Description: "Secret environment variable with a placeholder, short or repetitive value",
+		Severity:    SeverityHigh,
+		Fix:         "Replace the value with at least 32 random bytes, for example the output of `openssl rand -base64 32`.",
+	},
+	"env-not-gitignored": {
+		ID:          "env-not-gitignored",
+		Description: ".env file holding secrets is not ignored by git",
+		Severity:    SeverityHigh,
+		Fix:         "Add `.env` to .gitignore and keep only blank placeholders in .env.example. Rotate any secret that was already committed.",
+	},
+	"db-sslmode-disable": {
+		ID:          "db-sslmode-disable",
+		Description: "PostgreSQL connection with TLS disabled",
+		Severity:    SeverityMedium,
+		Fix:         "Use sslmode=verify-full (or at least require) outside local development, for example from a DB_SSLMODE variable.",
+	},
+	"cors-wildcard-credentials": {
+		ID:          "cors-wildcard-credentials",
+		Description: "CORS allows any origin and credentials",
+		Severity:    SeverityHigh,
+		Fix:         "List the trusted origins explicitly, for example `lalibela add cors --origins https://app.example.com`.",
+	},
+	"cors-wildcard": {
+		ID:          "cors-wildcard",
+		Description: "CORS allows any origin",
+		Severity:    SeverityLow,
+		Fix:         "List the origins allowed to call the API, for example `lalibela add cors --origins https://app.example.com`.",
+	},
+	"missing-timeouts": {
+		ID:          "missing-timeouts",
+		Description: "HTTP server without read, write or idle timeouts",
+		Severity:    SeverityMedium,
+		Fix:         "Serve with an http.Server (or fiber.Config) that sets ReadHeaderTimeout, ReadTimeout, WriteTimeout and IdleTimeout; `lalibela add hardening` sets them.",
+	},
+	"jwt-unverified": {
+		ID:          "jwt-unverified",
+		Description: "JWT read without verifying its signature",
+		Severity:    SeverityCritical,
+		Fix:         "Verify tokens with jwt.ParseWithClaims and a key function; `lalibela add auth` generates a verifying middleware.",
+	},
+	"jwt-none-alg": {
+		ID:          "jwt-none-alg",
+		Description: "JWT parsing accepts unsigned tokens (alg none)",
+		Severity:    SeverityCritical,
+		Fix:         "Remove jwt.UnsafeAllowNoneSignatureType and jwt.SigningMethodNone; accept only the algorithms your keys use.",
+	},
+	"jwt-alg-not-pinned": {
+		ID:          "jwt-alg-not-pinned",
+		Description: "JWT parsing does not restrict the signing algorithm",
+		Severity:    SeverityHigh,
+		Fix:         "Pass jwt.WithValidMethods([]string{\"HS256\"}) (or your key's algorithm) to the parser, or check token.Method in the key function.",
+	},
+	"jwt-claims-unchecked": {
+		ID:          "jwt-claims-unchecked",
+		Description: "JWT parsing skips exp/nbf claim validation",
+		Severity:    SeverityHigh,
+		Fix:         "Drop jwt.WithoutClaimsValidation and require exp with jwt.WithExpirationRequired.",
+	},
+	"tls-insecure-skip-verify": {
+		ID:          "tls-insecure-skip-verify",
+		Description: "TLS client configured to skip certificate verification",
+		Severity:    SeverityHigh,
+		Fix:         "Remove InsecureSkipVerify; trust a private CA through tls.Config.RootCAs instead.",
+	},
+}
+
+// Finding is an issue found in a project file.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	// File is the slash-separated path relative to the project root.
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+	Fix     string `json:"fix"`
+}
+
+// Report holds the findings of an audit, most severe first.
+type Report struct {
+	Findings []Finding `json:"findings"`
+	// Counts holds the number of findings of each severity.
+	Counts map[Severity]int `json:"counts"`
+}
+
+// Worst returns the most severe severity in the report, or "" when it has no
+// findings.
+func (r Report) Worst() Severity {
+	if len(r.Findings) == 0 {
+		return ""
+	}
+	return r.Findings[0].Severity
+}
+
+// skippedDirs are never audited: dependencies, VCS data and lalibela's own
+// state.
+var skippedDirs = map[string]bool{
+	".git":         true,
+	".lalibela":    true,
+	"vendor":       true,
+	"node_modules": true,
+	"testdata":     true,
+}
+
+// Run audits the project rooted at projectRoot. Go test files are skipped,
+// since tests use weak values on purpose.
+func Run(projectRoot string) (Report, error) {
+	if _, err := os.Stat(filepath.Join(projectRoot, "go.mod")); err != nil {
+		return Report{}, fmt.Errorf("%s is not a Go project: %w", projectRoot, err)
+	}
+	ignore, err := loadGitignore(projectRoot)
+	if err != nil {
+		return Report{}, err
+	}
+
+	var findings []Finding
+	packages := make(map[string][]*goFile)
+	err = filepath.WalkDir(projectRoot, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(projectRoot, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if entry.IsDir() {
+			if rel != "." && skippedDirs[entry.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch name := entry.Name(); {
+		case isEnvFile(name):
+			found, err := checkEnvFile(path, rel, ignore)
+			if err != nil {
+				return err
+			}
+			findings = append(findings, found...)
+		case strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go"):
+			file, err := parseGoFile(path, rel)
+			if err != nil {
+				return err
+			}
+			findings = append(findings, file.check()...)
+			dir := filepath.ToSlash(filepath.Dir(rel))
+			packages[dir] = append(packages[dir], file)
+		}
+		return nil
+	})
+	if err != nil {
+		return Report{}, err
+	}
+	for _, files := range packages {
+		findings = append(findings, checkCORS(files)...)
+	}
+	return newReport(findings), nil
+}
+
+// newReport fills in the rules' fix hints and sorts findings by severity,
+// then by location.
+func newReport(findings []Finding) Report {
+	report := Report{Findings: findings, Counts: make(map[Severity]int)}
+	for i, finding := range report.Findings {
+		if finding.Fix == "" {
+			report.Findings[i].Fix = Rules[finding.Rule].Fix
+		}
+		report.Counts[finding.Severity]++
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.Severity.rank() != b.Severity.rank() {
+			return a.Severity.rank() < b.Severity.rank()
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	if report.Findings == nil {
+		report.Findings = []Finding{}
+	}
+	return report
+}
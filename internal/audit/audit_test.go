@@ -0,0 +1,370 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeProject writes files below a temporary project root with a go.mod.
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	files["go.mod"] = "module demo\n\ngo 1.22\n"
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// findingsByRule indexes a report's findings by rule ID.
+func findingsByRule(report Report) map[string][]Finding {
+	byRule := make(map[string][]Finding)
+	for _, finding := range report.Findings {
+		byRule[finding.Rule] = append(byRule[finding.Rule], finding)
+	}
+	return byRule
+}
+
+const insecureMain = `package main
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func main() {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Header("Access-Control-Allow-Origin", "*")
+		c.Header("Access-Control-Allow-Credentials", "true")
+	})
+	listener, _ := net.Listen("tcp", ":8080")
+	_ = r.RunListener(listener)
+	_ = http.ListenAndServe(":9090", nil)
+}
+`
+
+const insecureJWT = `package main
+
+import (
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const dsn = "host=localhost sslmode=disable"
+
+func parseUnpinned(raw string, key []byte) (*jwt.Token, error) {
+	return jwt.Parse(raw, func(*jwt.Token) (any, error) { return key, nil })
+}
+
+func peek(raw string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(raw, claims)
+	return claims, err
+}
+
+func jwtFromHeader(header func(string) string) string {
+	return strings.TrimPrefix(header("Authorization"), "Bearer ")
+}
+`
+
+const secureJWT = `package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var parser = jwt.NewParser(jwt.WithValidMethods([]string{"HS256"}))
+
+func verify(raw string, key []byte) (*jwt.Token, error) {
+	return parser.Parse(raw, func(*jwt.Token) (any, error) { return key, nil })
+}
+
+func verifyMethod(raw string, key []byte) (*jwt.Token, error) {
+	return jwt.Parse(raw, func(token *jwt.Token) (any, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return key, nil
+	})
+}
+
+func jwtMiddleware(next http.Handler, key []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := verify(r.Header.Get("Authorization"), key); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func serve(handler http.Handler) error {
+	srv := &http.Server{
+		Addr:              ":8080",
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+	return srv.ListenAndServe()
+}
+`
+
+func TestRunReportsInsecureProject(t *testing.T) {
+	root := writeProject(t, map[string]string{
+		".env":         "JWT_SECRET=dev-secret-change-me\nDB_PASSWORD=password\nPORT=8080\nACCESS_TOKEN_TTL=15m\nAPI_KEYS_FILE=keys.json\nDATABASE_URL=postgres://u@h/db?sslmode=disable\nDB_SSLMODE=disable\n",
+		".env.example": "JWT_SECRET=changeme\n",
+		"main.go":      insecureMain,
+		"jwt.go":       insecureJWT,
+		"main_test.go": "package main\n\nconst testDSN = \"sslmode=disable\"\n",
+	})
+
+	report, err := Run(root)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	byRule := findingsByRule(report)
+
+	want := map[string]Severity{
+		"weak-secret":               SeverityCritical,
+		"env-not-gitignored":        SeverityHigh,
+		"db-sslmode-disable":        SeverityMedium,
+		"cors-wildcard-credentials": SeverityHigh,
+		"missing-timeouts":          SeverityMedium,
+		"jwt-unverified":            SeverityCritical,
+		"jwt-alg-not-pinned":        SeverityHigh,
+	}
+	for rule, severity := range want {
+		findings := byRule[rule]
+		if len(findings) == 0 {
+			t.Errorf("no %s finding in %+v", rule, report.Findings)
+			continue
+		}
+		for _, finding := range findings {
+			if finding.Severity != severity {
+				t.Errorf("%s severity = %s, want %s", rule, finding.Severity, severity)
+			}
+			if finding.Fix == "" {
+				t.Errorf("%s finding has no fix hint", rule)
+			}
+		}
+	}
+	if got := len(byRule["weak-secret"]); got != 2 {
+		t.Errorf("weak-secret findings = %d, want 2 (JWT_SECRET and DB_PASSWORD)", got)
+	}
+	if got := len(byRule["db-sslmode-disable"]); got != 3 {
+		t.Errorf("db-sslmode-disable findings = %d, want 3 (DATABASE_URL and DB_SSLMODE in .env and jwt.go, not the test file)", got)
+	}
+	if got := len(byRule["missing-timeouts"]); got != 2 {
+		t.Errorf("missing-timeouts findings = %d, want 2 (RunListener and ListenAndServe)", got)
+	}
+	if got := len(byRule["jwt-unverified"]); got != 2 {
+		t.Errorf("jwt-unverified findings = %d, want 2 (ParseUnverified and jwtFromHeader)", got)
+	}
+	if _, ok := byRule["cors-wildcard"]; ok {
+		t.Error("wildcard CORS with credentials is also reported as plain cors-wildcard")
+	}
+	for _, finding := range report.Findings {
+		if finding.File == ".env.example" || finding.File == "main_test.go" {
+			t.Errorf("finding in skipped file: %+v", finding)
+		}
+	}
+
+	if report.Worst() != SeverityCritical {
+		t.Errorf("Worst() = %s, want critical", report.Worst())
+	}
+	for i := 1; i < len(report.Findings); i++ {
+		if report.Findings[i].Severity.rank() < report.Findings[i-1].Severity.rank() {
+			t.Fatalf("findings not sorted by severity: %+v", report.Findings)
+		}
+	}
+	total := 0
+	for _, count := range report.Counts {
+		total += count
+	}
+	if total != len(report.Findings) {
+		t.Errorf("counts add up to %d, want %d", total, len(report.Findings))
+	}
+}
+
+func TestRunGitignoredEnvIsLessSevere(t *testing.T) {
+	root := writeProject(t, map[string]string{
+		".gitignore": "bin/\n.env\n",
+		".env":       "JWT_SECRET=changeme\nAPI_KEY=Zr8v1Qx0pLm3Nc7TbY2sKd5W\n",
+	})
+
+	report, err := Run(root)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	byRule := findingsByRule(report)
+	if _, ok := byRule["env-not-gitignored"]; ok {
+		t.Errorf("ignored .env reported as not gitignored: %+v", report.Findings)
+	}
+	weak := byRule["weak-secret"]
+	if len(weak) != 1 || weak[0].Severity != SeverityHigh || weak[0].Line != 1 {
+		t.Errorf("weak-secret findings = %+v, want one high finding on line 1", weak)
+	}
+}
+
+func TestRunAcceptsVerifyingProject(t *testing.T) {
+	root := writeProject(t, map[string]string{
+		"jwt.go": secureJWT,
+		"internal/server/cors.go": `package server
+
+import "github.com/gin-contrib/cors"
+
+var corsConfig = cors.Config{
+	AllowOrigins:     []string{"https://app.example.com"},
+	AllowCredentials: true,
+}
+`,
+	})
+
+	report, err := Run(root)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(report.Findings) != 0 {
+		t.Errorf("findings = %+v, want none", report.Findings)
+	}
+	if report.Worst() != "" {
+		t.Errorf("Worst() = %q, want empty", report.Worst())
+	}
+}
+
+func TestRunRequiresGoModule(t *testing.T) {
+	if _, err := Run(t.TempDir()); err == nil {
+		t.Fatal("Run succeeded outside a Go module")
+	}
+}
+
+func TestWeakSecret(t *testing.T) {
+	tests := []struct {
+		value string
+		weak  bool
+	}{
+		{"changeme", true},
+		{"dev-secret-change-me-please-now", true},
+		{"short", true},
+		{"aaaabbbbaaaabbbbaaaabbbb", true},
+		{"Zr8v1Qx0pLm3Nc7TbY2sKd5W", false},
+	}
+	for _, tt := range tests {
+		if got := weakSecret(tt.value) != ""; got != tt.weak {
+			t.Errorf("weakSecret(%q) weak = %v, want %v", tt.value, got, tt.weak)
+		}
+	}
+}
+
+func TestGitignoreMatches(t *testing.T) {
+	ignore := gitignore{".env*", "!.env.example", "/bin/", "**/secrets/*.pem"}
+	tests := []struct {
+		path    string
+		ignored bool
+	}{
+		{".env", true},
+		{"config/.env.local", true},
+		{".env.example", false},
+		{"bin/server", true},
+		{"cmd/bin/server", false},
+		{"deploy/secrets/key.pem", true},
+		{"main.go", false},
+	}
+	for _, tt := range tests {
+		if got := ignore.matches(tt.path); got != tt.ignored {
+			t.Errorf("matches(%q) = %v, want %v", tt.path, got, tt.ignored)
+		}
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	severity, err := ParseSeverity(" HIGH ")
+	if err != nil || severity != SeverityHigh {
+		t.Fatalf("ParseSeverity = %q, %v", severity, err)
+	}
+	if _, err := ParseSeverity("urgent"); err == nil {
+		t.Fatal("ParseSeverity accepted an unknown severity")
+	}
+	if !SeverityCritical.AtLeast(SeverityHigh) || SeverityLow.AtLeast(SeverityMedium) {
+		t.Fatal("AtLeast does not follow the severity order")
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	report := newReport([]Finding{
+		{Rule: "cors-wildcard", Severity: SeverityLow, File: "internal/server/cors.go", Line: 12, Message: "any origin"},
+		{Rule: "weak-secret", Severity: SeverityCritical, File: ".env", Line: 3, Message: "JWT_SECRET is short"},
+	})
+
+	var buf bytes.Buffer
+	if err := report.WriteSARIF(&buf, "1.2.3"); err != nil {
+		t.Fatalf("WriteSARIF: %v", err)
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Version string `json:"version"`
+					Rules   []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("SARIF is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected SARIF log: %s", buf.String())
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Version != "1.2.3" || len(run.Tool.Driver.Rules) != len(Rules) {
+		t.Errorf("driver = %+v", run.Tool.Driver)
+	}
+	if len(run.Results) != 2 {
+		t.Fatalf("results = %+v", run.Results)
+	}
+	first, second := run.Results[0], run.Results[1]
+	if first.RuleID != "weak-secret" || first.Level != "error" || first.Locations[0].PhysicalLocation.Region.StartLine != 3 {
+		t.Errorf("first result = %+v", first)
+	}
+	if second.Level != "note" || second.Locations[0].PhysicalLocation.ArtifactLocation.URI != "internal/server/cors.go" {
+		t.Errorf("second result = %+v", second)
+	}
+}
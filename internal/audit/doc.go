@@ -0,0 +1,5 @@
+// Package audit statically checks a generated project for insecure defaults:
+// weak secrets in .env files, wildcard CORS, unencrypted database connections,
+// servers without timeouts and JWT handling that skips verification. Checks
+// read files and parse Go sources with go/ast; nothing is built or run.
+package audit
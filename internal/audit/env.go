@@ -0,0 +1,224 @@
+package audit
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// isEnvFile reports whether name is a dotenv file holding real values.
+// Templates such as .env.example are meant to be committed and are skipped.
+func isEnvFile(name string) bool {
+	if name == ".env" {
+		return true
+	}
+	if !strings.HasPrefix(name, ".env.") {
+		return false
+	}
+	switch strings.TrimPrefix(name, ".env.") {
+	case "example", "sample", "template", "dist":
+		return false
+	}
+	return true
+}
+
+// secretName matches variable names that hold secrets.
+var secretName = regexp.MustCompile(`(?i)(SECRET|PASSWORD|PASSWD|TOKEN|API_?KEY|PRIVATE_?KEY|PEPPER)`)
+
+// settingSuffix matches variables that configure a secret rather than hold
+// one, such as ACCESS_TOKEN_TTL or API_KEYS_FILE.
+var settingSuffix = regexp.MustCompile(`(?i)_(TTL|FILE|PATH|DIR|URL|KID|ID|NAME|HEADER|TIMEOUT|EXPIRY|LIFETIME|LENGTH|ALG|ALGORITHM)$`)
+
+// placeholderSecrets are values that ship in examples and tutorials.
+// Longer placeholders come first so the most specific one is reported.
+var placeholderSecrets = []string{
+	"supersecret", "changeme", "change-me", "change_me", "password", "passw0rd", "12345678", "letmein",
+	"default", "example", "secret", "123456", "qwerty", "admin", "root", "test", "dev",
+}
+
+// minSecretLength is the shortest value not reported as a weak secret.
+const minSecretLength = 16
+
+// weakSecret returns why value is a weak secret, or "" when it is not.
+func weakSecret(value string) string {
+	lower := strings.ToLower(value)
+	for _, placeholder := range placeholderSecrets {
+		if lower == placeholder || (len(placeholder) >= 6 && strings.Contains(lower, placeholder)) {
+			return fmt.Sprintf("contains the well-known placeholder %q", placeholder)
+		}
+	}
+	if len(value) < minSecretLength {
+		return fmt.Sprintf("is only %d characters long", len(value))
+	}
+	distinct := make(map[rune]bool)
+	for _, r := range value {
+		distinct[r] = true
+	}
+	if len(distinct) < 8 {
+		return fmt.Sprintf("uses only %d distinct characters", len(distinct))
+	}
+	return ""
+}
+
+// checkEnvFile reports weak secrets and secrets in a file git does not
+// ignore, as well as connection strings and DB_SSLMODE settings that disable
+// TLS.
+func checkEnvFile(fullPath, rel string, ignore gitignore) ([]Finding, error) {
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ignored := ignore.matches(rel)
+	var findings []Finding
+	secretLine := 0
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		name, value, ok := parseEnvLine(scanner.Text())
+		if !ok {
+			continue
+		}
+		if strings.Contains(strings.ToLower(value), "sslmode=disable") || (name == "DB_SSLMODE" && strings.EqualFold(value, "disable")) {
+			findings = append(findings, Finding{
+				Rule:     "db-sslmode-disable",
+				Severity: SeverityMedium,
+				File:     rel,
+				Line:     line,
+				Message:  fmt.Sprintf("%s connects to PostgreSQL with sslmode=disable, sending credentials and data in plain text", name),
+			})
+		}
+		if value == "" || !secretName.MatchString(name) || settingSuffix.MatchString(name) {
+			continue
+		}
+		if secretLine == 0 {
+			secretLine = line
+		}
+		reason := weakSecret(value)
+		if reason == "" {
+			continue
+		}
+		finding := Finding{
+			Rule:     "weak-secret",
+			Severity: SeverityHigh,
+			File:     rel,
+			Line:     line,
+			Message:  fmt.Sprintf("%s %s", name, reason),
+		}
+		if !ignored {
+			finding.Severity = SeverityCritical
+			finding.Message += ", in a file git does not ignore"
+		}
+		findings = append(findings, finding)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", rel, err)
+	}
+
+	if secretLine > 0 && !ignored {
+		findings = append(findings, Finding{
+			Rule:     "env-not-gitignored",
+			Severity: SeverityHigh,
+			File:     rel,
+			Line:     secretLine,
+			Message:  fmt.Sprintf("%s holds secrets but is not listed in .gitignore, so it can be committed", rel),
+		})
+	}
+	return findings, nil
+}
+
+// parseEnvLine splits a KEY=value line, dropping an export prefix, quotes
+// and trailing comments of unquoted values.
+func parseEnvLine(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+	line = strings.TrimPrefix(line, "export ")
+	name, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return "", "", false
+	}
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	} else if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return strings.TrimSpace(name), value, true
+}
+
+// gitignore holds the patterns of a project's root .gitignore. It supports
+// the common forms: globs, anchored and directory patterns, ** prefixes and
+// negation.
+type gitignore []string
+
+func loadGitignore(projectRoot string) (gitignore, error) {
+	raw, err := os.ReadFile(filepath.Join(projectRoot, ".gitignore"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var patterns gitignore
+	for _, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns, nil
+}
+
+// matches reports whether the slash-separated path rel is ignored. As in git,
+// the last matching pattern wins.
+func (g gitignore) matches(rel string) bool {
+	ignored := false
+	for _, pattern := range g {
+		negated := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if matchIgnorePattern(pattern, rel) {
+			ignored = !negated
+		}
+	}
+	return ignored
+}
+
+func matchIgnorePattern(pattern, rel string) bool {
+	if rest, ok := strings.CutPrefix(pattern, "**/"); ok {
+		// A leading **/ matches the rest of the pattern in any directory.
+		parts := strings.Split(rel, "/")
+		for i := range parts {
+			if matchIgnorePattern("/"+rest, strings.Join(parts[i:], "/")) {
+				return true
+			}
+		}
+		return false
+	}
+	if dir, ok := strings.CutSuffix(pattern, "/"); ok {
+		// Directory patterns ignore everything below a matching directory.
+		parts := strings.Split(rel, "/")
+		for i := 1; i < len(parts); i++ {
+			if matchIgnorePattern(dir, strings.Join(parts[:i], "/")) {
+				return true
+			}
+		}
+		return false
+	}
+	if strings.Contains(strings.TrimPrefix(pattern, "/"), "/") || strings.HasPrefix(pattern, "/") {
+		ok, _ := path.Match(strings.TrimPrefix(pattern, "/"), rel)
+		return ok
+	}
+	// Patterns without a slash match a file or directory name at any depth.
+	for _, part := range strings.Split(rel, "/") {
+		if ok, _ := path.Match(pattern, part); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,514 @@
+package audit
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// goFile is a parsed Go source file of the audited project.
+type goFile struct {
+	rel  string
+	fset *token.FileSet
+	file *ast.File
+	// imports maps the names the file refers to imported packages by to
+	// their import paths.
+	imports map[string]string
+	// frameworkApps caches the result of apps.
+	frameworkApps map[string]string
+}
+
+func parseGoFile(fullPath, rel string) (*goFile, error) {
+	src, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, fullPath, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", rel, err)
+	}
+	imports := make(map[string]string)
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		name := importPath[strings.LastIndex(importPath, "/")+1:]
+		if strings.HasPrefix(name, "v") && len(name) > 1 && strings.Trim(name[1:], "0123456789") == "" {
+			trimmed := strings.TrimSuffix(importPath, "/"+name)
+			name = trimmed[strings.LastIndex(trimmed, "/")+1:]
+		}
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = importPath
+	}
+	return &goFile{rel: rel, fset: fset, file: file, imports: imports}, nil
+}
+
+func (f *goFile) finding(rule string, severity Severity, node ast.Node, format string, args ...any) Finding {
+	return Finding{
+		Rule:     rule,
+		Severity: severity,
+		File:     f.rel,
+		Line:     f.fset.Position(node.Pos()).Line,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// pkgSelector returns the package path and name of a pkg.Name expression.
+func (f *goFile) pkgSelector(expr ast.Expr) (string, string, bool) {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return "", "", false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return "", "", false
+	}
+	importPath, ok := f.imports[pkg.Name]
+	return importPath, sel.Sel.Name, ok
+}
+
+// isJWTPackage reports whether importPath is a golang-jwt (or its dgrijalva
+// predecessor) package.
+func isJWTPackage(importPath string) bool {
+	return strings.HasPrefix(importPath, "github.com/golang-jwt/jwt") || strings.HasPrefix(importPath, "github.com/dgrijalva/jwt-go")
+}
+
+// check runs the per-file Go checks.
+func (f *goFile) check() []Finding {
+	var findings []Finding
+	findings = append(findings, f.checkStrings()...)
+	findings = append(findings, f.checkTimeouts()...)
+	findings = append(findings, f.checkJWT()...)
+	return findings
+}
+
+// checkStrings reports connection strings that disable TLS and TLS configs
+// that skip certificate checks.
+func (f *goFile) checkStrings() []Finding {
+	var findings []Finding
+	ast.Inspect(f.file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.BasicLit:
+			if node.Kind == token.STRING && strings.Contains(strings.ToLower(node.Value), "sslmode=disable") {
+				findings = append(findings, f.finding("db-sslmode-disable", SeverityMedium, node,
+					"PostgreSQL connection string sets sslmode=disable, sending credentials and data in plain text"))
+			}
+		case *ast.KeyValueExpr:
+			key, ok := node.Key.(*ast.Ident)
+			if ok && key.Name == "InsecureSkipVerify" && isTrue(node.Value) {
+				findings = append(findings, f.finding("tls-insecure-skip-verify", SeverityHigh, node,
+					"tls.Config sets InsecureSkipVerify, so any certificate is accepted"))
+			}
+		}
+		return true
+	})
+	return findings
+}
+
+// serverTimeouts are the http.Server fields bounding how long a connection
+// may take.
+var serverTimeouts = []string{"ReadHeaderTimeout", "ReadTimeout", "WriteTimeout", "IdleTimeout"}
+
+// checkTimeouts reports servers started without timeouts: http.Server
+// literals missing them, the net/http helpers that cannot set them, gin's
+// Run methods, echo's Start methods and fiber apps created without them.
+func (f *goFile) checkTimeouts() []Finding {
+	var findings []Finding
+	ast.Inspect(f.file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.CompositeLit:
+			importPath, name, ok := f.pkgSelector(node.Type)
+			if !ok || name != "Server" && name != "Config" {
+				return true
+			}
+			if importPath == "net/http" && name == "Server" {
+				if finding, ok := f.checkServerLiteral(node); ok {
+					findings = append(findings, finding)
+				}
+			}
+			if strings.HasPrefix(importPath, "github.com/gofiber/fiber") && name == "Config" {
+				if missing := missingFields(node, "ReadTimeout", "WriteTimeout", "IdleTimeout"); len(missing) > 0 {
+					severity := SeverityLow
+					if contains(missing, "ReadTimeout") {
+						severity = SeverityMedium
+					}
+					findings = append(findings, f.finding("missing-timeouts", severity, node,
+						"fiber.Config does not set %s", strings.Join(missing, ", ")))
+				}
+			}
+		case *ast.CallExpr:
+			if finding, ok := f.checkServeCall(node); ok {
+				findings = append(findings, finding)
+			}
+		}
+		return true
+	})
+	return findings
+}
+
+func (f *goFile) checkServerLiteral(lit *ast.CompositeLit) (Finding, bool) {
+	missing := missingFields(lit, serverTimeouts...)
+	if len(missing) == 0 {
+		return Finding{}, false
+	}
+	// Either read timeout stops slow clients from holding connections open.
+	if contains(missing, "ReadHeaderTimeout") && contains(missing, "ReadTimeout") {
+		return f.finding("missing-timeouts", SeverityMedium, lit,
+			"http.Server sets no ReadHeaderTimeout or ReadTimeout, so slow clients can hold connections open forever (Slowloris)"), true
+	}
+	var unset []string
+	for _, field := range missing {
+		if field == "WriteTimeout" || field == "IdleTimeout" {
+			unset = append(unset, field)
+		}
+	}
+	if len(unset) == 0 {
+		return Finding{}, false
+	}
+	return f.finding("missing-timeouts", SeverityLow, lit, "http.Server does not set %s", strings.Join(unset, " or ")), true
+}
+
+func (f *goFile) checkServeCall(call *ast.CallExpr) (Finding, bool) {
+	if importPath, name, ok := f.pkgSelector(call.Fun); ok {
+		switch {
+		case importPath == "net/http" && (name == "ListenAndServe" || name == "ListenAndServeTLS" || name == "Serve" || name == "ServeTLS"):
+			return f.finding("missing-timeouts", SeverityMedium, call,
+				"http.%s serves without any timeouts; use an http.Server with timeouts", name), true
+		case strings.HasPrefix(importPath, "github.com/gofiber/fiber") && name == "New" && len(call.Args) == 0:
+			return f.finding("missing-timeouts", SeverityMedium, call,
+				"fiber.New() creates an app without ReadTimeout, WriteTimeout or IdleTimeout"), true
+		}
+		return Finding{}, false
+	}
+
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return Finding{}, false
+	}
+	receiver, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return Finding{}, false
+	}
+	switch f.apps()[receiver.Name] {
+	case "gin":
+		if sel.Sel.Name == "Run" || sel.Sel.Name == "RunListener" || sel.Sel.Name == "RunTLS" {
+			return f.finding("missing-timeouts", SeverityMedium, call,
+				"gin's %s serves without any timeouts; serve the engine with an http.Server with timeouts", sel.Sel.Name), true
+		}
+	case "echo":
+		if sel.Sel.Name == "Start" || sel.Sel.Name == "StartTLS" {
+			return f.finding("missing-timeouts", SeverityMedium, call,
+				"echo's %s serves without any timeouts; use StartServer with an http.Server with timeouts", sel.Sel.Name), true
+		}
+	}
+	return Finding{}, false
+}
+
+// apps maps the variables assigned a gin or echo app in the file to the
+// app's framework.
+func (f *goFile) apps() map[string]string {
+	if f.frameworkApps != nil {
+		return f.frameworkApps
+	}
+	f.frameworkApps = make(map[string]string)
+	ast.Inspect(f.file, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != len(assign.Rhs) {
+			return true
+		}
+		for i, rhs := range assign.Rhs {
+			call, ok := rhs.(*ast.CallExpr)
+			ident, isIdent := assign.Lhs[i].(*ast.Ident)
+			if !ok || !isIdent {
+				continue
+			}
+			importPath, name, ok := f.pkgSelector(call.Fun)
+			switch {
+			case !ok:
+			case importPath == "github.com/gin-gonic/gin" && (name == "New" || name == "Default"):
+				f.frameworkApps[ident.Name] = "gin"
+			case strings.HasPrefix(importPath, "github.com/labstack/echo") && name == "New":
+				f.frameworkApps[ident.Name] = "echo"
+			}
+		}
+		return true
+	})
+	return f.frameworkApps
+}
+
+// missingFields returns the fields of names that lit does not set.
+func missingFields(lit *ast.CompositeLit, names ...string) []string {
+	set := make(map[string]bool)
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if key, ok := kv.Key.(*ast.Ident); ok {
+				set[key.Name] = true
+			}
+		}
+	}
+	var missing []string
+	for _, name := range names {
+		if !set[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// checkJWT reports JWTs that are parsed without verification, accept alg
+// none or any algorithm, or skip the claim checks, and JWT middleware that
+// never verifies the token it reads.
+func (f *goFile) checkJWT() []Finding {
+	var findings []Finding
+	for _, decl := range f.file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		pinned := f.callsJWT(fn.Body, "WithValidMethods")
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.SelectorExpr:
+				importPath, name, ok := f.pkgSelector(node)
+				if !ok || !isJWTPackage(importPath) {
+					return true
+				}
+				switch name {
+				case "UnsafeAllowNoneSignatureType", "SigningMethodNone":
+					findings = append(findings, f.finding("jwt-none-alg", SeverityCritical, node,
+						"%s uses jwt.%s, accepting tokens without a signature", fn.Name.Name, name))
+				case "WithoutClaimsValidation":
+					findings = append(findings, f.finding("jwt-claims-unchecked", SeverityHigh, node,
+						"%s parses JWTs with jwt.WithoutClaimsValidation, accepting expired tokens", fn.Name.Name))
+				}
+			case *ast.CallExpr:
+				sel, ok := node.Fun.(*ast.SelectorExpr)
+				if !ok || !f.importsJWT() {
+					return true
+				}
+				switch sel.Sel.Name {
+				case "ParseUnverified":
+					findings = append(findings, f.finding("jwt-unverified", SeverityCritical, node,
+						"%s reads JWT claims with ParseUnverified, which skips the signature check", fn.Name.Name))
+				case "Parse", "ParseWithClaims":
+					// jwt.Parse pins the algorithm with options passed in the
+					// same function; a jwt.Parser with options given anywhere
+					// in the file.
+					checked := pinned
+					if importPath, _, ok := f.pkgSelector(sel); ok {
+						if !isJWTPackage(importPath) {
+							return true
+						}
+					} else if f.callsJWT(f.file, "NewParser") {
+						checked = f.callsJWT(f.file, "WithValidMethods")
+					} else {
+						return true
+					}
+					if !checked && !f.keyFuncChecksMethod(node) {
+						findings = append(findings, f.finding("jwt-alg-not-pinned", SeverityHigh, node,
+							"%s parses JWTs without jwt.WithValidMethods, so the token picks the algorithm its key is used with", fn.Name.Name))
+					}
+				}
+			}
+			return true
+		})
+
+		if finding, ok := f.checkJWTMiddleware(fn); ok {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+func (f *goFile) importsJWT() bool {
+	for _, importPath := range f.imports {
+		if isJWTPackage(importPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// callsJWT reports whether node calls the jwt package's function name.
+func (f *goFile) callsJWT(node ast.Node, name string) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if importPath, fn, ok := f.pkgSelector(call.Fun); ok && isJWTPackage(importPath) && fn == name {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// keyFuncChecksMethod reports whether the key function passed to a Parse
+// call is a literal that inspects token.Method, which pins the algorithm as
+// well as jwt.WithValidMethods does. Key functions declared elsewhere are
+// looked up by name in the same file.
+func (f *goFile) keyFuncChecksMethod(call *ast.CallExpr) bool {
+	for _, arg := range call.Args {
+		var body ast.Node
+		switch keyFunc := arg.(type) {
+		case *ast.FuncLit:
+			body = keyFunc.Body
+		case *ast.Ident:
+			body = f.funcBody(keyFunc.Name)
+		case *ast.SelectorExpr:
+			body = f.funcBody(keyFunc.Sel.Name)
+		}
+		if body != nil && referencesField(body, "Method") {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *goFile) funcBody(name string) ast.Node {
+	for _, decl := range f.file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Name == name && fn.Body != nil {
+			return fn.Body
+		}
+	}
+	return nil
+}
+
+func referencesField(node ast.Node, field string) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok && sel.Sel.Name == field {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// checkJWTMiddleware reports functions named like JWT middleware that read
+// the Authorization header but never call anything that parses, verifies or
+// validates the token.
+func (f *goFile) checkJWTMiddleware(fn *ast.FuncDecl) (Finding, bool) {
+	name := strings.ToLower(fn.Name.Name)
+	if !strings.Contains(name, "jwt") || strings.Contains(name, "parse") || strings.Contains(name, "verify") || strings.Contains(name, "validate") {
+		return Finding{}, false
+	}
+	readsHeader, verifies := false, false
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.BasicLit:
+			if node.Kind == token.STRING && strings.EqualFold(strings.Trim(node.Value, "`\""), "Authorization") {
+				readsHeader = true
+			}
+		case *ast.CallExpr:
+			var called string
+			switch fun := node.Fun.(type) {
+			case *ast.Ident:
+				called = fun.Name
+			case *ast.SelectorExpr:
+				called = fun.Sel.Name
+			}
+			called = strings.ToLower(called)
+			if strings.Contains(called, "parse") || strings.Contains(called, "verify") || strings.Contains(called, "validate") {
+				verifies = true
+			}
+		}
+		return true
+	})
+	if !readsHeader || verifies {
+		return Finding{}, false
+	}
+	return f.finding("jwt-unverified", SeverityCritical, fn.Name,
+		"%s reads the Authorization header but never parses or verifies the token", fn.Name.Name), true
+}
+
+// checkCORS reports a package that allows any origin, which is high
+// severity when it also allows credentials. files are one package's files.
+func checkCORS(files []*goFile) []Finding {
+	var wildcard *Finding
+	credentials := false
+	for _, f := range files {
+		ast.Inspect(f.file, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.BasicLit:
+				if node.Kind == token.STRING && strings.EqualFold(strings.Trim(node.Value, "`\""), "Access-Control-Allow-Credentials") {
+					credentials = true
+				}
+			case *ast.KeyValueExpr:
+				key, ok := node.Key.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				if key.Name == "AllowCredentials" && isTrue(node.Value) {
+					credentials = true
+				}
+				allowAll := key.Name == "AllowAllOrigins" && isTrue(node.Value)
+				if strings.Contains(strings.ToLower(key.Name), "origin") && wildcard == nil && (allowAll || containsWildcard(node.Value)) {
+					finding := f.finding("cors-wildcard", SeverityLow, node, "%s allows requests from any origin", key.Name)
+					wildcard = &finding
+				}
+			case *ast.ValueSpec:
+				for i, name := range node.Names {
+					if i < len(node.Values) && strings.Contains(strings.ToLower(name.Name), "origin") && wildcard == nil && containsWildcard(node.Values[i]) {
+						finding := f.finding("cors-wildcard", SeverityLow, node, "%s allows requests from any origin", name.Name)
+						wildcard = &finding
+					}
+				}
+			case *ast.CallExpr:
+				if len(node.Args) == 2 && isStringLit(node.Args[0], "Access-Control-Allow-Origin") && isStringLit(node.Args[1], "*") && wildcard == nil {
+					finding := f.finding("cors-wildcard", SeverityLow, node, "Access-Control-Allow-Origin is set to *")
+					wildcard = &finding
+				}
+			}
+			return true
+		})
+	}
+	if wildcard == nil {
+		return nil
+	}
+	if credentials {
+		wildcard.Rule = "cors-wildcard-credentials"
+		wildcard.Severity = SeverityHigh
+		wildcard.Message += " and allows credentials, so any site can make authenticated requests and read the responses"
+	}
+	return []Finding{*wildcard}
+}
+
+func containsWildcard(expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if isStringLit(n, "*") {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func isTrue(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "true"
+}
+
+func isStringLit(node ast.Node, value string) bool {
+	lit, ok := node.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return false
+	}
+	unquoted, err := strconv.Unquote(lit.Value)
+	return err == nil && unquoted == value
+}
+
+func contains(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
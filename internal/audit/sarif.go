@@ -0,0 +1,137 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// sarifLevels maps severities to SARIF result levels.
+var sarifLevels = map[Severity]string{
+	SeverityCritical: "error",
+	SeverityHigh:     "error",
+	SeverityMedium:   "warning",
+	SeverityLow:      "note",
+}
+
+// securitySeverity maps severities to the CVSS-like scores GitHub code
+// scanning ranks security alerts by.
+var securitySeverity = map[Severity]string{
+	SeverityCritical: "9.5",
+	SeverityHigh:     "8.0",
+	SeverityMedium:   "5.5",
+	SeverityLow:      "3.0",
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string         `json:"id"`
+	ShortDescription sarifText      `json:"shortDescription"`
+	Help             sarifText      `json:"help"`
+	Properties       map[string]any `json:"properties"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifText         `json:"message"`
+	Locations  []sarifLocation   `json:"locations"`
+	Properties map[string]string `json:"properties"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// WriteSARIF writes the report as a SARIF 2.1.0 log, the format code
+// scanning services such as GitHub's ingest. toolVersion is the lalibela
+// version reported as the tool's.
+func (r Report) WriteSARIF(w io.Writer, toolVersion string) error {
+	ids := make([]string, 0, len(Rules))
+	for id := range Rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	rules := make([]sarifRule, 0, len(ids))
+	for _, id := range ids {
+		rules = append(rules, sarifRule{
+			ID:               id,
+			ShortDescription: sarifText{Text: Rules[id].Description},
+			Help:             sarifText{Text: Rules[id].Fix},
+			Properties: map[string]any{
+				"tags":              []string{"security"},
+				"security-severity": securitySeverity[Rules[id].Severity],
+			},
+		})
+	}
+
+	results := make([]sarifResult, 0, len(r.Findings))
+	for _, finding := range r.Findings {
+		location := sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: finding.File}}
+		if finding.Line > 0 {
+			location.Region = &sarifRegion{StartLine: finding.Line}
+		}
+		results = append(results, sarifResult{
+			RuleID:     finding.Rule,
+			Level:      sarifLevels[finding.Severity],
+			Message:    sarifText{Text: finding.Message + ". Fix: " + finding.Fix},
+			Locations:  []sarifLocation{{PhysicalLocation: location}},
+			Properties: map[string]string{"severity": string(finding.Severity)},
+		})
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "lalibela",
+				Version:        toolVersion,
+				InformationURI: "https://github.com/naodEthiop/lalibela-cli",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
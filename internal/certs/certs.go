@@ -0,0 +1,295 @@
+package certs
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Files CreateDev writes: the CA to the CA directory and the certificate to
+// the project's.
+const (
+	CAFile    = "ca.pem"
+	CAKeyFile = "ca-key.pem"
+	CertFile  = "localhost.pem"
+	KeyFile   = "localhost-key.pem"
+)
+
+// Dir is the name of the directory development certificates are written to,
+// in the project for the certificate and in ~/.lalibela for the CA shared by
+// every project on the machine.
+const Dir = "certs"
+
+const (
+	// caLifetime is long enough that the CA is trusted once per machine.
+	caLifetime = 10 * 365 * 24 * time.Hour
+	// certLifetime stays within the 825 days Apple platforms accept for
+	// certificates issued by a locally trusted CA.
+	certLifetime = 825 * 24 * time.Hour
+)
+
+// DefaultHosts returns the names and addresses the development certificate
+// covers when no others are given.
+func DefaultHosts() []string {
+	return []string{"localhost", "127.0.0.1", "::1"}
+}
+
+// DevCerts describes the files written by CreateDev.
+type DevCerts struct {
+	CAFile    string
+	CAKeyFile string
+	CertFile  string
+	KeyFile   string
+	// CreatedCA reports whether a new CA was created. An existing, valid CA
+	// is reused so it only has to be trusted once.
+	CreatedCA bool
+	// Hosts lists the DNS names and IP addresses the certificate covers.
+	Hosts    []string
+	NotAfter time.Time
+}
+
+// CreateDev writes a certificate for hosts to dir, signed by the development
+// CA in caDir, which is created first when missing or expired. The CA is kept
+// outside projects so one root, trusted once, signs the certificates of all
+// of them, and its key never ends up in a project tree. Private keys are
+// readable by their owner only.
+func CreateDev(caDir, dir string, hosts []string) (DevCerts, error) {
+	if len(hosts) == 0 {
+		hosts = DefaultHosts()
+	}
+	out := DevCerts{
+		CAFile:    filepath.Join(caDir, CAFile),
+		CAKeyFile: filepath.Join(caDir, CAKeyFile),
+		CertFile:  filepath.Join(dir, CertFile),
+		KeyFile:   filepath.Join(dir, KeyFile),
+		Hosts:     hosts,
+	}
+	if err := os.MkdirAll(caDir, 0o700); err != nil {
+		return DevCerts{}, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return DevCerts{}, err
+	}
+
+	ca, caKey, err := loadCA(out.CAFile, out.CAKeyFile)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) && !errors.Is(err, errExpired) {
+			return DevCerts{}, err
+		}
+		if ca, caKey, err = createCA(out.CAFile, out.CAKeyFile); err != nil {
+			return DevCerts{}, err
+		}
+		out.CreatedCA = true
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return DevCerts{}, fmt.Errorf("generating certificate key: %w", err)
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return DevCerts{}, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization: []string{"lalibela development certificate"},
+			CommonName:   hosts[0],
+		},
+		NotBefore:   now.Add(-time.Hour),
+		NotAfter:    now.Add(certLifetime),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if template.NotAfter.After(ca.NotAfter) {
+		template.NotAfter = ca.NotAfter
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, key.Public(), caKey)
+	if err != nil {
+		return DevCerts{}, fmt.Errorf("signing certificate: %w", err)
+	}
+	if err := writeCertificate(out.CertFile, der); err != nil {
+		return DevCerts{}, err
+	}
+	if err := writePrivateKey(out.KeyFile, key); err != nil {
+		return DevCerts{}, err
+	}
+	out.NotAfter = template.NotAfter
+	return out, nil
+}
+
+// errExpired reports a CA that is expired or about to, which CreateDev
+// replaces.
+var errExpired = errors.New("development CA expired")
+
+// loadCA reads the CA certificate and key. It fails with errExpired when the
+// CA could not sign a certificate valid for a month.
+func loadCA(certFile, keyFile string) (*x509.Certificate, crypto.Signer, error) {
+	cert, err := readCertificate(certFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !cert.IsCA {
+		return nil, nil, fmt.Errorf("%s is not a CA certificate", certFile)
+	}
+	if time.Now().Add(30 * 24 * time.Hour).After(cert.NotAfter) {
+		return nil, nil, errExpired
+	}
+	key, err := readPrivateKey(keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+func createCA(certFile, keyFile string) (*x509.Certificate, crypto.Signer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating CA key: %w", err)
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	name := "lalibela development CA"
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		name += " (" + hostname + ")"
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization: []string{"lalibela development CA"},
+			CommonName:   name,
+		},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caLifetime),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating CA certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := writeCertificate(certFile, der); err != nil {
+		return nil, nil, err
+	}
+	if err := writePrivateKey(keyFile, key); err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// serialNumber returns a random 128-bit certificate serial number.
+func serialNumber() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("generating serial number: %w", err)
+	}
+	return serial, nil
+}
+
+func readCertificate(path string) (*x509.Certificate, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%s: no PEM certificate", path)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cert, nil
+}
+
+func readPrivateKey(path string) (crypto.Signer, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("%s: no PKCS #8 private key", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	key, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported key type %T", path, parsed)
+	}
+	return key, nil
+}
+
+func writeCertificate(path string, der []byte) error {
+	raw := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return os.WriteFile(path, raw, 0o644)
+}
+
+// writePrivateKey writes key as a PKCS #8 PEM block readable by its owner
+// only, also when the file already existed.
+func writePrivateKey(path string, key crypto.Signer) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("encoding private key: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := file.Chmod(0o600); err != nil {
+		file.Close()
+		return err
+	}
+	if err := pem.Encode(file, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		file.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// TrustCommands returns the commands that add the CA in caFile to the system
+// trust store on goos, so browsers accept the development certificate.
+func TrustCommands(goos, caFile string) []string {
+	switch goos {
+	case "darwin":
+		return []string{"sudo security add-trusted-cert -d -r trustRoot -k /Library/Keychains/System.keychain " + caFile}
+	case "windows":
+		return []string{"certutil -addstore -f ROOT " + strings.ReplaceAll(caFile, "/", `\`)}
+	default:
+		return []string{
+			"sudo cp " + caFile + " /usr/local/share/ca-certificates/lalibela-dev-ca.crt",
+			"sudo update-ca-certificates",
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateDev(t *testing.T) {
+	root := t.TempDir()
+	created, err := CreateDev(filepath.Join(root, "ca"), filepath.Join(root, Dir), nil)
+	if err != nil {
+		t.Fatalf("CreateDev: %v", err)
+	}
+	if !created.CreatedCA {
+		t.Fatal("expected a new CA")
+	}
+	for _, path := range []string{created.CAKeyFile, created.KeyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Fatalf("%s has mode %o, want 600", path, perm)
+		}
+	}
+
+	pair, err := tls.LoadX509KeyPair(created.CertFile, created.KeyFile)
+	if err != nil {
+		t.Fatalf("LoadX509KeyPair: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := readCertificate(created.CAFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	for _, host := range DefaultHosts() {
+		if _, err := leaf.Verify(x509.VerifyOptions{DNSName: host, Roots: roots}); err != nil {
+			t.Fatalf("certificate does not verify for %s: %v", host, err)
+		}
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{DNSName: "example.com", Roots: roots}); err == nil {
+		t.Fatal("certificate verified for a host it does not cover")
+	}
+}
+
+func TestCreateDevReusesCAAcrossProjects(t *testing.T) {
+	caDir := t.TempDir()
+	firstProject, secondProject := t.TempDir(), t.TempDir()
+	first, err := CreateDev(caDir, firstProject, nil)
+	if err != nil {
+		t.Fatalf("CreateDev: %v", err)
+	}
+	caBefore, err := os.ReadFile(first.CAFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := CreateDev(caDir, secondProject, []string{"api.test", "10.0.0.5"})
+	if err != nil {
+		t.Fatalf("CreateDev: %v", err)
+	}
+	if second.CreatedCA {
+		t.Fatal("expected the existing CA to be reused")
+	}
+	caAfter, err := os.ReadFile(second.CAFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(caBefore) != string(caAfter) {
+		t.Fatal("CA certificate was replaced")
+	}
+	for _, project := range []string{firstProject, secondProject} {
+		for _, name := range []string{CAFile, CAKeyFile} {
+			if _, err := os.Stat(filepath.Join(project, name)); !os.IsNotExist(err) {
+				t.Fatalf("expected no %s in the project, got %v", name, err)
+			}
+		}
+	}
+	leaf, err := readCertificate(second.CertFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leaf.DNSNames) != 1 || leaf.DNSNames[0] != "api.test" || len(leaf.IPAddresses) != 1 || leaf.IPAddresses[0].String() != "10.0.0.5" {
+		t.Fatalf("unexpected names %v and addresses %v", leaf.DNSNames, leaf.IPAddresses)
+	}
+}
+
+func TestCreateDevRejectsInvalidCA(t *testing.T) {
+	caDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(caDir, CAFile), []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateDev(caDir, t.TempDir(), nil); err == nil || !strings.Contains(err.Error(), "no PEM certificate") {
+		t.Fatalf("expected an invalid CA error, got %v", err)
+	}
+}
+
+func TestTrustCommands(t *testing.T) {
+	for goos, want := range map[string]string{
+		"darwin":  "add-trusted-cert",
+		"linux":   "update-ca-certificates",
+		"windows": `certutil -addstore -f ROOT certs\ca.pem`,
+	} {
+		commands := TrustCommands(goos, "certs/ca.pem")
+		if !strings.Contains(strings.Join(commands, "\n"), want) {
+			t.Fatalf("%s: expected %q in %v", goos, want, commands)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+// Package certs creates the local certificate authority and the localhost
+// certificate generated projects serve HTTPS with in development.
+package certs
@@ -0,0 +1,2 @@
+// Package apikey provides the "api-key" scaffold feature installer.
+package apikey
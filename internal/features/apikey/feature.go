@@ -0,0 +1,66 @@
+package apikey
+
+import (
+	"strings"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// Feature installs the "api-key" scaffold feature.
+type Feature struct{}
+
+// New returns a new "api-key" feature installer.
+func New() Feature { return Feature{} }
+
+// Name returns the registry name of the feature.
+func (Feature) Name() string { return "api-key" }
+
+// Description returns a one-line summary of the feature.
+func (Feature) Description() string {
+	return "Hashed API keys with scopes, per-key rate limits and an admin command to mint and revoke them"
+}
+
+// Version returns the version of the feature's installer. It changes
+// whenever the files the feature writes change.
+func (Feature) Version() string { return "1.0.0" }
+
+// EnvVars returns the environment variables the feature reads.
+func (Feature) EnvVars() []shared.EnvVar {
+	return []shared.EnvVar{
+		{Name: "API_KEYS_FILE", Default: "api_keys.json", Description: "File the file-backed API key store keeps key hashes in"},
+	}
+}
+
+// Compatible reports whether the feature supports a given framework.
+func (Feature) Compatible(framework string) bool {
+	return shared.IsFeatureCompatible("api-key", framework)
+}
+
+// Install writes the feature's scaffold files into target: the key store
+// and middleware in the middleware package, and the admin command under
+// cmd/apikeys.
+func (Feature) Install(target *shared.Target) error {
+	files := []struct{ name, source string }{
+		{"api_key.go", keySource},
+		{"api_key_test.go", keyTestSource},
+		{"api_key_store.go", storeSource},
+		{"api_key_middleware.go", shared.Variant(middlewareSources, target.Framework)},
+		{"api_key_middleware_test.go", shared.Variant(testHarnesses, target.Framework) + testCases},
+	}
+	for _, file := range files {
+		if err := target.WriteGoFile(shared.RoleMiddleware, file.name, file.source); err != nil {
+			return err
+		}
+	}
+	if err := target.WriteFile(target.Path(shared.RoleMigrations, "0003_api_keys.sql"), []byte(migration)); err != nil {
+		return err
+	}
+	admin := strings.ReplaceAll(adminSource, "{{import}}", target.Import(shared.RoleMiddleware))
+	return target.WriteFile("cmd/apikeys/main.go", []byte(target.Qualify(shared.RoleMiddleware, admin)))
+}
+
+// Usage returns a snippet showing how to protect routes with API keys on the
+// target's framework.
+func (Feature) Usage(target *shared.Target) string {
+	return target.Qualify(shared.RoleMiddleware, shared.Variant(usage, target.Framework))
+}
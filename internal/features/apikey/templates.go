@@ -0,0 +1,43 @@
+package apikey
+
+import (
+	"embed"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// templateFS holds the files the feature renders and the snippets it shows,
+// one file per template and one directory per set of framework variants.
+//
+//go:embed templates
+var templateFS embed.FS
+
+// keySource holds the framework-independent parts: minting, authenticating
+// and rate limiting keys.
+var keySource = shared.MustReadTemplate(templateFS, "templates/key.go.tmpl")
+
+// storeSource holds the in-memory, file and PostgreSQL key stores.
+var storeSource = shared.MustReadTemplate(templateFS, "templates/store.go.tmpl")
+
+// migration creates the table used by PostgresAPIKeyStore.
+var migration = shared.MustReadTemplate(templateFS, "templates/migration.sql.tmpl")
+
+// keyTestSource tests minting, the stores and the per-key limiter.
+var keyTestSource = shared.MustReadTemplate(templateFS, "templates/key_test.go.tmpl")
+
+// middlewareSources holds the API key middleware rendered for each framework.
+var middlewareSources = shared.MustReadVariants(templateFS, "templates/middleware")
+
+// testCases is shared by every framework's test so each variant is checked
+// against the same expectations. The cases run in order against one limiter.
+var testCases = shared.MustReadTemplate(templateFS, "templates/test_cases.go.tmpl")
+
+// testHarnesses runs testCases through each framework's router.
+var testHarnesses = shared.MustReadVariants(templateFS, "templates/test_harnesses")
+
+// adminSource is the generated project's cmd/apikeys command. {{import}} is
+// replaced with the import path of the middleware package.
+var adminSource = shared.MustReadTemplate(templateFS, "templates/admin.go.tmpl")
+
+// usage shows how to protect a group of routes with API keys.
+var usage = shared.MustReadVariants(templateFS, "templates/usage")
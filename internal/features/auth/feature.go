@@ -13,31 +13,52 @@ func New() Feature { return Feature{} }
 // Name returns the registry name of the feature.
 func (Feature) Name() string { return "auth" }
 
-// Compatible reports whether the feature supports a given framework.
-func (Feature) Compatible(framework string) bool {
-	return shared.IsFeatureCompatible("auth", framework)
+// Description returns a one-line summary of the feature.
+func (Feature) Description() string {
+	return "JWT verification (HS256/RS256/EdDSA, JWKS, key rotation) and bearer-token middleware"
 }
 
-// Install writes the feature's scaffold files into projectRoot.
-func (Feature) Install(projectRoot string) error {
-	const file = `package server
+// Version returns the version of the feature's installer. It changes
+// whenever the files the feature writes change.
+func (Feature) Version() string { return "2.0.0" }
 
-import (
-	"errors"
-	"strings"
+// EnvVars returns the environment variables the feature reads.
+func (Feature) EnvVars() []shared.EnvVar {
+	return []shared.EnvVar{
+		{Name: "JWT_SECRET", Description: "Secret used to verify HS256 bearer tokens (at least 32 bytes)", Secret: true, Generate: true},
+		{Name: "JWT_KID", Description: "Key ID of JWT_SECRET"},
+		{Name: "JWT_PREVIOUS_SECRET", Description: "Secret being rotated out, still accepted for JWT_PREVIOUS_KID", Secret: true},
+		{Name: "JWT_PREVIOUS_KID", Description: "Key ID of JWT_PREVIOUS_SECRET"},
+		{Name: "JWT_JWKS_FILE", Description: "JWKS file with the verification keys, used instead of JWT_SECRET"},
+		{Name: "JWT_ISSUER", Description: "Expected iss claim, unchecked when empty"},
+		{Name: "JWT_AUDIENCE", Description: "Expected aud claim, unchecked when empty"},
+	}
+}
 
-	"github.com/golang-jwt/jwt/v5"
-)
+// Compatible reports whether the feature supports a given framework.
+func (Feature) Compatible(framework string) bool {
+	return shared.IsFeatureCompatible("auth", framework)
+}
 
-func ValidateJWT(tokenString string, secret []byte) error {
-	if strings.TrimSpace(tokenString) == "" {
-		return errors.New("missing bearer token")
+// Install writes the feature's scaffold files into target, using the
+// middleware variant for the target's framework.
+func (Feature) Install(target *shared.Target) error {
+	if err := target.WriteGoFile(shared.RoleMiddleware, "auth_jwt.go", jwtSource); err != nil {
+		return err
 	}
-	_, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-		return secret, nil
-	})
-	return err
+	if err := target.WriteGoFile(shared.RoleMiddleware, "auth_jwt_test.go", jwtTestSource); err != nil {
+		return err
+	}
+	middleware := shared.Variant(middlewareSources, target.Framework)
+	if err := target.WriteGoFile(shared.RoleMiddleware, "auth_middleware.go", middleware); err != nil {
+		return err
+	}
+	test := shared.Variant(testHarnesses, target.Framework) + testCases
+	return target.WriteGoFile(shared.RoleMiddleware, "auth_middleware_test.go", test)
 }
-`
-	return shared.WriteFileIfMissing(projectRoot, "internal/server/auth_jwt.go", []byte(file))
+
+// Usage returns a snippet showing how to use the feature on the target's
+// framework.
+func (Feature) Usage(target *shared.Target) string {
+	return target.Qualify(shared.RoleMiddleware, shared.Variant(usage, target.Framework))
 }
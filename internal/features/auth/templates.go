@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"embed"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// templateFS holds the files the feature renders and the snippets it shows,
+// one file per template and one directory per set of framework variants.
+//
+//go:embed templates
+var templateFS embed.FS
+
+// jwtSource is shared by every framework: it verifies bearer tokens against a
+// key set and carries the verified claims in the request context.
+var jwtSource = shared.MustReadTemplate(templateFS, "templates/jwt.go.tmpl")
+
+// jwtTestSource tests the verifier with tokens generated for every supported
+// algorithm.
+var jwtTestSource = shared.MustReadTemplate(templateFS, "templates/jwt_test.go.tmpl")
+
+// middlewareSources holds the JWT middleware rendered for each framework.
+// Every variant rejects requests without a valid bearer token with 401 and
+// stores the verified claims in the request context.
+var middlewareSources = shared.MustReadVariants(templateFS, "templates/middleware")
+
+// testCases is shared by every framework's test so each variant is checked
+// against the same expectations.
+var testCases = shared.MustReadTemplate(templateFS, "templates/test_cases.go.tmpl")
+
+// testHarnesses runs testCases through each framework's router.
+var testHarnesses = shared.MustReadVariants(templateFS, "templates/test_harnesses")
+
+// usage shows how to protect a group of routes with the JWT middleware and
+// read the verified claims.
+var usage = shared.MustReadVariants(templateFS, "templates/usage")
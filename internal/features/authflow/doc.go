@@ -0,0 +1,2 @@
+// Package authflow provides the "auth-flow" scaffold feature installer.
+package authflow
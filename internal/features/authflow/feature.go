@@ -0,0 +1,89 @@
+package authflow
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// Feature installs the "auth-flow" scaffold feature.
+type Feature struct{}
+
+// New returns a new "auth-flow" feature installer.
+func New() Feature { return Feature{} }
+
+// Name returns the registry name of the feature.
+func (Feature) Name() string { return "auth-flow" }
+
+// Description returns a one-line summary of the feature.
+func (Feature) Description() string {
+	return "User store, password hashing, login with rotating refresh tokens and logout routes"
+}
+
+// Version returns the version of the feature's installer. It changes
+// whenever the files the feature writes change.
+func (Feature) Version() string { return "1.1.0" }
+
+// Requires returns the features auth-flow builds on: its access tokens are
+// verified by the auth feature's middleware.
+func (Feature) Requires() []string { return []string{"auth"} }
+
+// EnvVars returns the environment variables the feature reads.
+func (Feature) EnvVars() []shared.EnvVar {
+	return []shared.EnvVar{
+		{Name: "ACCESS_TOKEN_TTL", Default: "15m", Description: "Lifetime of access tokens issued at login"},
+		{Name: "REFRESH_TOKEN_TTL", Default: "720h", Description: "Lifetime of refresh tokens"},
+		{Name: "JWT_PRIVATE_KEY_FILE", Description: "PEM key signing access tokens with RS256 or EdDSA instead of JWT_SECRET (see 'lalibela secrets keygen')"},
+	}
+}
+
+// Compatible reports whether the feature supports a given framework.
+func (Feature) Compatible(framework string) bool {
+	return shared.IsFeatureCompatible("auth-flow", framework)
+}
+
+// hashers maps the hash option to the hasher new passwords are hashed with.
+var hashers = map[string]string{
+	"bcrypt": "BcryptHasher{Cost: bcrypt.DefaultCost}",
+	"argon2": "DefaultArgon2Hasher",
+}
+
+// Options returns the settings the feature accepts at install time.
+func (Feature) Options() []shared.Option {
+	return []shared.Option{
+		{Name: "hash", Type: shared.OptionString, Default: "bcrypt", Description: "Password hashing for new passwords: bcrypt or argon2", Validate: validateHash},
+	}
+}
+
+func validateHash(value string) error {
+	if _, ok := hashers[value]; !ok {
+		return fmt.Errorf("must be bcrypt or argon2, got %q", value)
+	}
+	return nil
+}
+
+// Install writes the feature's scaffold files into target, using the route
+// variant for the target's framework and the hasher chosen with --hash.
+func (Feature) Install(target *shared.Target) error {
+	files := []struct{ name, source string }{
+		{"auth_flow.go", strings.ReplaceAll(flowSource, "{{hasher}}", hashers[target.Options.String("hash")])},
+		{"auth_flow_test.go", flowTestSource},
+		{"auth_store_memory.go", memoryStoreSource},
+		{"auth_store_postgres.go", postgresStoreSource},
+		{"auth_routes.go", shared.Variant(routeSources, target.Framework)},
+		{"auth_routes_test.go", shared.Variant(routeTestHarnesses, target.Framework) + routeTestCases},
+	}
+	for _, file := range files {
+		if err := target.WriteGoFile(shared.RoleMiddleware, file.name, file.source); err != nil {
+			return err
+		}
+	}
+	return target.WriteFile(target.Path(shared.RoleMigrations, "0002_auth_flow.sql"), []byte(migration))
+}
+
+// Usage returns a snippet showing how to register the auth routes on the
+// target's framework.
+func (Feature) Usage(target *shared.Target) string {
+	return target.Qualify(shared.RoleMiddleware, shared.Variant(usage, target.Framework))
+}
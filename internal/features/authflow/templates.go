@@ -0,0 +1,46 @@
+package authflow
+
+import (
+	"embed"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// templateFS holds the files the feature renders and the snippets it shows,
+// one file per template and one directory per set of framework variants.
+//
+//go:embed templates
+var templateFS embed.FS
+
+// flowSource holds the framework-independent parts of the flow: users,
+// password hashing, token issuance and the refresh token rotation. The
+// {{hasher}} placeholder is replaced with the hasher chosen at install.
+var flowSource = shared.MustReadTemplate(templateFS, "templates/flow.go.tmpl")
+
+// memoryStoreSource holds the in-memory user and refresh token stores.
+var memoryStoreSource = shared.MustReadTemplate(templateFS, "templates/memory_store.go.tmpl")
+
+// postgresStoreSource holds the PostgreSQL user and refresh token stores.
+// They use database/sql so they work with any PostgreSQL driver, including
+// the pgx pool the postgres feature creates.
+var postgresStoreSource = shared.MustReadTemplate(templateFS, "templates/postgres_store.go.tmpl")
+
+// migration creates the tables used by the PostgreSQL stores.
+var migration = shared.MustReadTemplate(templateFS, "templates/migration.sql.tmpl")
+
+// flowTestSource tests the service and hashers with the in-memory stores.
+var flowTestSource = shared.MustReadTemplate(templateFS, "templates/flow_test.go.tmpl")
+
+// routeSources holds the login, refresh and logout routes for each
+// framework.
+var routeSources = shared.MustReadVariants(templateFS, "templates/route")
+
+// routeTestCases is shared by every framework's route test: it runs the whole
+// login, refresh and logout flow through the framework's router.
+var routeTestCases = shared.MustReadTemplate(templateFS, "templates/route_test_cases.go.tmpl")
+
+// routeTestHarnesses runs routeTestCases through each framework's router.
+var routeTestHarnesses = shared.MustReadVariants(templateFS, "templates/route_test_harnesses")
+
+// usage shows how to build the service and register its routes.
+var usage = shared.MustReadVariants(templateFS, "templates/usage")
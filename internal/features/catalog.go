@@ -0,0 +1,140 @@
+package features
+
+import (
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// catalogFrameworks is the order frameworks are listed in the catalog.
+var catalogFrameworks = []string{"gin", "echo", "fiber", "nethttp"}
+
+// CatalogEntry describes a registered feature for listings.
+type CatalogEntry struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+	// Compatibility maps each framework to whether the feature supports it.
+	Compatibility map[string]bool `json:"compatibility"`
+	Requires      []string        `json:"requires"`
+	// Files lists the project files the feature writes, rendered with
+	// default options.
+	Files   []string        `json:"files"`
+	EnvVars []shared.EnvVar `json:"env_vars"`
+	Default bool            `json:"default"`
+	// Installed reports whether the feature is recorded in the project's
+	// .lalibela/features.json.
+	Installed bool `json:"installed"`
+}
+
+// CatalogFrameworks returns the frameworks covered by CatalogEntry.Compatibility,
+// in display order.
+func CatalogFrameworks() []string {
+	out := make([]string, len(catalogFrameworks))
+	copy(out, catalogFrameworks)
+	return out
+}
+
+// Catalog describes every registered feature, sorted by name, followed by the
+// plugin features installed in the project at projectRoot. Installed status is
+// read from the project; a directory without feature state reports nothing
+// installed. An installed plugin that can no longer be found is listed with
+// its recorded version only.
+func Catalog(projectRoot string) ([]CatalogEntry, error) {
+	state, err := loadState(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := loadProjectSettings(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	names := KnownFeatures()
+	for _, name := range state.Installed {
+		if !contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	entries := make([]CatalogEntry, 0, len(names))
+	for _, name := range names {
+		feature, err := lookupFeature(name)
+		if err != nil {
+			entries = append(entries, CatalogEntry{
+				Name:          name,
+				Version:       recordedVersion(state, name),
+				Compatibility: make(map[string]bool, len(catalogFrameworks)),
+				Requires:      []string{},
+				Files:         []string{},
+				EnvVars:       []shared.EnvVar{},
+				Installed:     true,
+			})
+			continue
+		}
+		entry := CatalogEntry{
+			Name:          name,
+			Version:       featureVersion(feature),
+			Compatibility: make(map[string]bool, len(catalogFrameworks)),
+			Requires:      []string{},
+			Files:         []string{},
+			EnvVars:       []shared.EnvVar{},
+			Default:       contains(DefaultProductionFeatures, name),
+			Installed:     contains(state.Installed, name),
+		}
+		if describer, ok := feature.(Describer); ok {
+			entry.Description = describer.Description()
+		}
+		if dependent, ok := feature.(Dependent); ok {
+			entry.Requires = append(entry.Requires, dependent.Requires()...)
+		}
+		if declarer, ok := feature.(EnvDeclarer); ok {
+			entry.EnvVars = append(entry.EnvVars, declarer.EnvVars()...)
+		}
+
+		framework := ""
+		for _, fw := range catalogFrameworks {
+			compatible := feature.Compatible(fw)
+			entry.Compatibility[fw] = compatible
+			if compatible && framework == "" {
+				framework = fw
+			}
+		}
+		if state.Framework != "" && feature.Compatible(state.Framework) {
+			framework = state.Framework
+		}
+		if framework != "" {
+			files, err := plannedFiles(feature, framework, nil, settings.layout)
+			if err != nil {
+				return nil, err
+			}
+			entry.Files = files
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// plannedFiles returns the files feature writes for framework with option
+// values, defaulting the ones not given, in layout, without touching the
+// filesystem.
+func plannedFiles(feature Feature, framework string, values map[string]string, layout shared.Layout) ([]string, error) {
+	var schema []shared.Option
+	if configurable, ok := feature.(Configurable); ok {
+		schema = configurable.Options()
+	}
+	options, err := shared.ResolveOptions(schema, values)
+	if err != nil {
+		return nil, err
+	}
+
+	target := shared.NewDryRunTarget("", framework)
+	target.Layout = layout
+	target.Options = options
+	if err := feature.Install(target); err != nil {
+		return nil, err
+	}
+	files := make([]string, 0)
+	for _, file := range target.Written() {
+		files = append(files, file.Path)
+	}
+	return files, nil
+}
@@ -0,0 +1,74 @@
+package features
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCatalogDescribesEveryFeature(t *testing.T) {
+	t.Parallel()
+
+	entries, err := Catalog(t.TempDir())
+	if err != nil {
+		t.Fatalf("catalog: %v", err)
+	}
+	if len(entries) != len(Registry) {
+		t.Fatalf("expected %d entries, got %d", len(Registry), len(entries))
+	}
+	byName := make(map[string]CatalogEntry, len(entries))
+	for _, entry := range entries {
+		if entry.Description == "" {
+			t.Fatalf("feature %s has no description", entry.Name)
+		}
+		if len(entry.Files) == 0 {
+			t.Fatalf("feature %s lists no files", entry.Name)
+		}
+		if entry.Installed {
+			t.Fatalf("feature %s reported installed in an empty directory", entry.Name)
+		}
+		byName[entry.Name] = entry
+	}
+
+	if cors := byName["cors"]; !cors.Compatibility["nethttp"] || !cors.Compatibility["gin"] {
+		t.Fatalf("unexpected cors compatibility: %v", cors.Compatibility)
+	}
+	if swagger := byName["swagger"]; swagger.Compatibility["fiber"] || !swagger.Compatibility["nethttp"] {
+		t.Fatalf("unexpected swagger compatibility: %v", swagger.Compatibility)
+	}
+	if shutdown := byName["graceful-shutdown"]; len(shutdown.Requires) != 1 || shutdown.Requires[0] != "logger" {
+		t.Fatalf("expected graceful-shutdown to require logger, got %v", shutdown.Requires)
+	}
+	if redis := byName["redis"]; len(redis.EnvVars) != 1 || redis.EnvVars[0].Name != "REDIS_ADDR" {
+		t.Fatalf("expected redis to declare REDIS_ADDR, got %+v", redis.EnvVars)
+	}
+}
+
+func TestCatalogReportsInstalledFeaturesWithoutWriting(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if _, err := InstallFeature(root, "gin", "docker", nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	before, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+
+	entries, err := Catalog(root)
+	if err != nil {
+		t.Fatalf("catalog: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Installed != (entry.Name == "docker") {
+			t.Fatalf("unexpected installed status for %s: %t", entry.Name, entry.Installed)
+		}
+	}
+	after, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(after) != len(before) {
+		t.Fatalf("expected catalog not to write files, had %d entries now %d", len(before), len(after))
+	}
+}
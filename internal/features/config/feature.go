@@ -1,6 +1,8 @@
 package config
 
 import (
+	"fmt"
+
 	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
 )
 
@@ -13,51 +15,78 @@ func New() Feature { return Feature{} }
 // Name returns the registry name of the feature.
 func (Feature) Name() string { return "config" }
 
+// Description returns a one-line summary of the feature.
+func (Feature) Description() string {
+	return "Typed, validated config layered from defaults, configs/<APP_ENV> files, .env, env vars and flags"
+}
+
+// Version returns the version of the feature's installer. It changes
+// whenever the files the feature writes change.
+func (Feature) Version() string { return "2.0.0" }
+
+// EnvVars returns the environment variables the feature reads.
+func (Feature) EnvVars() []shared.EnvVar {
+	return []shared.EnvVar{
+		{Name: "APP_NAME", Default: "lalibela-app", Description: "Application name"},
+		{Name: "APP_ENV", Default: "development", Description: "Deployment environment, selecting the file read from configs/"},
+		{Name: "PORT", Default: "8080", Description: "HTTP listen port"},
+	}
+}
+
 // Compatible reports whether the feature supports a given framework.
 func (Feature) Compatible(framework string) bool {
 	return shared.IsFeatureCompatible("config", framework)
 }
 
-// Install writes the feature's scaffold files into projectRoot.
-func (Feature) Install(projectRoot string) error {
-	const file = `package config
-
-import (
-	"fmt"
-	"os"
-	"strconv"
-	"strings"
-)
-
-type Config struct {
-	AppName string
-	Env     string
-	Port    int
+// Options returns the settings the feature accepts at install time.
+func (Feature) Options() []shared.Option {
+	return []shared.Option{
+		{Name: "format", Type: shared.OptionString, Default: "yaml", Description: "Format of the sample configs/development file: yaml, json or toml", Validate: validateFormat},
+	}
 }
 
-func Load() (Config, error) {
-	cfg := Config{
-		AppName: strings.TrimSpace(os.Getenv("APP_NAME")),
-		Env:     strings.TrimSpace(os.Getenv("APP_ENV")),
-		Port:    8080,
+func validateFormat(value string) error {
+	if _, ok := samples[value]; !ok {
+		return fmt.Errorf("must be yaml, json or toml, got %q", value)
 	}
-	if cfg.AppName == "" {
-		cfg.AppName = "lalibela-app"
+	return nil
+}
+
+// Install writes the configuration struct, its loader and a sample
+// development file in the format option into target.
+func (Feature) Install(target *shared.Target) error {
+	if err := target.WriteGoFile(shared.RoleConfig, "config.go", configSource); err != nil {
+		return err
 	}
-	if cfg.Env == "" {
-		cfg.Env = "development"
+	if err := target.WriteGoFile(shared.RoleConfig, "load.go", loadSource); err != nil {
+		return err
 	}
-
-	if raw := strings.TrimSpace(os.Getenv("PORT")); raw != "" {
-		port, err := strconv.Atoi(raw)
-		if err != nil || port < 1 || port > 65535 {
-			return Config{}, fmt.Errorf("invalid PORT %q", raw)
-		}
-		cfg.Port = port
+	if err := target.WriteGoFile(shared.RoleConfig, "load_test.go", loadTestSource); err != nil {
+		return err
 	}
+	format := target.Options.String("format")
+	return target.WriteFile("configs/development."+format, []byte(samples[format]))
+}
 
-	return cfg, nil
+// Wiring returns how main.go loads the configuration and takes the port and
+// TLS settings from it.
+func (Feature) Wiring(target *shared.Target) (shared.Wiring, bool) {
+	return shared.Wiring{
+		Settings: &shared.Settings{
+			Load: fmt.Sprintf("cfg := %s.MustLoad()", target.Package(shared.RoleConfig)),
+			Values: map[string]string{
+				"port":        "cfg.Server.Port",
+				"tlsSettings": "tlsSettings{CertFile: cfg.TLS.CertFile, KeyFile: cfg.TLS.KeyFile, RedirectAddr: cfg.TLS.RedirectAddr}",
+			},
+		},
+		Imports: []string{target.Import(shared.RoleConfig)},
+	}, true
 }
-`
-	return shared.WriteFileIfMissing(projectRoot, "internal/config/config.go", []byte(file))
+
+// Usage returns a snippet showing how to use the feature.
+func (Feature) Usage(target *shared.Target) string {
+	return target.Qualify(shared.RoleConfig, `// Exits with the problems when the configuration is invalid, and prints it
+// with secrets redacted when run with --print-config.
+cfg := config.MustLoad()
+addr := fmt.Sprintf(":%d", cfg.Server.Port)`)
 }
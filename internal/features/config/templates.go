@@ -0,0 +1,27 @@
+package config
+
+import (
+	"embed"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// templateFS holds the files the feature renders and the snippets it shows,
+// one file per template and one directory per set of framework variants.
+//
+//go:embed templates
+var templateFS embed.FS
+
+// configSource declares the configuration. Features that need settings add
+// a section to Config and declare it in a file of their own next to it.
+var configSource = shared.MustReadTemplate(templateFS, "templates/config.go.tmpl")
+
+// loadSource reads the settings of Config from its tags: defaults, the file
+// of the environment, .env, environment variables and flags, in that order.
+var loadSource = shared.MustReadTemplate(templateFS, "templates/load.go.tmpl")
+
+// loadTestSource tests the loader against the sections of configSource.
+var loadTestSource = shared.MustReadTemplate(templateFS, "templates/load_test.go.tmpl")
+
+// samples holds configs/development in each supported format.
+var samples = shared.MustReadVariants(templateFS, "templates/samples")
@@ -1,6 +1,10 @@
 package cors
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
 )
 
@@ -13,29 +17,57 @@ func New() Feature { return Feature{} }
 // Name returns the registry name of the feature.
 func (Feature) Name() string { return "cors" }
 
+// Description returns a one-line summary of the feature.
+func (Feature) Description() string {
+	return "CORS middleware with configurable allowed origins"
+}
+
+// Version returns the version of the feature's installer. It changes
+// whenever the files the feature writes change.
+func (Feature) Version() string { return "1.1.0" }
+
 // Compatible reports whether the feature supports a given framework.
 func (Feature) Compatible(framework string) bool {
 	return shared.IsFeatureCompatible("cors", framework)
 }
 
-// Install writes the feature's scaffold files into projectRoot.
-func (Feature) Install(projectRoot string) error {
-	const file = `package server
-
-import "net/http"
-
-func CORSMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type,Authorization")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,POST,PUT,PATCH,DELETE,OPTIONS")
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
+// Options returns the settings the feature accepts at install time.
+func (Feature) Options() []shared.Option {
+	return []shared.Option{
+		{Name: "origins", Type: shared.OptionString, Default: "*", Description: "Comma-separated origins allowed to make cross-origin requests", Validate: shared.OriginList},
+	}
 }
-`
-	return shared.WriteFileIfMissing(projectRoot, "internal/server/cors.go", []byte(file))
+
+// Install writes the feature's scaffold files into target, using the
+// middleware variant for the target's framework.
+func (Feature) Install(target *shared.Target) error {
+	origins := shared.SplitList(target.Options.String("origins"))
+	if len(origins) == 0 {
+		origins = []string{"*"}
+	}
+	quoted := make([]string, len(origins))
+	for i, origin := range origins {
+		quoted[i] = strconv.Quote(origin)
+	}
+	source := shared.Variant(middlewareSources, target.Framework) + fmt.Sprintf(originsSource, strings.Join(quoted, ", "))
+	if err := target.WriteGoFile(shared.RoleMiddleware, "cors.go", source); err != nil {
+		return err
+	}
+	test := shared.Variant(testHarnesses, target.Framework) + testCases
+	return target.WriteGoFile(shared.RoleMiddleware, "cors_test.go", test)
+}
+
+// Wiring returns how the middleware is registered for the target's framework.
+func (Feature) Wiring(target *shared.Target) (shared.Wiring, bool) {
+	middleware := target.Package(shared.RoleMiddleware) + ".CORSMiddleware"
+	if target.Framework != "nethttp" {
+		middleware += "()"
+	}
+	return shared.Wiring{Middleware: middleware, Imports: []string{target.Import(shared.RoleMiddleware)}}, true
+}
+
+// Usage returns a snippet showing how to use the feature on the target's
+// framework.
+func (Feature) Usage(target *shared.Target) string {
+	return target.Qualify(shared.RoleMiddleware, shared.Variant(usage, target.Framework))
 }
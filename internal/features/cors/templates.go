@@ -0,0 +1,34 @@
+package cors
+
+import (
+	"embed"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// templateFS holds the files the feature renders and the snippets it shows,
+// one file per template and one directory per set of framework variants.
+//
+//go:embed templates
+var templateFS embed.FS
+
+// middlewareSources holds the CORS middleware rendered for each framework.
+// Every variant allows only the listed origins, sends Vary: Origin whenever the
+// answer depends on the request's origin, and answers preflight requests from
+// allowed origins with 204 No Content. originsSource is appended to each of
+// them.
+var middlewareSources = shared.MustReadVariants(templateFS, "templates/middleware")
+
+// originsSource lists the allowed origins. It is rendered with the quoted
+// values of the "origins" option.
+var originsSource = shared.MustReadTemplate(templateFS, "templates/origins.go.tmpl")
+
+// testCases is shared by every framework's test so each variant is checked
+// against the same expectations.
+var testCases = shared.MustReadTemplate(templateFS, "templates/test_cases.go.tmpl")
+
+// testHarnesses runs testCases through each framework's router.
+var testHarnesses = shared.MustReadVariants(templateFS, "templates/test_harnesses")
+
+// usage shows how the middleware is registered by hand.
+var usage = shared.MustReadVariants(templateFS, "templates/usage")
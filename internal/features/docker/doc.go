@@ -1,2 +0,0 @@
-// Package docker provides the "docker" scaffold feature installer.
-package docker
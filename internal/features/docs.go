@@ -0,0 +1,100 @@
+package features
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+	"github.com/naodEthiop/lalibela-cli/internal/features/wiring"
+)
+
+// Doc is a feature's documentation for one framework.
+type Doc struct {
+	Name       string
+	Summary    string
+	Framework  string
+	Compatible bool
+	Installed  bool
+	Requires   []string
+	Options    []shared.Option
+	// Values holds the option values the docs are rendered with: the
+	// recorded values when the feature is installed, otherwise defaults.
+	Values  shared.Options
+	Files   []string
+	EnvVars []shared.EnvVar
+	// Wiring lists the edits `lalibela add` makes to main.go and routes,
+	// phrased so they can be applied by hand.
+	Wiring []string
+	// Usage is a Go snippet showing how to use the generated code.
+	Usage string
+}
+
+// Explain returns the documentation for featureName on framework. When the
+// feature is installed in the project at projectRoot, the docs use the
+// options it was installed with.
+func Explain(projectRoot, featureName, framework string) (Doc, error) {
+	normalized := strings.ToLower(strings.TrimSpace(featureName))
+	feature, err := lookupFeature(normalized)
+	if err != nil {
+		return Doc{}, errors.New(unknownFeature(featureName, err))
+	}
+	state, err := loadState(projectRoot)
+	if err != nil {
+		return Doc{}, err
+	}
+
+	doc := Doc{
+		Name:       normalized,
+		Framework:  framework,
+		Compatible: feature.Compatible(framework),
+		Installed:  contains(state.Installed, normalized),
+		Requires:   []string{},
+		Files:      []string{},
+		EnvVars:    []shared.EnvVar{},
+	}
+	if describer, ok := feature.(Describer); ok {
+		doc.Summary = describer.Description()
+	}
+	if dependent, ok := feature.(Dependent); ok {
+		doc.Requires = append(doc.Requires, dependent.Requires()...)
+	}
+	if declarer, ok := feature.(EnvDeclarer); ok {
+		doc.EnvVars = append(doc.EnvVars, declarer.EnvVars()...)
+	}
+	if configurable, ok := feature.(Configurable); ok {
+		doc.Options = configurable.Options()
+	}
+	values, err := shared.ResolveOptions(doc.Options, state.Features[normalized].Options)
+	if err != nil {
+		values, err = shared.ResolveOptions(doc.Options, nil)
+		if err != nil {
+			return Doc{}, err
+		}
+	}
+	doc.Values = values
+	if !doc.Compatible {
+		return doc, nil
+	}
+
+	settings, err := loadProjectSettings(projectRoot)
+	if err != nil {
+		return Doc{}, err
+	}
+	target := settings.apply(shared.NewDryRunTarget("", framework))
+	target.Options = values
+	if err := feature.Install(target); err != nil {
+		return Doc{}, err
+	}
+	for _, file := range target.Written() {
+		doc.Files = append(doc.Files, file.Path)
+	}
+	if wirer, ok := feature.(Wirer); ok {
+		if spec, ok := wirer.Wiring(target); ok {
+			doc.Wiring = wiring.Instructions(framework, spec)
+		}
+	}
+	if documenter, ok := feature.(Documenter); ok {
+		doc.Usage = documenter.Usage(target)
+	}
+	return doc, nil
+}
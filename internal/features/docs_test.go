@@ -0,0 +1,88 @@
+package features
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplainDocumentsEveryCompatibleFeature(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, name := range KnownFeatures() {
+		for _, framework := range CatalogFrameworks() {
+			doc, err := Explain(root, name, framework)
+			if err != nil {
+				t.Fatalf("explain %s on %s: %v", name, framework, err)
+			}
+			if !doc.Compatible {
+				continue
+			}
+			if doc.Summary == "" || doc.Usage == "" || len(doc.Files) == 0 {
+				t.Fatalf("incomplete docs for %s on %s: %+v", name, framework, doc)
+			}
+		}
+	}
+}
+
+func TestExplainUsesInstalledOptions(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	doc, err := Explain(root, "rate-limit", "gin")
+	if err != nil {
+		t.Fatalf("explain: %v", err)
+	}
+	if doc.Values.Int("rps") != 10 || doc.Values.Int("burst") != 20 {
+		t.Fatalf("expected default options, got %v", doc.Values)
+	}
+	if doc.Usage != "app.Use(server.RateLimitMiddleware(cfg.RateLimit.RPS, cfg.RateLimit.Burst))" {
+		t.Fatalf("expected the rate from the config in usage, got %q", doc.Usage)
+	}
+
+	if _, err := InstallFeatureWithOptions(root, "gin", "rate-limit", map[string]string{"rps": "50", "burst": "100"}, nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	doc, err = Explain(root, "rate-limit", "gin")
+	if err != nil {
+		t.Fatalf("explain: %v", err)
+	}
+	if !doc.Installed || doc.Values.Int("rps") != 50 || doc.Values.Int("burst") != 100 {
+		t.Fatalf("expected installed options, got %+v", doc)
+	}
+	if len(doc.Wiring) == 0 || !strings.Contains(doc.Wiring[0], "RateLimitMiddleware(cfg.RateLimit.RPS, cfg.RateLimit.Burst)") {
+		t.Fatalf("expected wiring instructions with the rate from the config, got %v", doc.Wiring)
+	}
+}
+
+func TestExplainSessionsStoreNeedsNoConfig(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if _, err := InstallFeatureWithOptions(root, "gin", "sessions", map[string]string{"store": "redis"}, nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	doc, err := Explain(root, "sessions", "gin")
+	if err != nil {
+		t.Fatalf("explain: %v", err)
+	}
+	// sessions does not require config, so its usage cannot rely on cfg.
+	if !strings.Contains(doc.Usage, `storage.NewRedisClient(os.Getenv("REDIS_ADDR"))`) || strings.Contains(doc.Usage, "cfg.") {
+		t.Fatalf("expected a config-free redis store snippet, got:\n%s", doc.Usage)
+	}
+}
+
+func TestExplainIncompatibleFeature(t *testing.T) {
+	t.Parallel()
+
+	doc, err := Explain(t.TempDir(), "swagger", "fiber")
+	if err != nil {
+		t.Fatalf("explain: %v", err)
+	}
+	if doc.Compatible || doc.Usage != "" || len(doc.Files) != 0 {
+		t.Fatalf("expected no usage for incompatible feature, got %+v", doc)
+	}
+	if _, err := Explain(t.TempDir(), "nope", "gin"); err == nil {
+		t.Fatal("expected unknown feature error")
+	}
+}
@@ -2,19 +2,47 @@ package features
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+	"github.com/naodEthiop/lalibela-cli/internal/features/wiring"
 )
 
 const statePath = ".lalibela/features.json"
 
 // State stores which features have been installed for a project directory.
 type State struct {
-	Framework string   `json:"framework"`
-	Installed []string `json:"installed"`
+	Framework string                   `json:"framework"`
+	Installed []string                 `json:"installed"`
+	Features  map[string]FeatureRecord `json:"features,omitempty"`
+}
+
+// FeatureRecord stores what a feature installed into a project.
+type FeatureRecord struct {
+	Files   []OwnedFile       `json:"files"`
+	Options map[string]string `json:"options,omitempty"`
+	Wiring  []wiring.Patch    `json:"wiring,omitempty"`
+	// Version is the feature's installer version at install or upgrade.
+	Version string `json:"version,omitempty"`
+	// Edited lists existing project files the installer changed outside of
+	// the files and wiring above, such as edits made by a plugin. They are
+	// not reverted on removal.
+	Edited []string `json:"edited,omitempty"`
+	// Env lists the variables the install added to .env or .env.example,
+	// which removal takes out again.
+	Env []shared.EnvVar `json:"env,omitempty"`
+}
+
+// OwnedFile is a project file written by a feature, together with the hash of
+// its content at install time.
+type OwnedFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
 }
 
 // KnownFeatures returns a sorted list of registered feature names.
@@ -30,22 +58,13 @@ func KnownFeatures() []string {
 // InstallDefaults installs DefaultProductionFeatures that are compatible with
 // the target framework.
 func InstallDefaults(projectRoot, framework string, runner CommandRunner) ([]InstallResult, error) {
-	results := make([]InstallResult, 0, len(DefaultProductionFeatures))
-	changed := false
+	requests := make([]FeatureRequest, 0, len(DefaultProductionFeatures))
 	for _, name := range DefaultProductionFeatures {
-		result, err := installFeature(projectRoot, framework, name, false)
-		if err != nil {
-			return nil, err
-		}
-		if result.Installed {
-			changed = true
-		}
-		results = append(results, result)
+		requests = append(requests, FeatureRequest{Name: name})
 	}
-	if changed && runner != nil {
-		if err := runner(projectRoot, "go", "mod", "tidy"); err != nil {
-			return nil, fmt.Errorf("go mod tidy after default feature install: %w", err)
-		}
+	results, err := installFeatures(projectRoot, framework, requests, nil, runner, true)
+	if err != nil {
+		return nil, fmt.Errorf("default feature install: %w", err)
 	}
 	return results, nil
 }
@@ -55,59 +74,64 @@ func InstallDefaults(projectRoot, framework string, runner CommandRunner) ([]Ins
 // If a runner is provided and the feature installation wrote files, InstallFeature
 // runs `go mod tidy` to resolve dependencies.
 func InstallFeature(projectRoot, framework, featureName string, runner CommandRunner) (InstallResult, error) {
-	result, err := installFeature(projectRoot, framework, featureName, false)
-	if err != nil {
-		return result, err
-	}
-	if result.Installed && runner != nil {
-		if err := runner(projectRoot, "go", "mod", "tidy"); err != nil {
-			return result, fmt.Errorf("go mod tidy after feature install: %w", err)
-		}
-	}
-	return result, nil
+	return InstallFeatureWithOptions(projectRoot, framework, featureName, nil, runner)
 }
 
-func installFeature(projectRoot, framework, featureName string, saveOnly bool) (InstallResult, error) {
+// InstallFeatureWithOptions is like InstallFeature but passes option values
+// to the feature. Options that are not given take their defaults.
+func InstallFeatureWithOptions(projectRoot, framework, featureName string, options map[string]string, runner CommandRunner) (InstallResult, error) {
 	normalized := strings.ToLower(strings.TrimSpace(featureName))
-	feature, ok := Registry[normalized]
-	if !ok {
-		return InstallResult{}, fmt.Errorf("unknown feature %q", featureName)
+	results, err := installFeatures(projectRoot, framework, []FeatureRequest{{Name: featureName, Options: options}}, nil, runner, true)
+	for _, result := range results {
+		if result.Name == normalized {
+			return result, err
+		}
 	}
+	return InstallResult{Name: normalized}, err
+}
 
-	state, err := loadState(projectRoot)
+// FeatureOptions returns the install options accepted by the named feature.
+func FeatureOptions(featureName string) ([]shared.Option, error) {
+	normalized := strings.ToLower(strings.TrimSpace(featureName))
+	feature, err := lookupFeature(normalized)
 	if err != nil {
-		return InstallResult{}, err
+		return nil, errors.New(unknownFeature(featureName, err))
 	}
-	if state.Framework == "" {
-		state.Framework = strings.ToLower(strings.TrimSpace(framework))
+	if configurable, ok := feature.(Configurable); ok {
+		return configurable.Options(), nil
 	}
+	return nil, nil
+}
 
-	result := InstallResult{
-		Name:       normalized,
-		Compatible: feature.Compatible(state.Framework),
+// wireFeature registers feature in the project's main.go and routes. When the
+// code to edit cannot be found, manual steps are reported on result instead.
+func wireFeature(target *shared.Target, feature Feature, record *FeatureRecord, result *InstallResult) error {
+	wirer, ok := feature.(Wirer)
+	if !ok {
+		return nil
 	}
-	if !result.Compatible {
-		return result, nil
+	spec, ok := wirer.Wiring(target)
+	if !ok {
+		return nil
 	}
 
-	if contains(state.Installed, normalized) {
-		result.AlreadyPresent = true
-		return result, nil
+	framework := target.Framework
+	patches, err := wiring.Apply(target.Root, framework, spec)
+	if err != nil {
+		if wiring.IsAnchorError(err) {
+			result.ManualSteps = append([]string{fmt.Sprintf("Automatic wiring skipped: %v.", err)}, wiring.Instructions(framework, spec)...)
+			return nil
+		}
+		return fmt.Errorf("wiring feature %q: %w", feature.Name(), err)
 	}
 
-	if !saveOnly {
-		if err := feature.Install(projectRoot); err != nil {
-			return result, err
+	record.Wiring = patches
+	for _, patch := range patches {
+		if !contains(result.Wired, patch.File) {
+			result.Wired = append(result.Wired, patch.File)
 		}
 	}
-	state.Installed = append(state.Installed, normalized)
-	sort.Strings(state.Installed)
-	if err := saveState(projectRoot, state); err != nil {
-		return result, err
-	}
-
-	result.Installed = true
-	return result, nil
+	return nil
 }
 
 // DetectFramework attempts to infer a project's framework by inspecting the
@@ -153,6 +177,35 @@ func loadState(projectRoot string) (State, error) {
 	return state, nil
 }
 
+// projectSettings holds what every target installing into a project shares:
+// the directories from its layout and its module path.
+type projectSettings struct {
+	layout shared.Layout
+	module string
+}
+
+// loadProjectSettings reads the layout and go.mod of the project at
+// projectRoot. A project without a go.mod has no module path; an empty
+// projectRoot gets the default layout.
+func loadProjectSettings(projectRoot string) (projectSettings, error) {
+	layout, err := shared.LoadLayout(projectRoot)
+	if err != nil || projectRoot == "" {
+		return projectSettings{layout: layout}, err
+	}
+	module, err := wiring.ModulePath(projectRoot)
+	if err != nil && !wiring.IsAnchorError(err) {
+		return projectSettings{}, err
+	}
+	return projectSettings{layout: layout, module: module}, nil
+}
+
+// apply configures target with the project's layout and module path.
+func (p projectSettings) apply(target *shared.Target) *shared.Target {
+	target.Layout = p.layout
+	target.Module = p.module
+	return target
+}
+
 func saveState(projectRoot string, state State) error {
 	path := filepath.Join(projectRoot, statePath)
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
@@ -162,7 +215,14 @@ func saveState(projectRoot string, state State) error {
 	if err != nil {
 		return fmt.Errorf("encoding feature state: %w", err)
 	}
-	if err := os.WriteFile(path, encoded, 0o644); err != nil {
+	// Write to a temporary file and rename it so the state is replaced in one
+	// step and never left half-written.
+	temporary := path + ".tmp"
+	if err := os.WriteFile(temporary, encoded, 0o644); err != nil {
+		return fmt.Errorf("writing feature state: %w", err)
+	}
+	if err := os.Rename(temporary, path); err != nil {
+		_ = os.Remove(temporary)
 		return fmt.Errorf("writing feature state: %w", err)
 	}
 	return nil
@@ -1,8 +1,13 @@
 package features
 
 import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -35,11 +40,404 @@ func TestInstallFeatureCompatibility(t *testing.T) {
 	t.Parallel()
 
 	root := t.TempDir()
-	result, err := InstallFeature(root, "nethttp", "auth", nil)
+	result, err := InstallFeature(root, "fiber", "swagger", nil)
 	if err != nil {
 		t.Fatalf("install result should not error for incompatible feature: %v", err)
 	}
 	if result.Compatible {
-		t.Fatalf("expected feature to be incompatible for nethttp")
+		t.Fatalf("expected feature to be incompatible for fiber")
+	}
+}
+
+func TestInstallFeatureReportsManualWiring(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module demo\n"), 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+
+	result, err := InstallFeature(root, "gin", "graceful-shutdown", nil)
+	if err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if !result.Installed || len(result.Wired) != 0 {
+		t.Fatalf("expected install without wiring, got %+v", result)
+	}
+	if len(result.ManualSteps) == 0 {
+		t.Fatalf("expected manual wiring steps when main.go has no serve call")
+	}
+}
+
+func TestInstallFeatureRendersFrameworkNativeMiddleware(t *testing.T) {
+	t.Parallel()
+
+	signatures := map[string]string{
+		"gin":     "gin.HandlerFunc",
+		"echo":    "echo.MiddlewareFunc",
+		"fiber":   "fiber.Handler",
+		"nethttp": "http.Handler",
+	}
+	files := map[string]string{
+		"cors":       "internal/server/cors.go",
+		"rate-limit": "internal/server/rate_limit.go",
+		"auth":       "internal/server/auth_middleware.go",
+	}
+	for framework, signature := range signatures {
+		for name, file := range files {
+			t.Run(framework+"/"+name, func(t *testing.T) {
+				t.Parallel()
+
+				root := t.TempDir()
+				result, err := InstallFeature(root, framework, name, nil)
+				if err != nil {
+					t.Fatalf("install: %v", err)
+				}
+				if !result.Compatible || !result.Installed {
+					t.Fatalf("expected %s to install on %s, which has a template for it, got %+v", name, framework, result)
+				}
+				for _, path := range []string{file, strings.TrimSuffix(file, ".go") + "_test.go"} {
+					raw, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+					if err != nil {
+						t.Fatalf("read %s: %v", path, err)
+					}
+					if _, err := parser.ParseFile(token.NewFileSet(), path, raw, 0); err != nil {
+						t.Fatalf("%s does not parse: %v", path, err)
+					}
+					if path == file && !strings.Contains(string(raw), signature) {
+						t.Fatalf("expected %s to use %s:\n%s", path, signature, raw)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestInstallFeatureWithOptions(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module demo\n"), 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+
+	result, err := InstallFeatureWithOptions(root, "gin", "rate-limit", map[string]string{"rps": "50"}, nil)
+	if err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if result.Options["rps"] != "50" || result.Options["burst"] != "20" {
+		t.Fatalf("expected given rps and default burst, got %v", result.Options)
+	}
+	if settings := readFile(t, filepath.Join(root, "internal", "config", "rate_limit.go")); !strings.Contains(settings, `env:"RATE_LIMIT_RPS" default:"50"`) || !strings.Contains(settings, `env:"RATE_LIMIT_BURST" default:"20"`) {
+		t.Fatalf("expected options to be rendered into the config defaults, got:\n%s", settings)
+	}
+	if env := readFile(t, filepath.Join(root, ".env")); !strings.Contains(env, "RATE_LIMIT_RPS=50\n") || !strings.Contains(env, "RATE_LIMIT_BURST=20\n") {
+		t.Fatalf("expected options to be recorded in .env, got:\n%s", env)
+	}
+
+	state, err := loadState(root)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if got := state.Features["rate-limit"].Options; got["rps"] != "50" || got["burst"] != "20" {
+		t.Fatalf("expected options to be recorded in state, got %v", got)
+	}
+}
+
+func TestInstallFeatureRendersOptionsIntoFiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if _, err := InstallFeatureWithOptions(root, "echo", "cors", map[string]string{"origins": "https://a.example, https://b.example"}, nil); err != nil {
+		t.Fatalf("install cors: %v", err)
+	}
+	raw, err := os.ReadFile(filepath.Join(root, "internal", "server", "cors.go"))
+	if err != nil {
+		t.Fatalf("read cors.go: %v", err)
+	}
+	if !strings.Contains(string(raw), `[]string{"https://a.example", "https://b.example"}`) {
+		t.Fatalf("expected origins in cors.go:\n%s", raw)
+	}
+
+	if _, err := InstallFeatureWithOptions(root, "echo", "redis", map[string]string{"addr": "cache:6380"}, nil); err != nil {
+		t.Fatalf("install redis: %v", err)
+	}
+	env, err := os.ReadFile(filepath.Join(root, ".env"))
+	if err != nil {
+		t.Fatalf("read .env: %v", err)
+	}
+	if !strings.Contains(string(env), "REDIS_ADDR=cache:6380\n") {
+		t.Fatalf("expected REDIS_ADDR in .env, got:\n%s", env)
+	}
+}
+
+func TestInstallFeatureRejectsInvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		feature string
+		options map[string]string
+		want    string
+	}{
+		"non-integer":        {feature: "rate-limit", options: map[string]string{"rps": "fast"}},
+		"not positive":       {feature: "rate-limit", options: map[string]string{"burst": "0"}},
+		"unknown":            {feature: "rate-limit", options: map[string]string{"window": "1m"}},
+		"unknown hash":       {feature: "auth-flow", options: map[string]string{"hash": "md5"}, want: "bcrypt or argon2"},
+		"unknown store":      {feature: "sessions", options: map[string]string{"store": "mongo"}},
+		"invalid frame opts": {feature: "hardening", options: map[string]string{"frame-options": "ALLOW"}},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			root := t.TempDir()
+			_, err := InstallFeatures(root, "gin", []FeatureRequest{{Name: tc.feature, Options: tc.options}}, nil)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected %s options %v to be rejected, got %v", tc.feature, tc.options, err)
+			}
+			if _, err := os.Stat(filepath.Join(root, "internal")); !os.IsNotExist(err) {
+				t.Fatalf("expected nothing to be written for invalid options, err=%v", err)
+			}
+		})
+	}
+}
+
+func TestInstallFeatureUsesProjectLayout(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/app\n\ngo 1.25\n"), 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, ".lalibela"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	layout := `{"middleware": "internal/http/middleware", "storage": "internal/platform/db"}`
+	if err := os.WriteFile(filepath.Join(root, ".lalibela", "layout.json"), []byte(layout), 0o644); err != nil {
+		t.Fatalf("write layout: %v", err)
+	}
+
+	results, err := InstallFeatures(root, "gin", []FeatureRequest{{Name: "cors"}, {Name: "redis"}}, nil)
+	if err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	for path, clause := range map[string]string{
+		"internal/http/middleware/cors.go":      "package middleware",
+		"internal/http/middleware/cors_test.go": "package middleware",
+		"internal/platform/db/redis.go":         "package db",
+	} {
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatalf("expected %s to be written: %v", path, err)
+		}
+		if !strings.HasPrefix(string(content), clause+"\n") {
+			t.Fatalf("expected %s to start with %q, got %q", path, clause, strings.SplitN(string(content), "\n", 2)[0])
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "internal", "server")); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing in the default package, err=%v", err)
+	}
+
+	// Without a main.go the wiring is reported as manual steps, which name the
+	// module-qualified package.
+	steps := strings.Join(results[0].ManualSteps, "\n")
+	if !strings.Contains(steps, "middleware.CORSMiddleware()") || !strings.Contains(steps, "example.com/app/internal/http/middleware") {
+		t.Fatalf("expected manual steps to use the configured package, got:\n%s", steps)
+	}
+}
+
+func TestInstallFeatureRejectsInvalidLayout(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".lalibela"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".lalibela", "layout.json"), []byte(`{"handlers": "internal/handlers"}`), 0o644); err != nil {
+		t.Fatalf("write layout: %v", err)
+	}
+	if _, err := InstallFeature(root, "gin", "logger", nil); err == nil || !strings.Contains(err.Error(), `unknown role "handlers"`) {
+		t.Fatalf("expected unknown role error, got %v", err)
+	}
+}
+
+func TestInstallFeatureBuildsOnEveryFramework(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		options   map[string]string
+		installed []string
+		files     []string
+		contains  map[string][]string
+		check     func(t *testing.T, root string)
+	}{
+		{
+			name:      "cors",
+			options:   map[string]string{"origins": "https://app.example"},
+			installed: []string{"cors"},
+			files:     []string{"internal/server/cors.go", "internal/server/cors_test.go"},
+		},
+		{
+			name:      "rate-limit",
+			installed: []string{"config", "rate-limit"},
+			files:     []string{"internal/server/rate_limit.go", "internal/server/rate_limit_test.go"},
+		},
+		{
+			name:      "auth",
+			installed: []string{"auth"},
+			files:     []string{"internal/server/auth_jwt.go", "internal/server/auth_jwt_test.go", "internal/server/auth_middleware.go", "internal/server/auth_middleware_test.go"},
+		},
+		{
+			name:      "auth-flow",
+			options:   map[string]string{"hash": "argon2"},
+			installed: []string{"auth", "auth-flow"},
+			files:     []string{"internal/server/auth_flow.go", "internal/server/auth_flow_test.go", "internal/server/auth_store_memory.go", "internal/server/auth_store_postgres.go", "internal/server/auth_routes.go", "internal/server/auth_routes_test.go", "db/migrations/0002_auth_flow.sql"},
+			contains:  map[string][]string{"internal/server/auth_flow.go": {"return DefaultArgon2Hasher"}},
+		},
+		{
+			name:      "api-key",
+			installed: []string{"api-key"},
+			files:     []string{"internal/server/api_key.go", "internal/server/api_key_middleware.go", "cmd/apikeys/main.go", "db/migrations/0003_api_keys.sql"},
+			contains:  map[string][]string{"cmd/apikeys/main.go": {`"demo/internal/server"`, "server.MintAPIKey("}},
+		},
+		{
+			name:      "oidc",
+			installed: []string{"auth", "oidc"},
+			files:     []string{"internal/server/oidc.go", "internal/server/oidc_test.go", "internal/server/oidc_routes.go", "internal/server/oidc_routes_test.go"},
+		},
+		{
+			name:      "rbac",
+			installed: []string{"auth", "rbac"},
+			files:     []string{"internal/server/rbac.go", "internal/server/rbac_test.go", "internal/server/rbac_middleware.go", "internal/server/rbac_middleware_test.go", "rbac_policy.json"},
+			check: func(t *testing.T, root string) {
+				if raw := readFile(t, filepath.Join(root, "rbac_policy.json")); !json.Valid([]byte(raw)) {
+					t.Fatalf("policy file is not valid JSON:\n%s", raw)
+				}
+			},
+		},
+		{
+			name:      "sessions",
+			options:   map[string]string{"store": "postgres"},
+			installed: []string{"sessions"},
+			files:     []string{"internal/server/sessions.go", "internal/server/sessions_test.go", "internal/server/sessions_middleware.go", "internal/server/sessions_middleware_test.go", "internal/server/session_store_postgres.go", "db/migrations/0004_sessions.sql"},
+			check: func(t *testing.T, root string) {
+				if _, err := os.Stat(filepath.Join(root, "internal", "server", "session_store_redis.go")); !os.IsNotExist(err) {
+					t.Fatalf("expected no redis store with --store postgres, got %v", err)
+				}
+			},
+		},
+		{
+			name:      "hardening",
+			options:   map[string]string{"frame-options": "SAMEORIGIN", "max-body": "2048", "hsts-max-age": "0"},
+			installed: []string{"hardening"},
+			files:     []string{"internal/server/hardening.go", "internal/server/hardening_middleware.go", "internal/server/hardening_middleware_test.go"},
+			contains:  map[string][]string{"internal/server/hardening.go": {`"SAMEORIGIN"`, "2048", "0 * time.Second", `"strict-origin-when-cross-origin"`}},
+		},
+	}
+	for _, framework := range []string{"gin", "echo", "fiber", "nethttp"} {
+		for _, tc := range cases {
+			t.Run(framework+"/"+tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				root := newGoProject(t)
+				results, err := InstallFeatures(root, framework, []FeatureRequest{{Name: tc.name, Options: tc.options}}, nil)
+				if err != nil {
+					t.Fatalf("install: %v", err)
+				}
+				var installed []string
+				for _, result := range results {
+					if !result.Compatible || !result.Installed {
+						t.Fatalf("expected %s to install on %s, got %+v", result.Name, framework, result)
+					}
+					installed = append(installed, result.Name)
+				}
+				if strings.Join(installed, ",") != strings.Join(tc.installed, ",") {
+					t.Fatalf("expected %v to be installed in order, got %v", tc.installed, installed)
+				}
+				for _, path := range tc.files {
+					raw := readFile(t, filepath.Join(root, filepath.FromSlash(path)))
+					if strings.HasSuffix(path, ".go") {
+						if _, err := parser.ParseFile(token.NewFileSet(), path, raw, 0); err != nil {
+							t.Fatalf("%s does not parse: %v", path, err)
+						}
+					}
+					for _, want := range tc.contains[path] {
+						if !strings.Contains(raw, want) {
+							t.Fatalf("expected %s in %s:\n%s", want, path, raw)
+						}
+					}
+				}
+				if tc.check != nil {
+					tc.check(t, root)
+				}
+				testProject(t, root)
+			})
+		}
+	}
+}
+
+// newGoProject returns a project root whose go.mod pins the modules the
+// feature templates import, so rendered code can be built from the module
+// cache.
+func newGoProject(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	goMod := `module demo
+
+go 1.23
+
+require (
+	github.com/gin-gonic/gin v1.12.0
+	github.com/gofiber/fiber/v2 v2.52.15
+	github.com/golang-jwt/jwt/v5 v5.3.1
+	github.com/jackc/pgx/v5 v5.9.2
+	github.com/labstack/echo/v4 v4.16.0
+	github.com/pelletier/go-toml/v2 v2.2.4
+	github.com/redis/go-redis/v9 v9.22.0
+	golang.org/x/crypto v0.48.0
+	golang.org/x/time v0.9.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+`
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte(goMod), 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+	return root
+}
+
+// testProject vets and runs the tests of every package of a rendered project,
+// so the tests the features generate are held to the same bar as their code.
+// It skips when the pinned modules cannot be downloaded.
+func testProject(t *testing.T, root string) {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("skipping build of rendered code in short mode")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not found")
+	}
+	run := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("go", args...)
+		cmd.Dir = root
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off")
+		return cmd.CombinedOutput()
+	}
+	if out, err := run("mod", "download"); err != nil {
+		t.Skipf("pinned modules unavailable: %v\n%s", err, out)
+	}
+	if out, err := run("vet", "./..."); err != nil {
+		t.Fatalf("rendered code does not build: %v\n%s", err, out)
+	}
+	if out, err := run("test", "./..."); err != nil {
+		t.Fatalf("rendered tests fail: %v\n%s", err, out)
 	}
 }
@@ -0,0 +1,168 @@
+package features
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(content)
+}
+
+func TestInstallFeaturesMergesEnvVars(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".env"), []byte("PORT=8080\nDB_HOST=db.internal\n"), 0o644); err != nil {
+		t.Fatalf("write .env: %v", err)
+	}
+
+	results, err := InstallFeatures(root, "gin", []FeatureRequest{{Name: "postgres"}}, nil)
+	if err != nil {
+		t.Fatalf("install postgres: %v", err)
+	}
+
+	env := readFile(t, filepath.Join(root, ".env"))
+	if !strings.Contains(env, "DB_HOST=db.internal\n") || strings.Contains(env, "DB_HOST=localhost") {
+		t.Fatalf("expected the existing DB_HOST to be kept, got:\n%s", env)
+	}
+	if !strings.Contains(env, "DB_PORT=5432\n") || !strings.Contains(env, "DB_PASSWORD=\n") {
+		t.Fatalf("expected missing vars appended to .env, got:\n%s", env)
+	}
+	example := readFile(t, filepath.Join(root, shared.EnvExampleFile))
+	if !strings.Contains(example, "# PostgreSQL password\nDB_PASSWORD=\n") || !strings.Contains(example, "DB_HOST=localhost\n") {
+		t.Fatalf("unexpected .env.example:\n%s", example)
+	}
+	if results[0].Name != "config" || results[0].RequiredBy != "postgres" {
+		t.Fatalf("expected config to be installed for postgres, got %+v", results[0])
+	}
+	if got := strings.Join(results[1].Env, ","); got != "DB_HOST,DB_PORT,DB_USER,DB_PASSWORD,DB_NAME,DB_SSLMODE" {
+		t.Fatalf("unexpected env in result: %s", got)
+	}
+}
+
+func TestMergeEnvFilesIsIdempotent(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	vars := []shared.EnvVar{
+		{Name: "API_URL", Default: "http://localhost", Description: "API base URL"},
+		{Name: "API_TOKEN", Default: "dev-token", Description: "API token", Secret: true},
+	}
+	added, err := shared.MergeEnvFiles(root, vars)
+	if err != nil || len(added) != 2 {
+		t.Fatalf("first merge: added %v, err %v", added, err)
+	}
+	env := readFile(t, filepath.Join(root, ".env"))
+	example := readFile(t, filepath.Join(root, shared.EnvExampleFile))
+	if env != "API_URL=http://localhost\nAPI_TOKEN=dev-token\n" {
+		t.Fatalf("unexpected .env:\n%s", env)
+	}
+	if example != "# API base URL\nAPI_URL=http://localhost\n# API token\nAPI_TOKEN=\n" {
+		t.Fatalf("expected the secret blank in .env.example, got:\n%s", example)
+	}
+
+	added, err = shared.MergeEnvFiles(root, vars)
+	if err != nil || len(added) != 0 {
+		t.Fatalf("second merge: added %v, err %v", added, err)
+	}
+	if readFile(t, filepath.Join(root, ".env")) != env || readFile(t, filepath.Join(root, shared.EnvExampleFile)) != example {
+		t.Fatalf("expected a second merge to change nothing")
+	}
+}
+
+func TestMergeEnvFilesGeneratesSecrets(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	vars := []shared.EnvVar{{Name: "SESSION_SECRET", Description: "Session secret", Secret: true, Generate: true}}
+	if _, err := shared.MergeEnvFiles(root, vars); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	first, _ := shared.EnvFileValues(root)
+	if len(first["SESSION_SECRET"]) < 32 {
+		t.Fatalf("expected a random SESSION_SECRET, got %q", first["SESSION_SECRET"])
+	}
+	if example := readFile(t, filepath.Join(root, shared.EnvExampleFile)); example != "# Session secret\nSESSION_SECRET=\n" {
+		t.Fatalf("expected a blank placeholder in .env.example, got:\n%s", example)
+	}
+	if gitignore := readFile(t, filepath.Join(root, shared.GitignoreFile)); gitignore != ".env\n" {
+		t.Fatalf("expected .env to be gitignored, got:\n%s", gitignore)
+	}
+	info, err := os.Stat(filepath.Join(root, ".env"))
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected a private .env, got %v, %v", info, err)
+	}
+
+	if _, err := shared.MergeEnvFiles(root, vars); err != nil {
+		t.Fatalf("second merge: %v", err)
+	}
+	if second, _ := shared.EnvFileValues(root); second["SESSION_SECRET"] != first["SESSION_SECRET"] {
+		t.Fatalf("expected a second merge to keep the generated secret")
+	}
+}
+
+func TestRemoveFeaturesRemovesEnvVars(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if _, err := InstallFeatures(root, "gin", []FeatureRequest{{Name: "config"}, {Name: "redis"}}, nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if env := readFile(t, filepath.Join(root, ".env")); !strings.Contains(env, "REDIS_ADDR=localhost:6379") {
+		t.Fatalf("expected REDIS_ADDR in .env, got:\n%s", env)
+	}
+
+	results, err := RemoveFeatures(root, []string{"redis"}, false, nil)
+	if err != nil {
+		t.Fatalf("remove redis: %v", err)
+	}
+	if len(results[0].Env) != 1 || results[0].Env[0] != "REDIS_ADDR" {
+		t.Fatalf("expected REDIS_ADDR reported as removed, got %v", results[0].Env)
+	}
+	for _, name := range []string{".env", shared.EnvExampleFile} {
+		content := readFile(t, filepath.Join(root, name))
+		if strings.Contains(content, "REDIS_ADDR") || strings.Contains(content, "Redis server address") {
+			t.Fatalf("expected REDIS_ADDR removed from %s, got:\n%s", name, content)
+		}
+		if !strings.Contains(content, "APP_NAME=") {
+			t.Fatalf("expected config's vars to stay in %s, got:\n%s", name, content)
+		}
+	}
+}
+
+func TestRemoveFeaturesKeepsEnvVarsOtherFeaturesRead(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if _, err := InstallFeatures(root, "gin", []FeatureRequest{{Name: "postgres"}}, nil); err != nil {
+		t.Fatalf("install postgres: %v", err)
+	}
+	// A second feature that reads DB_HOST, installed after postgres added it.
+	state, err := loadState(root)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	state.Installed = append(state.Installed, "replica")
+	state.Features["replica"] = FeatureRecord{Files: []OwnedFile{}, Env: []shared.EnvVar{{Name: "DB_HOST"}}}
+	if err := saveState(root, state); err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+
+	if _, err := RemoveFeatures(root, []string{"postgres"}, false, nil); err != nil {
+		t.Fatalf("remove postgres: %v", err)
+	}
+	env := readFile(t, filepath.Join(root, ".env"))
+	if !strings.Contains(env, "DB_HOST=") || strings.Contains(env, "DB_PORT=") {
+		t.Fatalf("expected only DB_HOST to stay, got:\n%s", env)
+	}
+}
@@ -1,2 +0,0 @@
-// Package errorhandler provides the "error-handler" scaffold feature installer.
-package errorhandler
@@ -1,11 +1,56 @@
 package features
 
+import "github.com/naodEthiop/lalibela-cli/internal/features/shared"
+
 // Feature describes an optional scaffold feature that can be installed into a
 // generated project (for example: logger, postgres, docker).
 type Feature interface {
 	Name() string
 	Compatible(framework string) bool
-	Install(projectRoot string) error
+	Install(target *shared.Target) error
+}
+
+// Wirer is implemented by features that can register themselves in a
+// generated project's main.go or routes for the target's framework.
+type Wirer interface {
+	Wiring(target *shared.Target) (shared.Wiring, bool)
+}
+
+// Configurable is implemented by features that accept install options, such
+// as rate-limit's --rps and --burst.
+type Configurable interface {
+	Options() []shared.Option
+}
+
+// Describer is implemented by features that provide a one-line summary for
+// listings such as `lalibela features`.
+type Describer interface {
+	Description() string
+}
+
+// Versioned is implemented by features that declare a version for their
+// installer. The version is recorded in the project state at install so
+// outdated installs can be upgraded.
+type Versioned interface {
+	Version() string
+}
+
+// EnvDeclarer is implemented by features whose generated code reads
+// environment variables.
+type EnvDeclarer interface {
+	EnvVars() []shared.EnvVar
+}
+
+// Documenter is implemented by features that can show how their generated
+// code is used on the target's framework.
+type Documenter interface {
+	Usage(target *shared.Target) string
+}
+
+// Dependent is implemented by features that build on other features. The
+// required features are installed first.
+type Dependent interface {
+	Requires() []string
 }
 
 // CommandRunner runs an external command in the given directory.
@@ -17,4 +62,45 @@ type InstallResult struct {
 	Installed      bool
 	AlreadyPresent bool
 	Compatible     bool
+	// RequiredBy names the feature that pulled this one into the install
+	// when it was not requested directly.
+	RequiredBy string
+	// Options holds the option values the feature was installed with.
+	Options map[string]string
+	// Wired lists project files that were edited to register the feature.
+	Wired []string
+	// Env lists the variables added to .env and .env.example.
+	Env []string
+	// ManualSteps describes how to register the feature by hand when it could
+	// not be wired automatically.
+	ManualSteps []string
+	// Conflicts records how existing files that differed from the feature's
+	// version were settled.
+	Conflicts []shared.ConflictOutcome
+}
+
+// RemoveResult describes the outcome of removing a feature from a project.
+type RemoveResult struct {
+	Name string
+	// Deleted lists files that were removed from the project.
+	Deleted []string
+	// Modified lists files that changed since install. They are kept unless
+	// the removal was forced, in which case they are also listed in Deleted.
+	Modified []string
+	// Missing lists owned files that no longer exist in the project.
+	Missing []string
+	// Untracked is true when the project state has no file ownership data for
+	// the feature (for example, it was installed by an older CLI version).
+	Untracked bool
+	// StaleWiring describes wiring edits that could not be reverted because
+	// the edited code changed since install.
+	StaleWiring []string
+	// Env lists the variables removed from .env and .env.example.
+	Env []string
+	// Edited lists project files the installer edited, such as a plugin's
+	// edits, which removal does not revert.
+	Edited []string
+	// RequiredBy lists installed features that still require this one. It is
+	// only set when the removal was forced.
+	RequiredBy []string
 }
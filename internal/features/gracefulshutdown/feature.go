@@ -13,13 +13,26 @@ func New() Feature { return Feature{} }
 // Name returns the registry name of the feature.
 func (Feature) Name() string { return "graceful-shutdown" }
 
+// Description returns a one-line summary of the feature.
+func (Feature) Description() string {
+	return "Signal handling that drains in-flight requests before exit"
+}
+
+// Version returns the version of the feature's installer. It changes
+// whenever the files the feature writes change.
+func (Feature) Version() string { return "1.0.0" }
+
 // Compatible reports whether the feature supports a given framework.
 func (Feature) Compatible(framework string) bool {
 	return shared.IsFeatureCompatible("graceful-shutdown", framework)
 }
 
-// Install writes the feature's scaffold files into projectRoot.
-func (Feature) Install(projectRoot string) error {
+// Requires returns the features graceful shutdown builds on: it logs
+// through the logger feature's *slog.Logger.
+func (Feature) Requires() []string { return []string{"logger"} }
+
+// Install writes the feature's scaffold files into target.
+func (Feature) Install(target *shared.Target) error {
 	const file = `package server
 
 import (
@@ -46,5 +59,36 @@ func WaitForShutdown(logger *slog.Logger, shutdownTimeout time.Duration, shutdow
 	logger.Info("server shutdown complete")
 }
 `
-	return shared.WriteFileIfMissing(projectRoot, "internal/server/graceful_shutdown.go", []byte(file))
+	return target.WriteGoFile(shared.RoleServer, "graceful_shutdown.go", file)
+}
+
+// Wiring returns how graceful shutdown is registered in main.go.
+func (Feature) Wiring(target *shared.Target) (shared.Wiring, bool) {
+	return shared.Wiring{
+		GracefulShutdown: true,
+		Shutdown:         target.Package(shared.RoleServer) + ".WaitForShutdown",
+		Imports:          []string{target.Import(shared.RoleServer)},
+	}, true
+}
+
+// Usage returns a snippet showing how to use the feature on the target's
+// framework.
+func (Feature) Usage(target *shared.Target) string {
+	return target.Qualify(shared.RoleServer, shared.Variant(usage, target.Framework))
+}
+
+// usage shows how main.go serves in the background and waits for a signal.
+var usage = map[string]string{
+	"fiber": `go func() {
+	if err := app.Listener(listener); err != nil {
+		log.Fatal(err)
+	}
+}()
+server.WaitForShutdown(slog.Default(), 10*time.Second, app.ShutdownWithContext)`,
+	"nethttp": `go func() {
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}()
+server.WaitForShutdown(slog.Default(), 10*time.Second, srv.Shutdown)`,
 }
@@ -0,0 +1,2 @@
+// Package hardening provides the "hardening" scaffold feature installer.
+package hardening
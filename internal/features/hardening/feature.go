@@ -0,0 +1,129 @@
+package hardening
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// Feature installs the "hardening" scaffold feature.
+type Feature struct{}
+
+// New returns a new "hardening" feature installer.
+func New() Feature { return Feature{} }
+
+// Name returns the registry name of the feature.
+func (Feature) Name() string { return "hardening" }
+
+// Description returns a one-line summary of the feature.
+func (Feature) Description() string {
+	return "Security headers, request body limits, server timeouts and JSON panic recovery"
+}
+
+// Version returns the version of the feature's installer. It changes
+// whenever the files the feature writes change.
+func (Feature) Version() string { return "1.0.0" }
+
+// Compatible reports whether the feature supports a given framework.
+func (Feature) Compatible(framework string) bool {
+	return shared.IsFeatureCompatible("hardening", framework)
+}
+
+// defaultCSP allows the scaffold's welcome page, which uses inline styles,
+// and forbids framing, plugins and foreign scripts.
+const defaultCSP = "default-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; object-src 'none'; frame-ancestors 'none'; base-uri 'self'; form-action 'self'"
+
+// Options returns the settings the feature accepts at install time.
+func (Feature) Options() []shared.Option {
+	return []shared.Option{
+		{Name: "csp", Type: shared.OptionString, Default: defaultCSP, Description: "Content-Security-Policy header; empty omits it"},
+		{Name: "frame-options", Type: shared.OptionString, Default: "DENY", Description: "X-Frame-Options header: DENY or SAMEORIGIN", Validate: validateFrameOptions},
+		{Name: "referrer-policy", Type: shared.OptionString, Default: "strict-origin-when-cross-origin", Description: "Referrer-Policy header", Validate: validateReferrerPolicy},
+		{Name: "hsts-max-age", Type: shared.OptionInt, Default: "31536000", Description: "Strict-Transport-Security max-age in seconds, sent over HTTPS; 0 omits it", Validate: nonNegativeInt},
+		{Name: "max-body", Type: shared.OptionInt, Default: "1048576", Description: "Largest request body in bytes; larger ones get 413", Validate: shared.PositiveInt},
+		{Name: "read-timeout", Type: shared.OptionInt, Default: "15", Description: "Server read timeout in seconds", Validate: shared.PositiveInt},
+		{Name: "write-timeout", Type: shared.OptionInt, Default: "30", Description: "Server write timeout in seconds", Validate: shared.PositiveInt},
+		{Name: "idle-timeout", Type: shared.OptionInt, Default: "60", Description: "Server keep-alive idle timeout in seconds", Validate: shared.PositiveInt},
+	}
+}
+
+func validateFrameOptions(value string) error {
+	if value != "DENY" && value != "SAMEORIGIN" {
+		return fmt.Errorf("must be DENY or SAMEORIGIN, got %q", value)
+	}
+	return nil
+}
+
+// referrerPolicies lists the values browsers accept in Referrer-Policy.
+var referrerPolicies = map[string]bool{
+	"no-referrer":                     true,
+	"no-referrer-when-downgrade":      true,
+	"origin":                          true,
+	"origin-when-cross-origin":        true,
+	"same-origin":                     true,
+	"strict-origin":                   true,
+	"strict-origin-when-cross-origin": true,
+	"unsafe-url":                      true,
+}
+
+func validateReferrerPolicy(value string) error {
+	if !referrerPolicies[value] {
+		return fmt.Errorf("%q is not a Referrer-Policy value such as strict-origin-when-cross-origin", value)
+	}
+	return nil
+}
+
+func nonNegativeInt(value string) error {
+	if n, _ := strconv.Atoi(value); n < 0 {
+		return fmt.Errorf("must be 0 or more, got %s", value)
+	}
+	return nil
+}
+
+// Install writes the security policy, rendered with the install options, and
+// the middleware variant for the target's framework.
+func (Feature) Install(target *shared.Target) error {
+	policy := fmt.Sprintf(policySource,
+		target.Options.Int("hsts-max-age"),
+		target.Options.String("csp"),
+		target.Options.String("frame-options"),
+		target.Options.String("referrer-policy"),
+		target.Options.Int("max-body"),
+	)
+	files := []struct{ name, source string }{
+		{"hardening.go", hardeningSource + policy},
+		{"hardening_middleware.go", shared.Variant(middlewareSources, target.Framework)},
+		{"hardening_middleware_test.go", shared.Variant(testHarnesses, target.Framework) + testCases},
+	}
+	for _, file := range files {
+		if err := target.WriteGoFile(shared.RoleMiddleware, file.name, file.source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Wiring returns how the middleware is registered and the server timeouts
+// set, using the timeout install options.
+func (Feature) Wiring(target *shared.Target) (shared.Wiring, bool) {
+	pkg := target.Package(shared.RoleMiddleware)
+	seconds := func(option string) string {
+		return fmt.Sprintf("%d * time.Second", target.Options.Int(option))
+	}
+	return shared.Wiring{
+		Middleware: fmt.Sprintf("%s.HardeningMiddleware(%s.DefaultSecurityPolicy())", pkg, pkg),
+		Timeouts: &shared.Timeouts{
+			Read:  seconds("read-timeout"),
+			Write: seconds("write-timeout"),
+			Idle:  seconds("idle-timeout"),
+		},
+		Imports: []string{target.Import(shared.RoleMiddleware)},
+	}, true
+}
+
+// Usage returns a snippet showing how to apply a different policy to a
+// group of routes on the target's framework.
+func (Feature) Usage(target *shared.Target) string {
+	return target.Qualify(shared.RoleMiddleware, shared.Variant(usage, target.Framework))
+}
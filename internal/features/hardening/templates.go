@@ -0,0 +1,37 @@
+package hardening
+
+import (
+	"embed"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// templateFS holds the files the feature renders and the snippets it shows,
+// one file per template and one directory per set of framework variants.
+//
+//go:embed templates
+var templateFS embed.FS
+
+// hardeningSource holds the framework-independent security policy: the
+// headers it sets, the body limit check and the JSON error bodies.
+var hardeningSource = shared.MustReadTemplate(templateFS, "templates/hardening.go.tmpl")
+
+// policySource holds DefaultSecurityPolicy, rendered with the csp,
+// frame-options, referrer-policy, hsts-max-age and max-body options.
+var policySource = shared.MustReadTemplate(templateFS, "templates/policy.go.tmpl")
+
+// middlewareSources holds HardeningMiddleware rendered for each framework.
+// Every variant recovers panics as a JSON 500, sets the policy's headers and
+// rejects bodies over the limit with a JSON 413.
+var middlewareSources = shared.MustReadVariants(templateFS, "templates/middleware")
+
+// testCases is shared by every framework's test so each variant is checked
+// against the same expectations.
+var testCases = shared.MustReadTemplate(templateFS, "templates/test_cases.go.tmpl")
+
+// testHarnesses runs testCases through each framework's router.
+var testHarnesses = shared.MustReadVariants(templateFS, "templates/test_harnesses")
+
+// usage shows how to apply a stricter policy to a group of API routes.
+// Routes outside the group keep DefaultSecurityPolicy, wired into main.go.
+var usage = shared.MustReadVariants(templateFS, "templates/usage")
@@ -1,2 +0,0 @@
-// Package health provides the "health" scaffold feature installer.
-package health
@@ -0,0 +1,352 @@
+package features
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+	"github.com/naodEthiop/lalibela-cli/internal/features/wiring"
+)
+
+// FeatureRequest names a feature to install and the option values passed for
+// it on the command line.
+type FeatureRequest struct {
+	Name    string
+	Options map[string]string
+}
+
+// InstallFeatures installs several features into the project in one
+// transaction. Every request is validated before anything is written:
+// unknown names, features that do not support the framework and invalid
+// options are all reported together. Features already installed are skipped.
+//
+// Features are installed after the features they require, which are added to
+// the install when missing. If any install fails, every file written so far
+// is removed, edited files are restored, and the project state is left
+// untouched. State is saved and `go mod tidy` is run once at the end; if
+// either fails, the install is rolled back too, including go.mod and go.sum.
+//
+// Existing project files that differ from a feature's version are kept.
+func InstallFeatures(projectRoot, framework string, requests []FeatureRequest, runner CommandRunner) ([]InstallResult, error) {
+	return InstallFeaturesWithResolver(projectRoot, framework, requests, nil, runner)
+}
+
+// InstallFeaturesWithResolver is InstallFeatures with resolve deciding what
+// happens to each existing file a feature wants to write with different
+// content. A nil resolve keeps existing files.
+func InstallFeaturesWithResolver(projectRoot, framework string, requests []FeatureRequest, resolve shared.Resolver, runner CommandRunner) ([]InstallResult, error) {
+	return installFeatures(projectRoot, framework, requests, resolve, runner, false)
+}
+
+// Conflicts validates requests like InstallFeatures and returns the existing
+// files the install would collide with, without writing anything. Callers use
+// it to ask how conflicts should be resolved before installing.
+func Conflicts(projectRoot, framework string, requests []FeatureRequest) ([]shared.Conflict, error) {
+	state, err := loadState(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	if state.Framework == "" {
+		state.Framework = strings.ToLower(strings.TrimSpace(framework))
+	}
+	steps, err := planInstall(state, requests, false)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := loadProjectSettings(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []shared.Conflict
+	for _, step := range steps {
+		if step.result.AlreadyPresent {
+			continue
+		}
+		target := settings.apply(shared.NewDryRunTarget(projectRoot, state.Framework))
+		target.Feature = step.result.Name
+		target.Options = step.options
+		if err := step.feature.Install(target); err != nil {
+			return nil, fmt.Errorf("planning feature %q: %w", step.result.Name, err)
+		}
+		conflicts = append(conflicts, target.PendingConflicts()...)
+	}
+	return conflicts, nil
+}
+
+// installStep is one feature in an install plan.
+type installStep struct {
+	feature Feature
+	options shared.Options
+	result  InstallResult
+}
+
+// appliedInstall is the undo information for a feature installed during a
+// transaction.
+type appliedInstall struct {
+	target  *shared.Target
+	patches []wiring.Patch
+}
+
+// transactionFiles are project files an install changes outside of features:
+// the module files rewritten by `go mod tidy` and the feature state.
+var transactionFiles = []string{"go.mod", "go.sum", statePath}
+
+// installFeatures runs an install transaction. With skipIncompatible, requested
+// features that do not support the framework are reported with
+// Compatible=false instead of failing the install.
+//
+// The transaction has three phases. Every feature is first staged by rendering
+// it without touching the project, so template and option errors surface
+// before anything is written. The staged features are then applied, and the
+// state is saved and `go mod tidy` run as the commit. A failure in any phase
+// after staging undoes everything: written files are removed, edited files,
+// go.mod, go.sum and the feature state are restored.
+func installFeatures(projectRoot, framework string, requests []FeatureRequest, resolve shared.Resolver, runner CommandRunner, skipIncompatible bool) ([]InstallResult, error) {
+	state, err := loadState(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	if state.Framework == "" {
+		state.Framework = strings.ToLower(strings.TrimSpace(framework))
+	}
+
+	steps, err := planInstall(state, requests, skipIncompatible)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := loadProjectSettings(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	pending := 0
+	for _, step := range steps {
+		if !step.result.Compatible || step.result.AlreadyPresent {
+			continue
+		}
+		if err := stageInstall(settings.apply(shared.NewDryRunTarget("", state.Framework)), step); err != nil {
+			return nil, err
+		}
+		pending++
+	}
+
+	results := make([]InstallResult, 0, len(steps))
+	if pending == 0 {
+		for _, step := range steps {
+			results = append(results, step.result)
+		}
+		return results, nil
+	}
+
+	// The journal target preserves the files the commit phase rewrites.
+	journal := shared.NewTarget(projectRoot, state.Framework)
+	for _, path := range transactionFiles {
+		if err := journal.Preserve(path); err != nil {
+			return nil, fmt.Errorf("preparing feature install: %w", err)
+		}
+	}
+	applied := []appliedInstall{{target: journal}}
+	abort := func(err error) error {
+		rollbackErr := rollbackInstalls(projectRoot, applied)
+		pruneEmptyDirs(projectRoot, filepath.Dir(filepath.Join(projectRoot, filepath.FromSlash(statePath))))
+		if rollbackErr != nil {
+			return fmt.Errorf("%w (rollback incomplete: %v)", err, rollbackErr)
+		}
+		return fmt.Errorf("%w (all changes were rolled back)", err)
+	}
+
+	for i := range steps {
+		step := &steps[i]
+		if !step.result.Compatible || step.result.AlreadyPresent {
+			continue
+		}
+
+		target := settings.apply(shared.NewTarget(projectRoot, state.Framework))
+		target.Feature = step.result.Name
+		target.Resolver = resolve
+		target.Options = step.options
+		record, err := installOne(target, step)
+		applied = append(applied, appliedInstall{target: target, patches: record.Wiring})
+		if err != nil {
+			return nil, abort(fmt.Errorf("installing feature %q: %w", step.result.Name, err))
+		}
+
+		state.Installed = append(state.Installed, step.result.Name)
+		if state.Features == nil {
+			state.Features = make(map[string]FeatureRecord)
+		}
+		state.Features[step.result.Name] = record
+		step.result.Installed = true
+	}
+
+	sort.Strings(state.Installed)
+	if err := saveState(projectRoot, state); err != nil {
+		return nil, abort(err)
+	}
+	if runner != nil {
+		if err := runner(projectRoot, "go", "mod", "tidy"); err != nil {
+			return nil, abort(fmt.Errorf("go mod tidy after feature install: %w", err))
+		}
+	}
+	for _, step := range steps {
+		results = append(results, step.result)
+	}
+	return results, nil
+}
+
+// stageInstall renders step's feature into a dry-run target without writing
+// to the project.
+func stageInstall(target *shared.Target, step installStep) error {
+	target.Feature = step.result.Name
+	target.Options = step.options
+	if err := step.feature.Install(target); err != nil {
+		return fmt.Errorf("installing feature %q: %w (nothing was written)", step.result.Name, err)
+	}
+	return nil
+}
+
+// installOne writes a feature's files and wires it into the project.
+func installOne(target *shared.Target, step *installStep) (FeatureRecord, error) {
+	record := FeatureRecord{Files: []OwnedFile{}, Version: featureVersion(step.feature)}
+	if len(step.options) > 0 {
+		record.Options = step.options
+		step.result.Options = step.options
+	}
+	err := step.feature.Install(target)
+	for _, file := range target.Written() {
+		record.Files = append(record.Files, OwnedFile{Path: file.Path, SHA256: file.SHA256})
+	}
+	step.result.Conflicts = target.Conflicts()
+	record.Edited = target.Edited()
+	step.result.Wired = append(step.result.Wired, record.Edited...)
+	step.result.ManualSteps = append(step.result.ManualSteps, target.ManualSteps()...)
+	if err != nil {
+		return record, err
+	}
+	if declarer, ok := step.feature.(EnvDeclarer); ok {
+		added, err := target.MergeEnv(declarer.EnvVars())
+		if err != nil {
+			return record, err
+		}
+		record.Env = added
+		for _, envVar := range added {
+			step.result.Env = append(step.result.Env, envVar.Name)
+		}
+	}
+	if err := wireFeature(target, step.feature, &record, &step.result); err != nil {
+		return record, err
+	}
+	return record, nil
+}
+
+// rollbackInstalls undoes applied installs in reverse order.
+func rollbackInstalls(projectRoot string, applied []appliedInstall) error {
+	var errs []error
+	for i := len(applied) - 1; i >= 0; i-- {
+		if len(applied[i].patches) > 0 {
+			if _, err := wiring.Revert(projectRoot, applied[i].patches); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if err := applied[i].target.Rollback(); err != nil {
+			errs = append(errs, err)
+		}
+		for _, file := range applied[i].target.Written() {
+			pruneEmptyDirs(projectRoot, filepath.Dir(filepath.Join(projectRoot, filepath.FromSlash(file.Path))))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// planInstall validates requests against state and orders them so features
+// come after the features they require. Missing requirements are added to the
+// plan with RequiredBy set.
+func planInstall(state State, requests []FeatureRequest, skipIncompatible bool) ([]installStep, error) {
+	requested := make(map[string]map[string]string, len(requests))
+	order := make([]string, 0, len(requests))
+	var problems []string
+	for _, request := range requests {
+		name := strings.ToLower(strings.TrimSpace(request.Name))
+		if _, err := lookupFeature(name); err != nil {
+			problems = append(problems, unknownFeature(request.Name, err))
+			continue
+		}
+		if _, ok := requested[name]; ok {
+			continue
+		}
+		requested[name] = request.Options
+		order = append(order, name)
+	}
+
+	steps := make([]installStep, 0, len(order))
+	visited := make(map[string]bool)
+	visiting := make(map[string]bool)
+	var visit func(name, requiredBy string)
+	visit = func(name, requiredBy string) {
+		if visited[name] {
+			return
+		}
+		if visiting[name] {
+			problems = append(problems, fmt.Sprintf("feature %q has a circular requirement", name))
+			return
+		}
+		feature, err := lookupFeature(name)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("feature %q requires %s", requiredBy, unknownFeature(name, err)))
+			return
+		}
+
+		step := installStep{
+			feature: feature,
+			result: InstallResult{
+				Name:       name,
+				Compatible: feature.Compatible(state.Framework),
+				RequiredBy: requiredBy,
+			},
+		}
+		switch {
+		case !step.result.Compatible && requiredBy != "":
+			problems = append(problems, fmt.Sprintf("feature %q requires %q, which is not supported for %s", requiredBy, name, state.Framework))
+		case !step.result.Compatible && !skipIncompatible:
+			problems = append(problems, fmt.Sprintf("feature %q is not supported for %s", name, state.Framework))
+		case contains(state.Installed, name):
+			step.result.AlreadyPresent = true
+		case step.result.Compatible:
+			visiting[name] = true
+			if dependent, ok := feature.(Dependent); ok {
+				for _, dependency := range dependent.Requires() {
+					if !contains(state.Installed, dependency) {
+						visit(dependency, name)
+					}
+				}
+			}
+			delete(visiting, name)
+
+			var schema []shared.Option
+			if configurable, ok := feature.(Configurable); ok {
+				schema = configurable.Options()
+			}
+			options, err := shared.ResolveOptions(schema, requested[name])
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("feature %q: %v", name, err))
+			}
+			step.options = options
+		}
+		if _, ok := requested[name]; ok {
+			step.result.RequiredBy = ""
+		}
+		visited[name] = true
+		steps = append(steps, step)
+	}
+	for _, name := range order {
+		visit(name, "")
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+	return steps, nil
+}
@@ -0,0 +1,303 @@
+package features
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+func TestInstallFeaturesOrdersRequirementsAndTidiesOnce(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	var tidyCalls int
+	runner := func(dir string, name string, args ...string) error {
+		tidyCalls++
+		return nil
+	}
+	results, err := InstallFeatures(root, "gin", []FeatureRequest{{Name: "graceful-shutdown"}, {Name: "redis"}}, runner)
+	if err != nil {
+		t.Fatalf("install: %v", err)
+	}
+
+	var names []string
+	for _, result := range results {
+		if !result.Installed {
+			t.Fatalf("expected %s to be installed, got %+v", result.Name, result)
+		}
+		names = append(names, result.Name)
+	}
+	if got := strings.Join(names, ","); got != "logger,graceful-shutdown,config,redis" {
+		t.Fatalf("unexpected install order %s", got)
+	}
+	if results[0].RequiredBy != "graceful-shutdown" {
+		t.Fatalf("expected logger to be required by graceful-shutdown, got %+v", results[0])
+	}
+	if tidyCalls != 1 {
+		t.Fatalf("expected go mod tidy to run once, got %d", tidyCalls)
+	}
+
+	state, err := loadState(root)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if len(state.Installed) != 4 || len(state.Features) != 4 {
+		t.Fatalf("expected four features in state, got %+v", state)
+	}
+}
+
+func TestInstallFeaturesValidatesBeforeWriting(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	_, err := InstallFeatures(root, "fiber", []FeatureRequest{{Name: "config"}, {Name: "nope"}, {Name: "swagger"}}, nil)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{`"nope"`, `"swagger" is not supported for fiber`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error to mention %s, got %v", want, err)
+		}
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected nothing to be written, found %d entries", len(entries))
+	}
+}
+
+func TestInstallFeaturesSkipsInstalledFeatures(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if _, err := InstallFeature(root, "echo", "config", nil); err != nil {
+		t.Fatalf("install config: %v", err)
+	}
+	results, err := InstallFeatures(root, "echo", []FeatureRequest{{Name: "config"}, {Name: "docker"}}, nil)
+	if err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if !results[0].AlreadyPresent || !results[1].Installed {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+}
+
+func TestInstallFeaturesRollsBackOnFailure(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	envPath := filepath.Join(root, ".env")
+	if err := os.WriteFile(envPath, []byte("PORT=8080\n"), 0o644); err != nil {
+		t.Fatalf("write .env: %v", err)
+	}
+	// A file where the server package directory should be makes cors fail
+	// after redis was installed.
+	if err := os.MkdirAll(filepath.Join(root, "internal"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "internal", "server"), []byte("not a directory"), 0o644); err != nil {
+		t.Fatalf("write blocker: %v", err)
+	}
+
+	_, err := InstallFeatures(root, "gin", []FeatureRequest{{Name: "redis", Options: map[string]string{"addr": "cache:6380"}}, {Name: "cors"}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("expected rolled back install error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "internal", "storage")); !os.IsNotExist(err) {
+		t.Fatalf("expected redis files to be removed, err=%v", err)
+	}
+	if env, _ := os.ReadFile(envPath); string(env) != "PORT=8080\n" {
+		t.Fatalf("expected .env to be restored, got %q", env)
+	}
+	if _, err := os.Stat(filepath.Join(root, statePath)); !os.IsNotExist(err) {
+		t.Fatalf("expected no feature state to be saved, err=%v", err)
+	}
+}
+
+func TestInstallFeaturesKeepsConflictingFilesByDefault(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	dockerfile := filepath.Join(root, "deployments", "Dockerfile")
+	if err := os.MkdirAll(filepath.Dir(dockerfile), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(dockerfile, []byte("FROM scratch\n"), 0o644); err != nil {
+		t.Fatalf("write Dockerfile: %v", err)
+	}
+
+	conflicts, err := Conflicts(root, "gin", []FeatureRequest{{Name: "docker"}})
+	if err != nil {
+		t.Fatalf("conflicts: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Path != "deployments/Dockerfile" || conflicts[0].Feature != "docker" {
+		t.Fatalf("expected a Dockerfile conflict, got %+v", conflicts)
+	}
+
+	results, err := InstallFeatures(root, "gin", []FeatureRequest{{Name: "docker"}}, nil)
+	if err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if got := results[0].Conflicts; len(got) != 1 || got[0].Resolution != shared.ResolveKeep {
+		t.Fatalf("expected Dockerfile to be kept, got %+v", got)
+	}
+	if content, _ := os.ReadFile(dockerfile); string(content) != "FROM scratch\n" {
+		t.Fatalf("expected Dockerfile to be untouched, got %q", content)
+	}
+	state, err := loadState(root)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	for _, file := range state.Features["docker"].Files {
+		if file.Path == "deployments/Dockerfile" {
+			t.Fatal("expected a kept file not to be owned by the feature")
+		}
+	}
+}
+
+func TestInstallFeaturesResolvesConflicts(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	source := filepath.Join(root, "internal", "storage", "postgres.go")
+	migration := filepath.Join(root, "db", "migrations", "0001_init.sql")
+	for _, path := range []string{source, migration} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("custom\n"), 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	resolve := func(conflict shared.Conflict) (shared.Resolution, error) {
+		if conflict.Path == "internal/storage/postgres.go" {
+			return shared.ResolveOverwrite, nil
+		}
+		return shared.ResolveWriteNew, nil
+	}
+
+	results, err := InstallFeaturesWithResolver(root, "gin", []FeatureRequest{{Name: "postgres"}}, resolve, nil)
+	if err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if postgres := results[len(results)-1]; len(postgres.Conflicts) != 2 {
+		t.Fatalf("expected two conflicts, got %+v", postgres.Conflicts)
+	}
+	if content, _ := os.ReadFile(source); string(content) == "custom\n" {
+		t.Fatal("expected postgres.go to be overwritten")
+	}
+	if content, _ := os.ReadFile(migration); string(content) != "custom\n" {
+		t.Fatalf("expected the migration to be kept, got %q", content)
+	}
+	if _, err := os.Stat(migration + shared.NewFileSuffix); err != nil {
+		t.Fatalf("expected the feature's migration to be written alongside: %v", err)
+	}
+
+	state, err := loadState(root)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	owned := make(map[string]bool)
+	for _, file := range state.Features["postgres"].Files {
+		owned[file.Path] = true
+	}
+	if !owned["internal/storage/postgres.go"] || !owned["db/migrations/0001_init.sql"+shared.NewFileSuffix] || owned["db/migrations/0001_init.sql"] {
+		t.Fatalf("unexpected owned files %v", owned)
+	}
+}
+
+func TestInstallFeaturesRestoresOverwrittenFilesOnRollback(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	dockerfile := filepath.Join(root, "deployments", "Dockerfile")
+	if err := os.MkdirAll(filepath.Dir(dockerfile), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(dockerfile, []byte("FROM scratch\n"), 0o644); err != nil {
+		t.Fatalf("write Dockerfile: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "internal"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "internal", "server"), []byte("not a directory"), 0o644); err != nil {
+		t.Fatalf("write blocker: %v", err)
+	}
+	overwrite := func(shared.Conflict) (shared.Resolution, error) { return shared.ResolveOverwrite, nil }
+
+	_, err := InstallFeaturesWithResolver(root, "gin", []FeatureRequest{{Name: "docker"}, {Name: "cors"}}, overwrite, nil)
+	if err == nil {
+		t.Fatal("expected install to fail")
+	}
+	if content, _ := os.ReadFile(dockerfile); string(content) != "FROM scratch\n" {
+		t.Fatalf("expected Dockerfile to be restored, got %q", content)
+	}
+}
+
+func TestDiffShowsChangedLines(t *testing.T) {
+	t.Parallel()
+
+	old := []byte("a\nb\nc\nd\ne\nf\ng\nh\n")
+	updated := []byte("a\nb\nc\nD\ne\nf\ng\nh\ni\n")
+	want := "--- old\n+++ new\n@@ -1,8 +1,9 @@\n a\n b\n c\n-d\n+D\n e\n f\n g\n h\n+i\n"
+	if got := shared.Diff("old", "new", old, updated); got != want {
+		t.Fatalf("unexpected diff:\n%s", got)
+	}
+	if got := shared.Diff("old", "new", old, old); got != "" {
+		t.Fatalf("expected no diff for equal content, got %q", got)
+	}
+}
+
+func TestInstallFeaturesRollsBackWhenTidyFails(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if _, err := InstallFeature(root, "gin", "config", nil); err != nil {
+		t.Fatalf("install config: %v", err)
+	}
+	goMod := []byte("module example.com/app\n\ngo 1.22\n")
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), goMod, 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+	stateBefore, err := os.ReadFile(filepath.Join(root, statePath))
+	if err != nil {
+		t.Fatalf("read state: %v", err)
+	}
+
+	// The runner changes the module files before failing, as a tidy that
+	// resolved some requirements would.
+	runner := func(dir string, name string, args ...string) error {
+		if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module broken\n"), 0o644); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, "go.sum"), []byte("broken h1:\n"), 0o644); err != nil {
+			return err
+		}
+		return errors.New("network unreachable")
+	}
+	_, err = InstallFeatures(root, "gin", []FeatureRequest{{Name: "postgres"}}, runner)
+	if err == nil || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("expected rolled back install error, got %v", err)
+	}
+
+	for _, path := range []string{"internal/storage", "db"} {
+		if _, err := os.Stat(filepath.Join(root, path)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed, err=%v", path, err)
+		}
+	}
+	if content, _ := os.ReadFile(filepath.Join(root, "go.mod")); string(content) != string(goMod) {
+		t.Fatalf("expected go.mod to be restored, got %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(root, "go.sum")); !os.IsNotExist(err) {
+		t.Fatalf("expected go.sum to be removed, err=%v", err)
+	}
+	if stateAfter, _ := os.ReadFile(filepath.Join(root, statePath)); string(stateAfter) != string(stateBefore) {
+		t.Fatalf("expected feature state to be restored, got %s", stateAfter)
+	}
+}
@@ -13,13 +13,29 @@ func New() Feature { return Feature{} }
 // Name returns the registry name of the feature.
 func (Feature) Name() string { return "logger" }
 
+// Description returns a one-line summary of the feature.
+func (Feature) Description() string {
+	return "Structured JSON logging with log/slog"
+}
+
+// Version returns the version of the feature's installer. It changes
+// whenever the files the feature writes change.
+func (Feature) Version() string { return "1.1.0" }
+
+// EnvVars returns the environment variables the feature reads.
+func (Feature) EnvVars() []shared.EnvVar {
+	return []shared.EnvVar{
+		{Name: "LOG_LEVEL", Default: "info", Description: "Minimum log level: debug, info, warn or error"},
+	}
+}
+
 // Compatible reports whether the feature supports a given framework.
 func (Feature) Compatible(framework string) bool {
 	return shared.IsFeatureCompatible("logger", framework)
 }
 
-// Install writes the feature's scaffold files into projectRoot.
-func (Feature) Install(projectRoot string) error {
+// Install writes the feature's scaffold files into target.
+func (Feature) Install(target *shared.Target) error {
 	const file = `package logger
 
 import (
@@ -45,5 +61,11 @@ func New(level string) *slog.Logger {
 	return slog.New(handler)
 }
 `
-	return shared.WriteFileIfMissing(projectRoot, "internal/logger/logger.go", []byte(file))
+	return target.WriteGoFile(shared.RoleLogger, "logger.go", file)
+}
+
+// Usage returns a snippet showing how to use the feature.
+func (Feature) Usage(target *shared.Target) string {
+	return target.Qualify(shared.RoleLogger, `slog.SetDefault(logger.New(os.Getenv("LOG_LEVEL")))
+slog.Info("server starting", "port", port)`)
 }
@@ -0,0 +1,3 @@
+// Package manifest loads features defined declaratively: a directory holding
+// a feature.json manifest and the templates of the files the feature writes.
+package manifest
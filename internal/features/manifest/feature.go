@@ -0,0 +1,168 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// Feature is a feature defined by a manifest. It implements the features
+// package's Feature interface and its optional interfaces.
+type Feature struct {
+	manifest  Manifest
+	options   []shared.Option
+	templates map[string]*template.Template
+}
+
+// TemplateData is what templates are rendered with.
+type TemplateData struct {
+	Framework string
+	// Module is the project's module path, empty when unknown.
+	Module  string
+	Options shared.Options
+	target  *shared.Target
+}
+
+// Package returns the package name of role's directory, for example
+// {{.Package "server"}}.
+func (d TemplateData) Package(role string) string { return d.target.Package(shared.Role(role)) }
+
+// Import returns the import path of role's package.
+func (d TemplateData) Import(role string) string { return d.target.Import(shared.Role(role)) }
+
+// Path returns the project-relative path of name in role's directory.
+func (d TemplateData) Path(role, name string) string { return d.target.Path(shared.Role(role), name) }
+
+// Name returns the registry name of the feature.
+func (f *Feature) Name() string { return f.manifest.Name }
+
+// Description returns a one-line summary of the feature.
+func (f *Feature) Description() string { return f.manifest.Description }
+
+// Version returns the version declared in the manifest.
+func (f *Feature) Version() string { return f.manifest.Version }
+
+// Compatible reports whether the manifest lists framework.
+func (f *Feature) Compatible(framework string) bool {
+	return slices.Contains(f.manifest.Frameworks, strings.ToLower(strings.TrimSpace(framework)))
+}
+
+// Requires returns the features this feature builds on.
+func (f *Feature) Requires() []string { return f.manifest.Requires }
+
+// EnvVars returns the environment variables the feature reads.
+func (f *Feature) EnvVars() []shared.EnvVar { return f.manifest.Env }
+
+// Options returns the settings the feature accepts at install time.
+func (f *Feature) Options() []shared.Option { return f.options }
+
+// Install renders the manifest's files for the target's framework and writes
+// them into target. Go files with a role get the package clause of the role's
+// directory.
+func (f *Feature) Install(target *shared.Target) error {
+	data := templateData(target)
+	for _, file := range f.manifest.Files {
+		if len(file.Frameworks) > 0 && !slices.Contains(file.Frameworks, target.Framework) {
+			continue
+		}
+		name := file.Template
+		if variant, ok := file.Templates[target.Framework]; ok {
+			name = variant
+		}
+		if name == "" {
+			continue
+		}
+		var content bytes.Buffer
+		if err := f.templates[name].Execute(&content, data); err != nil {
+			return fmt.Errorf("rendering %s: %w", file.Path, err)
+		}
+
+		switch {
+		case file.Role != "" && path.Ext(file.Path) == ".go":
+			if err := target.WriteGoFile(shared.Role(file.Role), file.Path, content.String()); err != nil {
+				return err
+			}
+		case file.Role != "":
+			if err := target.WriteFile(target.Path(shared.Role(file.Role), file.Path), content.Bytes()); err != nil {
+				return err
+			}
+		default:
+			if err := target.WriteFile(file.Path, content.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Wiring returns how the feature registers itself for the target's
+// framework, when the manifest declares wiring for it.
+func (f *Feature) Wiring(target *shared.Target) (shared.Wiring, bool) {
+	spec := f.manifest.Wiring
+	if spec == nil {
+		return shared.Wiring{}, false
+	}
+	data := templateData(target)
+	var wiring shared.Wiring
+	if middleware, ok := spec.Middleware.For(target.Framework); ok {
+		wiring.Middleware = render(middleware, data)
+	}
+	if spec.GracefulShutdown {
+		wiring.GracefulShutdown = true
+		wiring.Shutdown = render(spec.Shutdown, data)
+	}
+	if wiring.Middleware != "" || wiring.GracefulShutdown {
+		for _, value := range importsFor(spec.Imports, target.Framework) {
+			wiring.Imports = append(wiring.Imports, render(value, data))
+		}
+	}
+	if spec.Route != nil {
+		if handler, ok := spec.Route.Handler.For(target.Framework); ok {
+			route := &shared.Route{Path: spec.Route.Path, Handler: render(handler, data)}
+			for _, value := range importsFor(spec.Route.Imports, target.Framework) {
+				route.Imports = append(route.Imports, render(value, data))
+			}
+			wiring.Route = route
+		}
+	}
+	if wiring.Middleware == "" && !wiring.GracefulShutdown && wiring.Route == nil {
+		return shared.Wiring{}, false
+	}
+	return wiring, true
+}
+
+// Usage returns the manifest's usage snippet for the target's framework.
+func (f *Feature) Usage(target *shared.Target) string {
+	snippet, ok := f.manifest.Usage.For(target.Framework)
+	if !ok {
+		return ""
+	}
+	return render(snippet, templateData(target))
+}
+
+func templateData(target *shared.Target) TemplateData {
+	return TemplateData{Framework: target.Framework, Module: target.Module, Options: target.Options, target: target}
+}
+
+// render renders a short template such as a wiring expression. Snippets are
+// parsed when the manifest is loaded; one that fails to execute is returned
+// as written so the problem is visible in the output.
+func render(text string, data TemplateData) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	tmpl, err := parse("snippet", text)
+	if err != nil {
+		return text
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return text
+	}
+	return out.String()
+}
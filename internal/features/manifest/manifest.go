@@ -0,0 +1,302 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// FileName is the name of the manifest file in a feature directory.
+const FileName = "feature.json"
+
+// defaultVariant is the key of the entry used for frameworks without their
+// own entry in a Variants map.
+const defaultVariant = "default"
+
+// Manifest is the declarative definition of a feature, decoded from
+// feature.json.
+//
+//	{
+//	  "name": "health",
+//	  "version": "1.0.0",
+//	  "description": "/health endpoint reporting service status as JSON",
+//	  "frameworks": ["gin", "echo", "fiber", "nethttp"],
+//	  "files": [{"role": "server", "path": "health.go", "template": "health.go.tmpl"}],
+//	  "usage": {"default": "..."}
+//	}
+//
+// Templates, usage snippets and wiring expressions are text/template
+// templates rendered with TemplateData.
+type Manifest struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+	// Frameworks lists the frameworks the feature supports.
+	Frameworks []string        `json:"frameworks"`
+	Requires   []string        `json:"requires,omitempty"`
+	Env        []shared.EnvVar `json:"env,omitempty"`
+	Options    []OptionSpec    `json:"options,omitempty"`
+	Files      []FileSpec      `json:"files"`
+	Wiring     *WiringSpec     `json:"wiring,omitempty"`
+	Usage      Variants        `json:"usage,omitempty"`
+}
+
+// OptionSpec declares an install option.
+type OptionSpec struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Default     string `json:"default"`
+	Description string `json:"description"`
+	// Validate names a built-in check: positive-int, not-empty or
+	// origin-list.
+	Validate string `json:"validate,omitempty"`
+}
+
+// FileSpec declares a file the feature writes.
+type FileSpec struct {
+	// Path is the file's path inside Role's directory, or relative to the
+	// project root when Role is empty.
+	Path string `json:"path"`
+	Role string `json:"role,omitempty"`
+	// Template is the template file, relative to the feature directory, used
+	// for frameworks without an entry in Templates.
+	Template  string            `json:"template,omitempty"`
+	Templates map[string]string `json:"templates,omitempty"`
+	// Frameworks limits the file to the listed frameworks when set.
+	Frameworks []string `json:"frameworks,omitempty"`
+}
+
+// WiringSpec declares how the feature registers itself in main.go and
+// routes.go. Expressions are templates.
+type WiringSpec struct {
+	Middleware       Variants            `json:"middleware,omitempty"`
+	Imports          map[string][]string `json:"imports,omitempty"`
+	GracefulShutdown bool                `json:"graceful_shutdown,omitempty"`
+	Shutdown         string              `json:"shutdown,omitempty"`
+	Route            *RouteSpec          `json:"route,omitempty"`
+}
+
+// RouteSpec declares a route handler registered by the feature.
+type RouteSpec struct {
+	Path    string              `json:"path"`
+	Handler Variants            `json:"handler"`
+	Imports map[string][]string `json:"imports,omitempty"`
+}
+
+// Variants maps frameworks to values, with "default" used for frameworks
+// without their own entry.
+type Variants map[string]string
+
+// For returns the value for framework, and whether there is one.
+func (v Variants) For(framework string) (string, bool) {
+	if value, ok := v[framework]; ok {
+		return value, true
+	}
+	value, ok := v[defaultVariant]
+	return value, ok
+}
+
+// importsFor returns the imports for framework from a per-framework map.
+func importsFor(imports map[string][]string, framework string) []string {
+	if values, ok := imports[framework]; ok {
+		return values
+	}
+	return imports[defaultVariant]
+}
+
+// validators maps OptionSpec.Validate names to checks.
+var validators = map[string]func(string) error{
+	"positive-int": shared.PositiveInt,
+	"not-empty":    shared.NotEmpty,
+	"origin-list":  shared.OriginList,
+}
+
+// featureName matches valid feature names.
+var featureName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// Load reads the feature defined in dir of fsys.
+func Load(fsys fs.FS, dir string) (*Feature, error) {
+	raw, err := fs.ReadFile(fsys, path.Join(dir, FileName))
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&m); err != nil {
+		return nil, fmt.Errorf("%s: %w", path.Join(dir, FileName), err)
+	}
+	feature, err := compile(fsys, dir, m)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path.Join(dir, FileName), err)
+	}
+	return feature, nil
+}
+
+// LoadAll reads every feature defined in a subdirectory of root in fsys,
+// sorted by name. Subdirectories without a manifest are ignored. Features
+// that fail to load are reported together in the error; the others are
+// still returned.
+func LoadAll(fsys fs.FS, root string) ([]*Feature, error) {
+	entries, err := fs.ReadDir(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+	var loaded []*Feature
+	var errs []error
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := path.Join(root, entry.Name())
+		if _, err := fs.Stat(fsys, path.Join(dir, FileName)); err != nil {
+			continue
+		}
+		feature, err := Load(fsys, dir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		loaded = append(loaded, feature)
+	}
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].Name() < loaded[j].Name() })
+	return loaded, errors.Join(errs...)
+}
+
+// compile validates m and parses its templates.
+func compile(fsys fs.FS, dir string, m Manifest) (*Feature, error) {
+	if !featureName.MatchString(m.Name) {
+		return nil, fmt.Errorf("invalid feature name %q", m.Name)
+	}
+	if m.Version == "" {
+		return nil, errors.New("version is required")
+	}
+	if len(m.Frameworks) == 0 {
+		return nil, errors.New("frameworks must list at least one framework")
+	}
+	if len(m.Files) == 0 {
+		return nil, errors.New("files must list at least one file")
+	}
+
+	feature := &Feature{manifest: m, templates: make(map[string]*template.Template)}
+	for _, spec := range m.Options {
+		option := shared.Option{Name: spec.Name, Type: shared.OptionType(spec.Type), Default: spec.Default, Description: spec.Description}
+		switch option.Type {
+		case shared.OptionString, shared.OptionInt, shared.OptionBool:
+		case "":
+			option.Type = shared.OptionString
+		default:
+			return nil, fmt.Errorf("option %q: unknown type %q", spec.Name, spec.Type)
+		}
+		if spec.Validate != "" {
+			validate, ok := validators[spec.Validate]
+			if !ok {
+				return nil, fmt.Errorf("option %q: unknown validation %q", spec.Name, spec.Validate)
+			}
+			option.Validate = validate
+		}
+		if err := option.Check(option.Default); err != nil {
+			return nil, fmt.Errorf("option %q: default: %w", spec.Name, err)
+		}
+		feature.options = append(feature.options, option)
+	}
+
+	roles := make(map[string]bool)
+	for _, role := range shared.Roles() {
+		roles[string(role)] = true
+	}
+	for _, file := range m.Files {
+		if file.Path == "" || !fs.ValidPath(file.Path) {
+			return nil, fmt.Errorf("file %q: path must be a relative slash-separated path", file.Path)
+		}
+		if file.Role != "" && !roles[file.Role] {
+			return nil, fmt.Errorf("file %q: unknown role %q", file.Path, file.Role)
+		}
+		if file.Template == "" && len(file.Templates) == 0 {
+			return nil, fmt.Errorf("file %q: template is required", file.Path)
+		}
+		names := []string{file.Template}
+		for _, name := range file.Templates {
+			names = append(names, name)
+		}
+		for _, name := range names {
+			if name == "" || feature.templates[name] != nil {
+				continue
+			}
+			if !fs.ValidPath(name) {
+				return nil, fmt.Errorf("file %q: template %q must be inside the feature directory", file.Path, name)
+			}
+			raw, err := fs.ReadFile(fsys, path.Join(dir, name))
+			if err != nil {
+				return nil, fmt.Errorf("file %q: %w", file.Path, err)
+			}
+			tmpl, err := parse(name, string(raw))
+			if err != nil {
+				return nil, err
+			}
+			feature.templates[name] = tmpl
+		}
+	}
+	for _, snippet := range snippets(m) {
+		if _, err := parse("snippet", snippet); err != nil {
+			return nil, err
+		}
+	}
+	return feature, nil
+}
+
+// snippets returns the inline templates of m: usage, wiring expressions and
+// imports.
+func snippets(m Manifest) []string {
+	var out []string
+	for _, value := range m.Usage {
+		out = append(out, value)
+	}
+	if m.Wiring == nil {
+		return out
+	}
+	for _, value := range m.Wiring.Middleware {
+		out = append(out, value)
+	}
+	for _, values := range m.Wiring.Imports {
+		out = append(out, values...)
+	}
+	out = append(out, m.Wiring.Shutdown)
+	if m.Wiring.Route != nil {
+		for _, value := range m.Wiring.Route.Handler {
+			out = append(out, value)
+		}
+		for _, values := range m.Wiring.Route.Imports {
+			out = append(out, values...)
+		}
+	}
+	return out
+}
+
+// funcs are the functions available to templates.
+var funcs = template.FuncMap{
+	"quote": strconv.Quote,
+	"join":  strings.Join,
+	"split": shared.SplitList,
+	"quoteEach": func(values []string) []string {
+		quoted := make([]string, len(values))
+		for i, value := range values {
+			quoted[i] = strconv.Quote(value)
+		}
+		return quoted
+	},
+}
+
+func parse(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+}
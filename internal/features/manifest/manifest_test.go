@@ -0,0 +1,149 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+const metricsManifest = `{
+  "name": "metrics",
+  "version": "1.2.0",
+  "description": "Metrics endpoint",
+  "frameworks": ["gin", "nethttp"],
+  "requires": ["logger"],
+  "env": [{"name": "METRICS_PATH", "default": "/metrics", "description": "Path metrics are served on"}],
+  "options": [{"name": "namespace", "default": "app", "description": "Metric prefix", "validate": "not-empty"}],
+  "files": [
+    {"role": "middleware", "path": "metrics.go", "template": "metrics.go.tmpl"},
+    {"path": "docs/metrics.md", "templates": {"gin": "gin.md.tmpl"}, "template": "default.md.tmpl"}
+  ],
+  "wiring": {
+    "middleware": {"default": "{{.Package \"middleware\"}}.Metrics({{quote .Options.namespace}})"},
+    "imports": {"default": ["{{.Import \"middleware\"}}"]}
+  },
+  "usage": {"default": "{{.Package \"middleware\"}}.Metrics(\"app\")"}
+}`
+
+func metricsFS() fstest.MapFS {
+	return fstest.MapFS{
+		"metrics/feature.json":    {Data: []byte(metricsManifest)},
+		"metrics/metrics.go.tmpl": {Data: []byte("package server\n\nconst namespace = {{quote .Options.namespace}}\n")},
+		"metrics/gin.md.tmpl":     {Data: []byte("gin docs\n")},
+		"metrics/default.md.tmpl": {Data: []byte("{{.Framework}} docs\n")},
+		"notes/README.md":         {Data: []byte("not a feature\n")},
+	}
+}
+
+func TestLoadDeclaresFeature(t *testing.T) {
+	t.Parallel()
+
+	feature, err := Load(metricsFS(), "metrics")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if feature.Name() != "metrics" || feature.Version() != "1.2.0" || feature.Description() != "Metrics endpoint" {
+		t.Fatalf("unexpected metadata: %s %s %q", feature.Name(), feature.Version(), feature.Description())
+	}
+	if !feature.Compatible("gin") || feature.Compatible("fiber") {
+		t.Fatalf("expected compatibility with gin only among gin/fiber")
+	}
+	if got := feature.Requires(); len(got) != 1 || got[0] != "logger" {
+		t.Fatalf("unexpected requires: %v", got)
+	}
+	if got := feature.EnvVars(); len(got) != 1 || got[0].Name != "METRICS_PATH" {
+		t.Fatalf("unexpected env vars: %v", got)
+	}
+	options := feature.Options()
+	if len(options) != 1 || options[0].Type != shared.OptionString {
+		t.Fatalf("unexpected options: %+v", options)
+	}
+	if err := options[0].Check(""); err == nil {
+		t.Fatalf("expected not-empty validation on namespace")
+	}
+}
+
+func TestInstallRendersTemplatesIntoLayout(t *testing.T) {
+	t.Parallel()
+
+	feature, err := Load(metricsFS(), "metrics")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	target := shared.NewDryRunTarget("", "gin")
+	target.Layout = shared.Layout{shared.RoleMiddleware: "internal/http/middleware"}
+	target.Module = "example.com/demo"
+	target.Options = shared.Options{"namespace": "billing"}
+	if err := feature.Install(target); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+
+	files := make(map[string]string)
+	for _, file := range target.Written() {
+		files[file.Path] = file.SHA256
+	}
+	if files["internal/http/middleware/metrics.go"] != shared.HashContent([]byte("package middleware\n\nconst namespace = \"billing\"\n")) {
+		t.Fatalf("metrics.go was not rendered into the middleware package: %v", files)
+	}
+	if files["docs/metrics.md"] != shared.HashContent([]byte("gin docs\n")) {
+		t.Fatalf("expected gin template for docs: %v", files)
+	}
+
+	wiring, ok := feature.Wiring(target)
+	if !ok {
+		t.Fatalf("expected wiring")
+	}
+	if wiring.Middleware != `middleware.Metrics("billing")` {
+		t.Fatalf("unexpected middleware: %q", wiring.Middleware)
+	}
+	if len(wiring.Imports) != 1 || wiring.Imports[0] != "example.com/demo/internal/http/middleware" {
+		t.Fatalf("unexpected imports: %v", wiring.Imports)
+	}
+	if usage := feature.Usage(target); usage != `middleware.Metrics("app")` {
+		t.Fatalf("unexpected usage: %q", usage)
+	}
+}
+
+func TestLoadRejectsInvalidManifests(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		manifest string
+		want     string
+	}{
+		"unknown field":    {`{"name": "x", "version": "1.0.0", "frameworks": ["gin"], "files": [], "extra": true}`, "unknown field"},
+		"bad name":         {`{"name": "Bad Name", "version": "1.0.0", "frameworks": ["gin"], "files": [{"path": "a", "template": "a.tmpl"}]}`, "invalid feature name"},
+		"no version":       {`{"name": "x", "frameworks": ["gin"], "files": [{"path": "a", "template": "a.tmpl"}]}`, "version is required"},
+		"unknown role":     {`{"name": "x", "version": "1.0.0", "frameworks": ["gin"], "files": [{"path": "a", "role": "web", "template": "a.tmpl"}]}`, `unknown role "web"`},
+		"escaping path":    {`{"name": "x", "version": "1.0.0", "frameworks": ["gin"], "files": [{"path": "../a", "template": "a.tmpl"}]}`, "relative slash-separated path"},
+		"missing template": {`{"name": "x", "version": "1.0.0", "frameworks": ["gin"], "files": [{"path": "a", "template": "missing.tmpl"}]}`, "missing.tmpl"},
+		"bad default":      {`{"name": "x", "version": "1.0.0", "frameworks": ["gin"], "options": [{"name": "rps", "type": "int", "default": "fast"}], "files": [{"path": "a", "template": "a.tmpl"}]}`, `option "rps"`},
+		"bad snippet":      {`{"name": "x", "version": "1.0.0", "frameworks": ["gin"], "files": [{"path": "a", "template": "a.tmpl"}], "usage": {"default": "{{.Package"}}`, "snippet"},
+	}
+	for name, tc := range cases {
+		fsys := fstest.MapFS{
+			"x/feature.json": {Data: []byte(tc.manifest)},
+			"x/a.tmpl":       {Data: []byte("a\n")},
+		}
+		_, err := Load(fsys, "x")
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected error containing %q, got %v", name, tc.want, err)
+		}
+	}
+}
+
+func TestLoadAllSkipsDirectoriesWithoutManifest(t *testing.T) {
+	t.Parallel()
+
+	fsys := metricsFS()
+	fsys["broken/feature.json"] = &fstest.MapFile{Data: []byte(`{"name": "broken"}`)}
+	loaded, err := LoadAll(fsys, ".")
+	if err == nil || !strings.Contains(err.Error(), "broken/feature.json") {
+		t.Fatalf("expected error for broken manifest, got %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].Name() != "metrics" {
+		t.Fatalf("expected metrics to still load, got %v", loaded)
+	}
+}
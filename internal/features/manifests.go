@@ -0,0 +1,49 @@
+package features
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/manifest"
+)
+
+// builtin holds the features defined by manifests, one directory each.
+//
+//go:embed builtin
+var builtin embed.FS
+
+func init() {
+	loaded, err := manifest.LoadAll(builtin, "builtin")
+	if err != nil {
+		panic(fmt.Sprintf("loading built-in feature manifests: %v", err))
+	}
+	for _, feature := range loaded {
+		Registry[feature.Name()] = feature
+	}
+}
+
+// LoadUserFeatures registers the features defined by manifests in the
+// subdirectories of dir, typically ~/.lalibela/features, and returns their
+// names. A missing dir is not an error. Features that fail to load or that
+// reuse the name of a registered feature are reported in the error; the
+// others are still registered.
+func LoadUserFeatures(dir string) ([]string, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	loaded, err := manifest.LoadAll(os.DirFS(dir), ".")
+	errs := []error{err}
+	var names []string
+	for _, feature := range loaded {
+		name := feature.Name()
+		if _, exists := Registry[name]; exists {
+			errs = append(errs, fmt.Errorf("feature %q in %s is already registered", name, dir))
+			continue
+		}
+		Registry[name] = feature
+		names = append(names, name)
+	}
+	return names, errors.Join(errs...)
+}
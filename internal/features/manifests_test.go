@@ -0,0 +1,159 @@
+package features
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeUserFeature(t *testing.T, dir, name, manifest string, templates map[string]string) {
+	t.Helper()
+	featureDir := filepath.Join(dir, name)
+	if err := os.MkdirAll(featureDir, 0o755); err != nil {
+		t.Fatalf("mkdir %s: %v", featureDir, err)
+	}
+	if err := os.WriteFile(filepath.Join(featureDir, "feature.json"), []byte(manifest), 0o644); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+	for file, content := range templates {
+		if err := os.WriteFile(filepath.Join(featureDir, file), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", file, err)
+		}
+	}
+}
+
+func TestBuiltinManifestsAreRegistered(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"health", "error-handler", "docker", "swagger"} {
+		feature, ok := Registry[name]
+		if !ok {
+			t.Fatalf("expected built-in manifest feature %q to be registered", name)
+		}
+		if featureVersion(feature) == unversioned {
+			t.Fatalf("expected %q to declare a version", name)
+		}
+	}
+}
+
+func TestBuiltinManifestsOnlyShowUsageForTheirFrameworks(t *testing.T) {
+	t.Parallel()
+
+	entries, err := fs.ReadDir(builtin, "builtin")
+	if err != nil {
+		t.Fatalf("read built-in manifests: %v", err)
+	}
+	for _, entry := range entries {
+		raw, err := fs.ReadFile(builtin, path.Join("builtin", entry.Name(), "feature.json"))
+		if err != nil {
+			t.Fatalf("read %s manifest: %v", entry.Name(), err)
+		}
+		var manifest struct {
+			Frameworks []string          `json:"frameworks"`
+			Usage      map[string]string `json:"usage"`
+		}
+		if err := json.Unmarshal(raw, &manifest); err != nil {
+			t.Fatalf("decode %s manifest: %v", entry.Name(), err)
+		}
+		for framework := range manifest.Usage {
+			if framework != "default" && !slices.Contains(manifest.Frameworks, framework) {
+				t.Fatalf("%s shows usage for %s, which it does not list in frameworks %v", entry.Name(), framework, manifest.Frameworks)
+			}
+		}
+	}
+}
+
+func TestErrorHandlerInstallsOnNetHTTP(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	result, err := InstallFeature(root, "nethttp", "error-handler", nil)
+	if err != nil {
+		t.Fatalf("install error-handler: %v", err)
+	}
+	if !result.Compatible || !result.Installed {
+		t.Fatalf("expected error-handler to install on nethttp, got %+v", result)
+	}
+}
+
+// TestLoadUserFeaturesInstallsDeclaredFeature registers a feature in the
+// shared Registry, so it does not run in parallel.
+func TestLoadUserFeaturesInstallsDeclaredFeature(t *testing.T) {
+	dir := t.TempDir()
+	writeUserFeature(t, dir, "greeting", `{
+  "name": "greeting",
+  "version": "1.0.0",
+  "description": "Greeting helper",
+  "frameworks": ["gin", "echo", "fiber", "nethttp"],
+  "options": [{"name": "message", "default": "hello", "description": "Greeting text", "validate": "not-empty"}],
+  "files": [{"role": "server", "path": "greeting.go", "template": "greeting.go.tmpl"}]
+}`, map[string]string{
+		"greeting.go.tmpl": "package server\n\nconst Greeting = {{quote .Options.message}}\n",
+	})
+
+	names, err := LoadUserFeatures(dir)
+	if err != nil {
+		t.Fatalf("load user features: %v", err)
+	}
+	t.Cleanup(func() { delete(Registry, "greeting") })
+	if len(names) != 1 || names[0] != "greeting" {
+		t.Fatalf("unexpected loaded features: %v", names)
+	}
+
+	root := t.TempDir()
+	result, err := InstallFeatureWithOptions(root, "gin", "greeting", map[string]string{"message": "selam"}, nil)
+	if err != nil {
+		t.Fatalf("install greeting: %v", err)
+	}
+	if !result.Installed {
+		t.Fatalf("expected greeting to be installed")
+	}
+	content, err := os.ReadFile(filepath.Join(root, "internal", "server", "greeting.go"))
+	if err != nil {
+		t.Fatalf("read greeting.go: %v", err)
+	}
+	if !strings.Contains(string(content), `const Greeting = "selam"`) {
+		t.Fatalf("expected option rendered into greeting.go, got:\n%s", content)
+	}
+	state, err := loadState(root)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if record := state.Features["greeting"]; record.Version != "1.0.0" || record.Options["message"] != "selam" {
+		t.Fatalf("unexpected record: %+v", record)
+	}
+}
+
+func TestLoadUserFeaturesRejectsRegisteredNames(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeUserFeature(t, dir, "health", `{
+  "name": "health",
+  "version": "9.0.0",
+  "frameworks": ["gin"],
+  "files": [{"path": "health.txt", "template": "health.tmpl"}]
+}`, map[string]string{"health.tmpl": "custom\n"})
+
+	names, err := LoadUserFeatures(dir)
+	if err == nil || !strings.Contains(err.Error(), `"health"`) {
+		t.Fatalf("expected name clash error, got %v", err)
+	}
+	if len(names) != 0 || featureVersion(Registry["health"]) == "9.0.0" {
+		t.Fatalf("built-in health feature must not be replaced")
+	}
+}
+
+func TestLoadUserFeaturesIgnoresMissingDirectory(t *testing.T) {
+	t.Parallel()
+
+	names, err := LoadUserFeatures(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || len(names) != 0 {
+		t.Fatalf("expected nothing loaded without error, got %v, %v", names, err)
+	}
+}
@@ -0,0 +1,2 @@
+// Package oidc provides the "oidc" scaffold feature installer.
+package oidc
@@ -0,0 +1,67 @@
+package oidc
+
+import (
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// Feature installs the "oidc" scaffold feature.
+type Feature struct{}
+
+// New returns a new "oidc" feature installer.
+func New() Feature { return Feature{} }
+
+// Name returns the registry name of the feature.
+func (Feature) Name() string { return "oidc" }
+
+// Description returns a one-line summary of the feature.
+func (Feature) Description() string {
+	return "OpenID Connect login (authorization code + PKCE) with ID token verification and session cookies"
+}
+
+// Version returns the version of the feature's installer. It changes
+// whenever the files the feature writes change.
+func (Feature) Version() string { return "1.0.0" }
+
+// Requires returns the features oidc builds on: ID tokens are verified with
+// the auth feature's key sets and algorithm restrictions.
+func (Feature) Requires() []string { return []string{"auth"} }
+
+// EnvVars returns the environment variables the feature reads.
+func (Feature) EnvVars() []shared.EnvVar {
+	return []shared.EnvVar{
+		{Name: "OIDC_ISSUER_URL", Description: "OpenID provider issuer URL, used for discovery"},
+		{Name: "OIDC_CLIENT_ID", Description: "OAuth client ID registered with the provider"},
+		{Name: "OIDC_CLIENT_SECRET", Description: "OAuth client secret, empty for public clients", Secret: true},
+		{Name: "OIDC_REDIRECT_URL", Default: "http://localhost:8080/oidc/callback", Description: "Callback URL registered with the provider"},
+		{Name: "OIDC_SCOPES", Default: "openid profile email", Description: "Space-separated scopes requested at login"},
+		{Name: "OIDC_COOKIE_SECRET", Description: "Secret encrypting the login and session cookies (at least 32 bytes)", Secret: true, Generate: true},
+	}
+}
+
+// Compatible reports whether the feature supports a given framework.
+func (Feature) Compatible(framework string) bool {
+	return shared.IsFeatureCompatible("oidc", framework)
+}
+
+// Install writes the feature's scaffold files into target, using the route
+// and middleware variant for the target's framework.
+func (Feature) Install(target *shared.Target) error {
+	files := []struct{ name, source string }{
+		{"oidc.go", oidcSource},
+		{"oidc_test.go", oidcTestSource},
+		{"oidc_routes.go", shared.Variant(routeSources, target.Framework)},
+		{"oidc_routes_test.go", shared.Variant(testHarnesses, target.Framework) + testCases},
+	}
+	for _, file := range files {
+		if err := target.WriteGoFile(shared.RoleMiddleware, file.name, file.source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Usage returns a snippet showing how to register the login routes and
+// protect pages on the target's framework.
+func (Feature) Usage(target *shared.Target) string {
+	return target.Qualify(shared.RoleMiddleware, shared.Variant(usage, target.Framework))
+}
@@ -0,0 +1,36 @@
+package oidc
+
+import (
+	"embed"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// templateFS holds the files the feature renders and the snippets it shows,
+// one file per template and one directory per set of framework variants.
+//
+//go:embed templates
+var templateFS embed.FS
+
+// oidcSource holds the framework-independent login flow. Its handlers are
+// plain net/http handlers that each framework's routes adapt.
+var oidcSource = shared.MustReadTemplate(templateFS, "templates/oidc.go.tmpl")
+
+// oidcTestSource holds the in-process mock provider shared by the tests, and
+// the ID token verification tests.
+var oidcTestSource = shared.MustReadTemplate(templateFS, "templates/oidc_test.go.tmpl")
+
+// routeSources holds the login routes and session middleware for each
+// framework. The login handlers are net/http handlers, registered through
+// each framework's adapter.
+var routeSources = shared.MustReadVariants(templateFS, "templates/route")
+
+// testCases is shared by every framework's test: it walks a browser through
+// the whole login against the mock provider.
+var testCases = shared.MustReadTemplate(templateFS, "templates/test_cases.go.tmpl")
+
+// testHarnesses runs testCases through each framework's router.
+var testHarnesses = shared.MustReadVariants(templateFS, "templates/test_harnesses")
+
+// usage shows how to register the login routes and protect pages.
+var usage = shared.MustReadVariants(templateFS, "templates/usage")
@@ -0,0 +1,5 @@
+// Package plugin runs features implemented by external programs. A plugin for
+// feature foo is an executable named lalibela-feature-foo on PATH that answers
+// one JSON request on stdin with one JSON response on stdout. Serve implements
+// the plugin side of the protocol.
+package plugin
@@ -0,0 +1,270 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// ErrNotFound is returned by Find when no plugin executable is on PATH.
+var ErrNotFound = errors.New("plugin not found")
+
+// featureName matches names a plugin can be looked up by.
+var featureName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// Feature is a feature implemented by a plugin. It implements the features
+// package's Feature interface and its optional interfaces.
+type Feature struct {
+	path        string
+	description Description
+	options     []shared.Option
+	compatible  map[string]bool
+
+	// planned is the plan of the last target Install ran for, reused by
+	// Wiring so the plugin is not asked to plan twice.
+	planned *plannedTarget
+}
+
+// plannedTarget is the plan a plugin returned for target.
+type plannedTarget struct {
+	target *shared.Target
+	plan   Plan
+}
+
+// Find looks up the plugin for feature name on PATH and loads it.
+func Find(name string) (*Feature, error) {
+	if !featureName.MatchString(name) {
+		return nil, ErrNotFound
+	}
+	path, err := exec.LookPath(Prefix + name)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	feature, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if feature.Name() != name {
+		return nil, fmt.Errorf("plugin %s describes itself as %q", path, feature.Name())
+	}
+	return feature, nil
+}
+
+// Load runs the plugin executable at path to describe its feature.
+func Load(path string) (*Feature, error) {
+	feature := &Feature{path: path, compatible: make(map[string]bool)}
+	if err := feature.call(Request{Protocol: ProtocolVersion, Command: CommandDescribe}, &feature.description); err != nil {
+		return nil, err
+	}
+	description := feature.description
+	if !featureName.MatchString(description.Name) {
+		return nil, fmt.Errorf("plugin %s: invalid feature name %q", path, description.Name)
+	}
+	if description.Version == "" {
+		return nil, fmt.Errorf("plugin %s: version is required", path)
+	}
+	for _, spec := range description.Options {
+		option := shared.Option{Name: spec.Name, Type: shared.OptionType(spec.Type), Default: spec.Default, Description: spec.Description}
+		switch option.Type {
+		case shared.OptionString, shared.OptionInt, shared.OptionBool:
+		case "":
+			option.Type = shared.OptionString
+		default:
+			return nil, fmt.Errorf("plugin %s: option %q: unknown type %q", path, spec.Name, spec.Type)
+		}
+		feature.options = append(feature.options, option)
+	}
+	return feature, nil
+}
+
+// Path returns the plugin executable's path.
+func (f *Feature) Path() string { return f.path }
+
+// Name returns the registry name of the feature.
+func (f *Feature) Name() string { return f.description.Name }
+
+// Description returns a one-line summary of the feature.
+func (f *Feature) Description() string { return f.description.Description }
+
+// Version returns the version the plugin describes.
+func (f *Feature) Version() string { return f.description.Version }
+
+// Requires returns the features this feature builds on.
+func (f *Feature) Requires() []string { return f.description.Requires }
+
+// EnvVars returns the environment variables the feature reads.
+func (f *Feature) EnvVars() []shared.EnvVar { return f.description.Env }
+
+// Options returns the settings the feature accepts at install time.
+func (f *Feature) Options() []shared.Option { return f.options }
+
+// Compatible asks the plugin whether it supports framework. A plugin that
+// fails to answer supports nothing.
+func (f *Feature) Compatible(framework string) bool {
+	framework = strings.ToLower(strings.TrimSpace(framework))
+	if compatible, ok := f.compatible[framework]; ok {
+		return compatible
+	}
+	var result Compatibility
+	err := f.call(Request{Protocol: ProtocolVersion, Command: CommandCompatible, Framework: framework}, &result)
+	f.compatible[framework] = err == nil && result.Compatible
+	return f.compatible[framework]
+}
+
+// Install writes the files the plugin plans into target. Outside of a dry run
+// it then declares the files the plugin edits and runs the plugin's install,
+// recording the manual steps it reports.
+func (f *Feature) Install(target *shared.Target) error {
+	plan, err := f.plan(target)
+	if err != nil {
+		return err
+	}
+	f.planned = &plannedTarget{target: target, plan: plan}
+	for _, file := range plan.Files {
+		if !fs.ValidPath(file.Path) {
+			return fmt.Errorf("plugin %s: file %q must be a relative slash-separated path", f.path, file.Path)
+		}
+		if err := target.WriteFile(file.Path, []byte(file.Content)); err != nil {
+			return err
+		}
+	}
+	if target.DryRun() {
+		return nil
+	}
+
+	for _, edit := range plan.Edits {
+		if !fs.ValidPath(edit) {
+			return fmt.Errorf("plugin %s: edit %q must be a relative slash-separated path", f.path, edit)
+		}
+		if err := target.Edit(edit); err != nil {
+			return err
+		}
+	}
+	var installed Installed
+	if err := f.call(f.request(CommandInstall, target), &installed); err != nil {
+		return err
+	}
+	for _, step := range installed.ManualSteps {
+		target.AddManualStep(step)
+	}
+	return nil
+}
+
+// Wiring returns the wiring the plugin planned for the target, reusing the
+// plan of Install when it ran for target. When the plugin fails to plan, the
+// failure is recorded as a manual step and no wiring is returned.
+func (f *Feature) Wiring(target *shared.Target) (shared.Wiring, bool) {
+	plan, err := f.planFor(target)
+	if err != nil {
+		target.AddManualStep(fmt.Sprintf("Automatic wiring skipped: %v.", err))
+		return shared.Wiring{}, false
+	}
+	if plan.Wiring == nil {
+		return shared.Wiring{}, false
+	}
+	spec := plan.Wiring
+	wiring := shared.Wiring{
+		Middleware:       spec.Middleware,
+		GracefulShutdown: spec.GracefulShutdown,
+		Shutdown:         spec.Shutdown,
+		Imports:          spec.Imports,
+	}
+	if spec.Route != nil {
+		wiring.Route = &shared.Route{Path: spec.Route.Path, Handler: spec.Route.Handler, Imports: spec.Route.Imports}
+	}
+	if wiring.Middleware == "" && !wiring.GracefulShutdown && wiring.Route == nil {
+		return shared.Wiring{}, false
+	}
+	return wiring, true
+}
+
+// Usage returns the plugin's usage snippet for the target's framework.
+func (f *Feature) Usage(target *shared.Target) string {
+	if usage, ok := f.description.Usage[target.Framework]; ok {
+		return usage
+	}
+	return f.description.Usage["default"]
+}
+
+// planFor returns the plan Install got for target, or asks the plugin for one
+// when Install did not run for it.
+func (f *Feature) planFor(target *shared.Target) (Plan, error) {
+	if f.planned != nil && f.planned.target == target {
+		return f.planned.plan, nil
+	}
+	return f.plan(target)
+}
+
+func (f *Feature) plan(target *shared.Target) (Plan, error) {
+	var plan Plan
+	err := f.call(f.request(CommandPlan, target), &plan)
+	return plan, err
+}
+
+// request returns a request for command describing target.
+func (f *Feature) request(command string, target *shared.Target) Request {
+	request := Request{
+		Protocol:  ProtocolVersion,
+		Command:   command,
+		Framework: target.Framework,
+		Module:    target.Module,
+		Layout:    make(map[string]string),
+		Options:   target.Options,
+	}
+	if target.Root != "" {
+		if root, err := filepath.Abs(target.Root); err == nil {
+			request.ProjectRoot = root
+		}
+	}
+	for _, role := range shared.Roles() {
+		request.Layout[string(role)] = target.Layout.Dir(role)
+	}
+	return request
+}
+
+// call sends request to the plugin and decodes the response's result into
+// result.
+func (f *Feature) call(request Request, result any) error {
+	input, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(f.path)
+	cmd.Dir = request.ProjectRoot
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if detail := strings.TrimSpace(stderr.String()); detail != "" {
+			return fmt.Errorf("plugin %s %s: %w: %s", f.path, request.Command, err, detail)
+		}
+		return fmt.Errorf("plugin %s %s: %w", f.path, request.Command, err)
+	}
+
+	var response Response
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return fmt.Errorf("plugin %s %s: decoding response: %w", f.path, request.Command, err)
+	}
+	if response.Protocol != ProtocolVersion {
+		return fmt.Errorf("plugin %s speaks protocol version %d, lalibela speaks %d", f.path, response.Protocol, ProtocolVersion)
+	}
+	if response.Error != "" {
+		return fmt.Errorf("plugin %s %s: %s", f.path, request.Command, response.Error)
+	}
+	if len(response.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("plugin %s %s: decoding result: %w", f.path, request.Command, err)
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// servePluginEnv makes the test binary act as a plugin when set, so tests can
+// run it as one.
+const servePluginEnv = "LALIBELA_TEST_SERVE_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(servePluginEnv) != "" {
+		if err := Serve(os.Stdin, os.Stdout, greetingPlugin{}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Setenv(servePluginEnv, "1")
+	os.Exit(m.Run())
+}
+
+// greetingPlugin writes a greeting file and appends a comment to main.go.
+type greetingPlugin struct{}
+
+func (greetingPlugin) Describe() (Description, error) {
+	return Description{
+		Name:        "greeting",
+		Version:     "1.1.0",
+		Description: "Greeting helper",
+		Options:     []Option{{Name: "message", Default: "hello", Description: "Greeting text"}},
+		Usage:       map[string]string{"default": "server.Greeting"},
+	}, nil
+}
+
+func (greetingPlugin) Compatible(framework string) (bool, error) {
+	return framework == "gin" || framework == "nethttp", nil
+}
+
+func (greetingPlugin) Plan(request Request) (Plan, error) {
+	if request.Options["message"] == "" {
+		return Plan{}, errors.New("message must not be empty")
+	}
+	return Plan{
+		Files: []File{{
+			Path:    request.Layout["server"] + "/greeting.go",
+			Content: fmt.Sprintf("package server\n\nconst Greeting = %q\n", request.Options["message"]),
+		}},
+		Edits: []string{"main.go"},
+		Wiring: &Wiring{Route: &Route{
+			Path:    "/greet",
+			Handler: "greetHandler",
+			Imports: []string{request.Module + "/" + request.Layout["server"]},
+		}},
+	}, nil
+}
+
+func (greetingPlugin) Install(request Request) (Installed, error) {
+	path := filepath.Join(request.ProjectRoot, "main.go")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Installed{}, err
+	}
+	if err := os.WriteFile(path, append(content, "// greeting installed\n"...), 0o644); err != nil {
+		return Installed{}, err
+	}
+	return Installed{ManualSteps: []string{"Call server.Greeting from a handler."}}, nil
+}
+
+func loadGreeting(t *testing.T) *Feature {
+	t.Helper()
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatalf("locate test binary: %v", err)
+	}
+	feature, err := Load(executable)
+	if err != nil {
+		t.Fatalf("load plugin: %v", err)
+	}
+	return feature
+}
+
+func TestLoadDescribesPlugin(t *testing.T) {
+	t.Parallel()
+
+	feature := loadGreeting(t)
+	if feature.Name() != "greeting" || feature.Version() != "1.1.0" || feature.Description() != "Greeting helper" {
+		t.Fatalf("unexpected description: %s %s %q", feature.Name(), feature.Version(), feature.Description())
+	}
+	if options := feature.Options(); len(options) != 1 || options[0].Type != shared.OptionString {
+		t.Fatalf("unexpected options: %+v", options)
+	}
+	if !feature.Compatible("gin") || feature.Compatible("fiber") {
+		t.Fatalf("expected the plugin to support gin but not fiber")
+	}
+}
+
+func TestInstallWritesPlannedFilesAndRunsPluginInstall(t *testing.T) {
+	t.Parallel()
+
+	feature := loadGreeting(t)
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	target := shared.NewTarget(root, "gin")
+	target.Module = "example.com/demo"
+	target.Options = shared.Options{"message": "selam"}
+	if err := feature.Install(target); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, "internal", "server", "greeting.go"))
+	if err != nil || !strings.Contains(string(content), `"selam"`) {
+		t.Fatalf("expected greeting.go with the option rendered, got %q (%v)", content, err)
+	}
+	if written := target.Written(); len(written) != 1 || written[0].Path != "internal/server/greeting.go" {
+		t.Fatalf("unexpected written files: %v", written)
+	}
+	if edited := target.Edited(); len(edited) != 1 || edited[0] != "main.go" {
+		t.Fatalf("unexpected edited files: %v", edited)
+	}
+	if steps := target.ManualSteps(); len(steps) != 1 {
+		t.Fatalf("expected the plugin's manual step, got %v", steps)
+	}
+
+	wiring, ok := feature.Wiring(target)
+	if !ok || wiring.Route == nil || wiring.Route.Path != "/greet" {
+		t.Fatalf("unexpected wiring: %+v", wiring)
+	}
+	if wiring.Route.Imports[0] != "example.com/demo/internal/server" {
+		t.Fatalf("expected the layout and module in the request, got %v", wiring.Route.Imports)
+	}
+
+	if err := target.Rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	main, _ := os.ReadFile(filepath.Join(root, "main.go"))
+	if string(main) != "package main\n" {
+		t.Fatalf("expected the plugin's edit to be rolled back, got %q", main)
+	}
+}
+
+func TestDryRunOnlyPlans(t *testing.T) {
+	t.Parallel()
+
+	feature := loadGreeting(t)
+	target := shared.NewDryRunTarget("", "gin")
+	target.Options = shared.Options{"message": "hi"}
+	if err := feature.Install(target); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if len(target.Written()) != 1 || len(target.Edited()) != 0 || len(target.ManualSteps()) != 0 {
+		t.Fatalf("expected only the planned file in a dry run")
+	}
+
+	target = shared.NewDryRunTarget("", "gin")
+	if err := feature.Install(target); err == nil || !strings.Contains(err.Error(), "message must not be empty") {
+		t.Fatalf("expected the plugin's plan error, got %v", err)
+	}
+}
+
+func TestWiringReusesInstallPlanAndReportsPlanErrors(t *testing.T) {
+	t.Parallel()
+
+	feature := loadGreeting(t)
+	target := shared.NewDryRunTarget("", "gin")
+	target.Options = shared.Options{"message": "hi"}
+	if err := feature.Install(target); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	// Planning again would fail without a message, so wiring must come from
+	// the plan Install got.
+	target.Options = shared.Options{}
+	if wiring, ok := feature.Wiring(target); !ok || wiring.Route == nil {
+		t.Fatalf("expected the wiring of the install plan, got %+v", wiring)
+	}
+
+	target = shared.NewDryRunTarget("", "gin")
+	if _, ok := feature.Wiring(target); ok {
+		t.Fatalf("expected no wiring when the plugin fails to plan")
+	}
+	if steps := target.ManualSteps(); len(steps) != 1 || !strings.Contains(steps[0], "message must not be empty") {
+		t.Fatalf("expected the plan error as a manual step, got %v", steps)
+	}
+}
+
+func TestServeRejectsOtherProtocolVersions(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	request := `{"protocol": 2, "command": "describe"}`
+	if err := Serve(strings.NewReader(request), &out, greetingPlugin{}); err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	var response Response
+	if err := json.Unmarshal(out.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if response.Protocol != ProtocolVersion || !strings.Contains(response.Error, "unsupported protocol version 2") {
+		t.Fatalf("unexpected response: %+v", response)
+	}
+}
+
+func TestFindReportsMissingPlugins(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"no-such-feature-for-tests", "../escape"} {
+		if _, err := Find(name); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Find(%q): expected ErrNotFound, got %v", name, err)
+		}
+	}
+}
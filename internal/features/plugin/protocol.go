@@ -0,0 +1,166 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// ProtocolVersion is the version of the protocol spoken with plugins. Requests
+// and responses carry it, and a plugin answering with another version is
+// rejected.
+const ProtocolVersion = 1
+
+// Prefix is the prefix of plugin executable names.
+const Prefix = "lalibela-feature-"
+
+// Commands sent to plugins.
+const (
+	// CommandDescribe asks for the plugin's Description.
+	CommandDescribe = "describe"
+	// CommandCompatible asks whether the plugin supports Request.Framework.
+	CommandCompatible = "compatible"
+	// CommandPlan asks for the Plan of an install without changing anything.
+	CommandPlan = "plan"
+	// CommandInstall asks the plugin to make the changes it planned to the
+	// project at Request.ProjectRoot, after lalibela wrote the planned files.
+	CommandInstall = "install"
+)
+
+// Request is sent to a plugin on stdin.
+type Request struct {
+	Protocol  int    `json:"protocol"`
+	Command   string `json:"command"`
+	Framework string `json:"framework,omitempty"`
+	// ProjectRoot is the absolute path of the project. It is empty when a
+	// plan is made without a project, for example by `lalibela explain`.
+	ProjectRoot string `json:"project_root,omitempty"`
+	// Module is the project's module path, empty when unknown.
+	Module string `json:"module,omitempty"`
+	// Layout maps roles to the project's directories, such as
+	// {"server": "internal/server"}.
+	Layout map[string]string `json:"layout,omitempty"`
+	// Options holds the resolved install options.
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// Response is written by a plugin on stdout. Result holds the command's
+// result: Description, Compatibility, Plan or Installed.
+type Response struct {
+	Protocol int             `json:"protocol"`
+	Error    string          `json:"error,omitempty"`
+	Result   json.RawMessage `json:"result,omitempty"`
+}
+
+// Description is the result of CommandDescribe.
+type Description struct {
+	Name        string          `json:"name"`
+	Version     string          `json:"version"`
+	Description string          `json:"description"`
+	Requires    []string        `json:"requires,omitempty"`
+	Env         []shared.EnvVar `json:"env,omitempty"`
+	Options     []Option        `json:"options,omitempty"`
+	// Usage maps frameworks to usage snippets, with "default" used for
+	// frameworks without their own entry.
+	Usage map[string]string `json:"usage,omitempty"`
+}
+
+// Option declares an install option. Values are validated by the plugin when
+// it plans the install.
+type Option struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Default     string `json:"default"`
+	Description string `json:"description"`
+}
+
+// Compatibility is the result of CommandCompatible.
+type Compatibility struct {
+	Compatible bool `json:"compatible"`
+}
+
+// Plan is the result of CommandPlan.
+type Plan struct {
+	// Files are written by lalibela, which handles conflicts with existing
+	// files and records the files as owned by the feature.
+	Files []File `json:"files,omitempty"`
+	// Edits lists existing project files the plugin changes during
+	// CommandInstall. They are restored if the install is rolled back.
+	Edits  []string `json:"edits,omitempty"`
+	Wiring *Wiring  `json:"wiring,omitempty"`
+}
+
+// File is a file to write, relative to the project root.
+type File struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// Wiring declares how the feature registers itself in main.go and routes.go.
+type Wiring struct {
+	Middleware       string   `json:"middleware,omitempty"`
+	Imports          []string `json:"imports,omitempty"`
+	GracefulShutdown bool     `json:"graceful_shutdown,omitempty"`
+	Shutdown         string   `json:"shutdown,omitempty"`
+	Route            *Route   `json:"route,omitempty"`
+}
+
+// Route is a route handler registered by the feature.
+type Route struct {
+	Path    string   `json:"path"`
+	Handler string   `json:"handler"`
+	Imports []string `json:"imports,omitempty"`
+}
+
+// Installed is the result of CommandInstall.
+type Installed struct {
+	// ManualSteps describes what the user has to finish by hand.
+	ManualSteps []string `json:"manual_steps,omitempty"`
+}
+
+// Handler implements a plugin's commands for Serve.
+type Handler interface {
+	Describe() (Description, error)
+	Compatible(framework string) (bool, error)
+	Plan(request Request) (Plan, error)
+	Install(request Request) (Installed, error)
+}
+
+// Serve reads one request from in, runs it on handler and writes the response
+// to out. Errors from handler are sent to lalibela in the response; the
+// returned error only reports a request or response that could not be
+// exchanged.
+func Serve(in io.Reader, out io.Writer, handler Handler) error {
+	var request Request
+	if err := json.NewDecoder(in).Decode(&request); err != nil {
+		return fmt.Errorf("decoding request: %w", err)
+	}
+
+	response := Response{Protocol: ProtocolVersion}
+	var result any
+	var err error
+	switch {
+	case request.Protocol != ProtocolVersion:
+		err = fmt.Errorf("unsupported protocol version %d, this plugin speaks %d", request.Protocol, ProtocolVersion)
+	case request.Command == CommandDescribe:
+		result, err = handler.Describe()
+	case request.Command == CommandCompatible:
+		var compatible bool
+		compatible, err = handler.Compatible(request.Framework)
+		result = Compatibility{Compatible: compatible}
+	case request.Command == CommandPlan:
+		result, err = handler.Plan(request)
+	case request.Command == CommandInstall:
+		result, err = handler.Install(request)
+	default:
+		err = fmt.Errorf("unknown command %q", request.Command)
+	}
+	if err != nil {
+		response.Error = err.Error()
+	} else if response.Result, err = json.Marshal(result); err != nil {
+		return fmt.Errorf("encoding result: %w", err)
+	}
+	return json.NewEncoder(out).Encode(response)
+}
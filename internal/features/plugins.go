@@ -0,0 +1,46 @@
+package features
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/plugin"
+)
+
+var (
+	pluginsMu sync.Mutex
+	// plugins caches the plugin features found on PATH, by name.
+	plugins = make(map[string]*plugin.Feature)
+)
+
+// lookupFeature returns the registered feature called name. Names missing
+// from Registry fall back to a plugin executable on PATH, such as
+// lalibela-feature-foo for foo. It returns plugin.ErrNotFound when there is
+// neither, and the plugin's error when it cannot be loaded.
+func lookupFeature(name string) (Feature, error) {
+	if feature, ok := Registry[name]; ok {
+		return feature, nil
+	}
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	if feature, ok := plugins[name]; ok {
+		return feature, nil
+	}
+	feature, err := plugin.Find(name)
+	if err != nil {
+		return nil, err
+	}
+	plugins[name] = feature
+	return feature, nil
+}
+
+// unknownFeature formats a lookup error for a feature requested as
+// requested: the plain "unknown feature" message when nothing was found, the
+// plugin's error otherwise.
+func unknownFeature(requested string, err error) string {
+	if errors.Is(err, plugin.ErrNotFound) {
+		return fmt.Sprintf("unknown feature %q", requested)
+	}
+	return fmt.Sprintf("feature %q: %v", requested, err)
+}
@@ -0,0 +1,206 @@
+package features
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/plugin"
+)
+
+// servePluginEnv makes the test binary act as the stamp plugin when set, so
+// tests can put it on PATH as lalibela-feature-stamp.
+const servePluginEnv = "LALIBELA_TEST_SERVE_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(servePluginEnv) != "" {
+		if err := plugin.Serve(os.Stdin, os.Stdout, stampPlugin{}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Setenv(servePluginEnv, "1")
+	os.Exit(m.Run())
+}
+
+// stampPlugin writes a stamp file and appends a comment to main.go. Its
+// install fails when the fail option is set.
+type stampPlugin struct{}
+
+func (stampPlugin) Describe() (plugin.Description, error) {
+	return plugin.Description{
+		Name:        "stamp",
+		Version:     "2.0.0",
+		Description: "Stamps the project",
+		Requires:    []string{"config"},
+		Options:     []plugin.Option{{Name: "fail", Type: "bool", Default: "false", Description: "Fail the install"}},
+	}, nil
+}
+
+func (stampPlugin) Compatible(framework string) (bool, error) { return true, nil }
+
+func (stampPlugin) Plan(request plugin.Request) (plugin.Plan, error) {
+	return plugin.Plan{
+		Files: []plugin.File{{Path: "STAMP", Content: "stamped for " + request.Framework + "\n"}},
+		Edits: []string{"main.go"},
+	}, nil
+}
+
+func (stampPlugin) Install(request plugin.Request) (plugin.Installed, error) {
+	path := filepath.Join(request.ProjectRoot, "main.go")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return plugin.Installed{}, err
+	}
+	if err := os.WriteFile(path, append(content, "// stamped\n"...), 0o644); err != nil {
+		return plugin.Installed{}, err
+	}
+	if request.Options["fail"] == "true" {
+		return plugin.Installed{}, errors.New("stamp service unavailable")
+	}
+	return plugin.Installed{ManualSteps: []string{"Register the stamp with the service catalog."}}, nil
+}
+
+// installStampPlugin puts the test binary on PATH as lalibela-feature-stamp.
+// It changes the process environment, so callers must not run in parallel.
+func installStampPlugin(t *testing.T) {
+	t.Helper()
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatalf("locate test binary: %v", err)
+	}
+	content, err := os.ReadFile(executable)
+	if err != nil {
+		t.Fatalf("read test binary: %v", err)
+	}
+	dir := t.TempDir()
+	name := plugin.Prefix + "stamp"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), content, 0o755); err != nil {
+		t.Fatalf("write plugin: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Cleanup(func() {
+		pluginsMu.Lock()
+		delete(plugins, "stamp")
+		pluginsMu.Unlock()
+	})
+}
+
+func writeMainGo(t *testing.T, root string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+}
+
+func TestInstallFeaturesFallsBackToPluginOnPath(t *testing.T) {
+	installStampPlugin(t)
+	root := t.TempDir()
+	writeMainGo(t, root)
+
+	results, err := InstallFeatures(root, "gin", []FeatureRequest{{Name: "stamp"}}, nil)
+	if err != nil {
+		t.Fatalf("install stamp: %v", err)
+	}
+	if len(results) != 2 || results[0].Name != "config" || results[1].Name != "stamp" {
+		t.Fatalf("expected config to be installed before stamp, got %+v", results)
+	}
+	stamp := results[1]
+	if !stamp.Installed || len(stamp.Wired) != 1 || stamp.Wired[0] != "main.go" {
+		t.Fatalf("expected stamp installed with main.go edited, got %+v", stamp)
+	}
+	if len(stamp.ManualSteps) != 1 || !strings.Contains(stamp.ManualSteps[0], "service catalog") {
+		t.Fatalf("expected the plugin's manual steps, got %v", stamp.ManualSteps)
+	}
+	if main, _ := os.ReadFile(filepath.Join(root, "main.go")); !strings.Contains(string(main), "// stamped") {
+		t.Fatalf("expected the plugin to edit main.go, got %q", main)
+	}
+
+	state, err := loadState(root)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	record := state.Features["stamp"]
+	if record.Version != "2.0.0" || len(record.Files) != 1 || record.Files[0].Path != "STAMP" {
+		t.Fatalf("unexpected stamp record: %+v", record)
+	}
+	if len(record.Edited) != 1 || record.Edited[0] != "main.go" {
+		t.Fatalf("expected main.go recorded as edited, got %v", record.Edited)
+	}
+
+	removed, err := RemoveFeatures(root, []string{"stamp"}, false, nil)
+	if err != nil {
+		t.Fatalf("remove stamp: %v", err)
+	}
+	if len(removed[0].Deleted) != 1 || len(removed[0].Edited) != 1 {
+		t.Fatalf("expected STAMP deleted and main.go reported as edited, got %+v", removed[0])
+	}
+}
+
+func TestCatalogListsInstalledPlugins(t *testing.T) {
+	installStampPlugin(t)
+	root := t.TempDir()
+	writeMainGo(t, root)
+	if _, err := InstallFeatures(root, "gin", []FeatureRequest{{Name: "stamp"}}, nil); err != nil {
+		t.Fatalf("install stamp: %v", err)
+	}
+
+	entries, err := Catalog(root)
+	if err != nil {
+		t.Fatalf("catalog: %v", err)
+	}
+	stamp := entries[len(entries)-1]
+	if stamp.Name != "stamp" || !stamp.Installed || stamp.Version != "2.0.0" || stamp.Description != "Stamps the project" {
+		t.Fatalf("expected the installed plugin last in the catalog, got %+v", stamp)
+	}
+	if !stamp.Compatibility["gin"] || len(stamp.Requires) != 1 || len(stamp.Files) != 1 || stamp.Files[0] != "STAMP" {
+		t.Fatalf("expected the plugin's description to be resolved, got %+v", stamp)
+	}
+
+	// Without the plugin on PATH it is still listed as installed.
+	t.Setenv("PATH", t.TempDir())
+	pluginsMu.Lock()
+	delete(plugins, "stamp")
+	pluginsMu.Unlock()
+	entries, err = Catalog(root)
+	if err != nil {
+		t.Fatalf("catalog without plugin: %v", err)
+	}
+	if stamp := entries[len(entries)-1]; stamp.Name != "stamp" || !stamp.Installed || stamp.Version != "2.0.0" {
+		t.Fatalf("expected the missing plugin to be listed, got %+v", stamp)
+	}
+}
+
+func TestInstallFeaturesRollsBackPluginEdits(t *testing.T) {
+	installStampPlugin(t)
+	root := t.TempDir()
+	writeMainGo(t, root)
+
+	_, err := InstallFeatures(root, "gin", []FeatureRequest{{Name: "stamp", Options: map[string]string{"fail": "true"}}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "stamp service unavailable") || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("expected the plugin's error and a rollback, got %v", err)
+	}
+	if main, _ := os.ReadFile(filepath.Join(root, "main.go")); string(main) != "package main\n" {
+		t.Fatalf("expected main.go to be restored, got %q", main)
+	}
+	if _, err := os.Stat(filepath.Join(root, "STAMP")); !os.IsNotExist(err) {
+		t.Fatalf("expected STAMP to be removed, err=%v", err)
+	}
+}
+
+func TestInstallFeaturesReportsUnknownFeatureWithoutPlugin(t *testing.T) {
+	t.Parallel()
+
+	_, err := InstallFeatures(t.TempDir(), "gin", []FeatureRequest{{Name: "no-such-feature-for-tests"}}, nil)
+	if err == nil || !strings.Contains(err.Error(), `unknown feature "no-such-feature-for-tests"`) {
+		t.Fatalf("expected unknown feature error, got %v", err)
+	}
+}
@@ -13,33 +13,62 @@ func New() Feature { return Feature{} }
 // Name returns the registry name of the feature.
 func (Feature) Name() string { return "postgres" }
 
+// Description returns a one-line summary of the feature.
+func (Feature) Description() string {
+	return "PostgreSQL connection pool (pgx) and a migrations directory"
+}
+
+// Version returns the version of the feature's installer. It changes
+// whenever the files the feature writes change.
+func (Feature) Version() string { return "1.1.0" }
+
+// EnvVars returns the environment variables the feature reads.
+func (Feature) EnvVars() []shared.EnvVar {
+	return []shared.EnvVar{
+		{Name: "DB_HOST", Default: "localhost", Description: "PostgreSQL host"},
+		{Name: "DB_PORT", Default: "5432", Description: "PostgreSQL port"},
+		{Name: "DB_USER", Default: "postgres", Description: "PostgreSQL user"},
+		{Name: "DB_PASSWORD", Description: "PostgreSQL password", Secret: true},
+		{Name: "DB_NAME", Default: "mydb", Description: "PostgreSQL database name"},
+		{Name: "DB_SSLMODE", Default: "prefer", Description: "PostgreSQL sslmode: disable, allow, prefer, require, verify-ca or verify-full"},
+	}
+}
+
 // Compatible reports whether the feature supports a given framework.
 func (Feature) Compatible(framework string) bool {
 	return shared.IsFeatureCompatible("postgres", framework)
 }
 
-// Install writes the feature's scaffold files into projectRoot.
-func (Feature) Install(projectRoot string) error {
-	const source = `package storage
+// Requires returns the features postgres builds on: its connection
+// settings are a section of the config feature's Config.
+func (Feature) Requires() []string { return []string{"config"} }
 
-import (
-	"context"
-	"time"
+// Install writes the feature's scaffold files and its configuration section
+// into target.
+func (Feature) Install(target *shared.Target) error {
+	if err := target.WriteGoFile(shared.RoleStorage, "postgres.go", poolSource); err != nil {
+		return err
+	}
+	if err := target.WriteGoFile(shared.RoleConfig, "postgres.go", settings); err != nil {
+		return err
+	}
+	return target.WriteFile(target.Path(shared.RoleMigrations, "0001_init.sql"), []byte(migration))
+}
 
-	"github.com/jackc/pgx/v5/pgxpool"
-)
+// Wiring adds the Postgres section to the project's Config.
+func (Feature) Wiring(target *shared.Target) (shared.Wiring, bool) {
+	return shared.Wiring{Field: &shared.Field{
+		File:   target.Path(shared.RoleConfig, "config.go"),
+		Struct: "Config",
+		Decl:   "Postgres PostgresConfig `yaml:\"postgres\" json:\"postgres\" toml:\"postgres\"`",
+	}}, true
+}
 
-func NewPostgresPool(dsn string) (*pgxpool.Pool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	return pgxpool.New(ctx, dsn)
+// Usage returns a snippet showing how to use the feature.
+func (Feature) Usage(target *shared.Target) string {
+	return target.Qualify(shared.RoleStorage, `pool, err := storage.NewPostgresPool(cfg.Postgres.DSN())
+if err != nil {
+	log.Fatal(err)
 }
-`
-	const migration = `-- 0001_init.sql
--- Add project migrations here.
-`
-	if err := shared.WriteFileIfMissing(projectRoot, "internal/storage/postgres.go", []byte(source)); err != nil {
-		return err
-	}
-	return shared.WriteFileIfMissing(projectRoot, "db/migrations/0001_init.sql", []byte(migration))
+defer pool.Close()`)
 }
@@ -0,0 +1,21 @@
+package postgres
+
+import (
+	"embed"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// templateFS holds the files the feature renders.
+//
+//go:embed templates
+var templateFS embed.FS
+
+// poolSource opens the connection pool.
+var poolSource = shared.MustReadTemplate(templateFS, "templates/pool.go.tmpl")
+
+// migration is the first file of the migrations directory.
+var migration = shared.MustReadTemplate(templateFS, "templates/migration.sql.tmpl")
+
+// settings is the feature's section of the project's Config.
+var settings = shared.MustReadTemplate(templateFS, "templates/settings.go.tmpl")
@@ -1,6 +1,9 @@
 package ratelimit
 
 import (
+	"fmt"
+	"strconv"
+
 	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
 )
 
@@ -13,32 +16,79 @@ func New() Feature { return Feature{} }
 // Name returns the registry name of the feature.
 func (Feature) Name() string { return "rate-limit" }
 
+// Description returns a one-line summary of the feature.
+func (Feature) Description() string {
+	return "Token-bucket rate limiting middleware"
+}
+
+// Version returns the version of the feature's installer. It changes
+// whenever the files the feature writes change.
+func (Feature) Version() string { return "1.1.0" }
+
+// EnvVars returns the environment variables the feature reads.
+func (Feature) EnvVars() []shared.EnvVar {
+	return []shared.EnvVar{
+		{Name: "RATE_LIMIT_RPS", Default: "10", Description: "Requests per second allowed across all clients"},
+		{Name: "RATE_LIMIT_BURST", Default: "20", Description: "Requests allowed in a single burst above the rate"},
+	}
+}
+
 // Compatible reports whether the feature supports a given framework.
 func (Feature) Compatible(framework string) bool {
 	return shared.IsFeatureCompatible("rate-limit", framework)
 }
 
-// Install writes the feature's scaffold files into projectRoot.
-func (Feature) Install(projectRoot string) error {
-	const file = `package server
+// Options returns the settings the feature accepts at install time.
+func (Feature) Options() []shared.Option {
+	return []shared.Option{
+		{Name: "rps", Type: shared.OptionInt, Default: "10", Description: "Requests per second allowed across all clients", Validate: shared.PositiveInt},
+		{Name: "burst", Type: shared.OptionInt, Default: "20", Description: "Requests allowed in a single burst above the rate", Validate: shared.PositiveInt},
+	}
+}
 
-import (
-	"net/http"
-	"time"
+// Requires returns the features rate limiting builds on: its rate is a
+// section of the config feature's Config.
+func (Feature) Requires() []string { return []string{"config"} }
 
-	"golang.org/x/time/rate"
-)
+// Install writes the feature's scaffold files into target, using the
+// middleware variant for the target's framework, and its configuration
+// section defaulting to the rps and burst options, which are also recorded
+// in the project's .env.
+func (Feature) Install(target *shared.Target) error {
+	source := shared.Variant(middlewareSources, target.Framework)
+	if err := target.WriteGoFile(shared.RoleMiddleware, "rate_limit.go", source); err != nil {
+		return err
+	}
+	test := shared.Variant(testHarnesses, target.Framework) + testCases
+	if err := target.WriteGoFile(shared.RoleMiddleware, "rate_limit_test.go", test); err != nil {
+		return err
+	}
+	rps, burst := target.Options.Int("rps"), target.Options.Int("burst")
+	if err := target.WriteGoFile(shared.RoleConfig, "rate_limit.go", fmt.Sprintf(settings, rps, burst)); err != nil {
+		return err
+	}
+	if err := target.SetEnv("RATE_LIMIT_RPS", strconv.Itoa(rps)); err != nil {
+		return err
+	}
+	return target.SetEnv("RATE_LIMIT_BURST", strconv.Itoa(burst))
+}
 
-func RateLimitMiddleware(rps int, burst int, next http.Handler) http.Handler {
-	limiter := rate.NewLimiter(rate.Every(time.Second/time.Duration(rps)), burst)
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !limiter.Allow() {
-			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
+// Wiring returns how the middleware is registered with the rate from the
+// project's Config, and adds the RateLimit section to it.
+func (Feature) Wiring(target *shared.Target) (shared.Wiring, bool) {
+	return shared.Wiring{
+		Middleware: target.Package(shared.RoleMiddleware) + ".RateLimitMiddleware(cfg.RateLimit.RPS, cfg.RateLimit.Burst)",
+		Field: &shared.Field{
+			File:   target.Path(shared.RoleConfig, "config.go"),
+			Struct: "Config",
+			Decl:   "RateLimit RateLimitConfig `yaml:\"rate_limit\" json:\"rate_limit\" toml:\"rate_limit\"`",
+		},
+		Imports: []string{target.Import(shared.RoleMiddleware)},
+	}, true
 }
-`
-	return shared.WriteFileIfMissing(projectRoot, "internal/server/rate_limit.go", []byte(file))
+
+// Usage returns a snippet showing how to use the feature on the target's
+// framework.
+func (Feature) Usage(target *shared.Target) string {
+	return target.Qualify(shared.RoleMiddleware, shared.Variant(usage, target.Framework))
 }
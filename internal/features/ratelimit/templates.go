@@ -0,0 +1,33 @@
+package ratelimit
+
+import (
+	"embed"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// templateFS holds the files the feature renders and the snippets it shows,
+// one file per template and one directory per set of framework variants.
+//
+//go:embed templates
+var templateFS embed.FS
+
+// middlewareSources holds the rate limiting middleware rendered for each
+// framework. Every variant shares one token bucket and rejects requests over
+// the limit with 429 Too Many Requests.
+var middlewareSources = shared.MustReadVariants(templateFS, "templates/middleware")
+
+// testCases is shared by every framework's test so each variant is checked
+// against the same expectations.
+var testCases = shared.MustReadTemplate(templateFS, "templates/test_cases.go.tmpl")
+
+// testHarnesses runs testCases through each framework's router.
+var testHarnesses = shared.MustReadVariants(templateFS, "templates/test_harnesses")
+
+// usage shows how the middleware is registered by hand, with the rate from
+// the project's Config.
+var usage = shared.MustReadVariants(templateFS, "templates/usage")
+
+// settings is the feature's section of the project's Config, defaulting to
+// the rps and burst install options.
+var settings = shared.MustReadTemplate(templateFS, "templates/settings.go.tmpl")
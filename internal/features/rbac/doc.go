@@ -0,0 +1,2 @@
+// Package rbac provides the "rbac" scaffold feature installer.
+package rbac
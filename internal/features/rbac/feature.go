@@ -0,0 +1,62 @@
+package rbac
+
+import (
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// Feature installs the "rbac" scaffold feature.
+type Feature struct{}
+
+// New returns a new "rbac" feature installer.
+func New() Feature { return Feature{} }
+
+// Name returns the registry name of the feature.
+func (Feature) Name() string { return "rbac" }
+
+// Description returns a one-line summary of the feature.
+func (Feature) Description() string {
+	return "Role-based authorization from a hot-reloaded policy file, using the roles in verified JWTs"
+}
+
+// Version returns the version of the feature's installer. It changes
+// whenever the files the feature writes change.
+func (Feature) Version() string { return "1.0.0" }
+
+// Requires returns the features rbac builds on: roles are read from the
+// claims the auth feature's JWT middleware verified.
+func (Feature) Requires() []string { return []string{"auth"} }
+
+// EnvVars returns the environment variables the feature reads.
+func (Feature) EnvVars() []shared.EnvVar {
+	return []shared.EnvVar{
+		{Name: "RBAC_POLICY_FILE", Default: "rbac_policy.json", Description: "Policy file mapping roles to permissions and routes, reloaded when it changes"},
+	}
+}
+
+// Compatible reports whether the feature supports a given framework.
+func (Feature) Compatible(framework string) bool {
+	return shared.IsFeatureCompatible("rbac", framework)
+}
+
+// Install writes the policy engine, the middleware variant for the target's
+// framework and an example policy file.
+func (Feature) Install(target *shared.Target) error {
+	files := []struct{ name, source string }{
+		{"rbac.go", policySource},
+		{"rbac_test.go", policyTestSource},
+		{"rbac_middleware.go", shared.Variant(middlewareSources, target.Framework)},
+		{"rbac_middleware_test.go", shared.Variant(testHarnesses, target.Framework) + testCases},
+	}
+	for _, file := range files {
+		if err := target.WriteGoFile(shared.RoleMiddleware, file.name, file.source); err != nil {
+			return err
+		}
+	}
+	return target.WriteFile("rbac_policy.json", []byte(examplePolicy))
+}
+
+// Usage returns a snippet showing how to put a route group behind the
+// policy on the target's framework.
+func (Feature) Usage(target *shared.Target) string {
+	return target.Qualify(shared.RoleMiddleware, shared.Variant(usage, target.Framework))
+}
@@ -0,0 +1,39 @@
+package rbac
+
+import (
+	"embed"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// templateFS holds the files the feature renders and the snippets it shows,
+// one file per template and one directory per set of framework variants.
+//
+//go:embed templates
+var templateFS embed.FS
+
+// policySource holds the policy format and the engine that evaluates it.
+var policySource = shared.MustReadTemplate(templateFS, "templates/policy.go.tmpl")
+
+// policyTestSource holds the policy engine's tests.
+var policyTestSource = shared.MustReadTemplate(templateFS, "templates/policy_test.go.tmpl")
+
+// middlewareSources holds the RBAC middleware rendered for each framework.
+// Every variant runs after JWTMiddleware and answers 401 without verified
+// claims and 403 when none of the caller's roles allows the route.
+var middlewareSources = shared.MustReadVariants(templateFS, "templates/middleware")
+
+// testCases is shared by every framework's test so each variant is checked
+// against the same expectations.
+var testCases = shared.MustReadTemplate(templateFS, "templates/test_cases.go.tmpl")
+
+// testHarnesses runs testCases through each framework's router, behind the
+// auth feature's JWT middleware.
+var testHarnesses = shared.MustReadVariants(templateFS, "templates/test_harnesses")
+
+// examplePolicy is written to rbac_policy.json for the project to edit.
+var examplePolicy = shared.MustReadTemplate(templateFS, "templates/example_policy.json.tmpl")
+
+// usage shows how to put a route group behind the policy and check a
+// permission inside a handler.
+var usage = shared.MustReadVariants(templateFS, "templates/usage")
@@ -1,6 +1,8 @@
 package redis
 
 import (
+	"fmt"
+
 	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
 )
 
@@ -13,29 +15,63 @@ func New() Feature { return Feature{} }
 // Name returns the registry name of the feature.
 func (Feature) Name() string { return "redis" }
 
+// Description returns a one-line summary of the feature.
+func (Feature) Description() string {
+	return "Redis client configured from REDIS_ADDR"
+}
+
+// Version returns the version of the feature's installer. It changes
+// whenever the files the feature writes change.
+func (Feature) Version() string { return "2.0.0" }
+
+// EnvVars returns the environment variables the feature reads.
+func (Feature) EnvVars() []shared.EnvVar {
+	return []shared.EnvVar{
+		{Name: "REDIS_ADDR", Default: "localhost:6379", Description: "Redis server address"},
+	}
+}
+
 // Compatible reports whether the feature supports a given framework.
 func (Feature) Compatible(framework string) bool {
 	return shared.IsFeatureCompatible("redis", framework)
 }
 
-// Install writes the feature's scaffold files into projectRoot.
-func (Feature) Install(projectRoot string) error {
-	const file = `package storage
+// Options returns the settings the feature accepts at install time.
+func (Feature) Options() []shared.Option {
+	return []shared.Option{
+		{Name: "addr", Type: shared.OptionString, Default: "localhost:6379", Description: "Redis server address, written to REDIS_ADDR in .env", Validate: shared.NotEmpty},
+	}
+}
 
-import (
-	"context"
-	"time"
+// Requires returns the features redis builds on: its address is a section
+// of the config feature's Config.
+func (Feature) Requires() []string { return []string{"config"} }
 
-	redis "github.com/redis/go-redis/v9"
-)
+// Install writes the feature's scaffold files and its configuration section,
+// defaulting to the addr option, into target and records the address in the
+// project's .env.
+func (Feature) Install(target *shared.Target) error {
+	addr := target.Options.String("addr")
+	if err := target.WriteGoFile(shared.RoleStorage, "redis.go", clientSource); err != nil {
+		return err
+	}
+	if err := target.WriteGoFile(shared.RoleConfig, "redis.go", fmt.Sprintf(settings, addr)); err != nil {
+		return err
+	}
+	return target.SetEnv("REDIS_ADDR", addr)
+}
 
-func NewRedisClient(addr string) *redis.Client {
-	client := redis.NewClient(&redis.Options{Addr: addr})
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-	_ = client.Ping(ctx)
-	return client
+// Wiring adds the Redis section to the project's Config.
+func (Feature) Wiring(target *shared.Target) (shared.Wiring, bool) {
+	return shared.Wiring{Field: &shared.Field{
+		File:   target.Path(shared.RoleConfig, "config.go"),
+		Struct: "Config",
+		Decl:   "Redis RedisConfig `yaml:\"redis\" json:\"redis\" toml:\"redis\"`",
+	}}, true
 }
-`
-	return shared.WriteFileIfMissing(projectRoot, "internal/storage/redis.go", []byte(file))
+
+// Usage returns a snippet showing how to use the feature.
+func (Feature) Usage(target *shared.Target) string {
+	return target.Qualify(shared.RoleStorage, `client := storage.NewRedisClient(cfg.Redis.Addr)
+defer client.Close()`)
 }
@@ -0,0 +1,19 @@
+package redis
+
+import (
+	"embed"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// templateFS holds the files the feature renders.
+//
+//go:embed templates
+var templateFS embed.FS
+
+// clientSource connects the Redis client.
+var clientSource = shared.MustReadTemplate(templateFS, "templates/client.go.tmpl")
+
+// settings is the feature's section of the project's Config, defaulting
+// to the addr option.
+var settings = shared.MustReadTemplate(templateFS, "templates/settings.go.tmpl")
@@ -1,34 +1,38 @@
 package features
 
 import (
+	apikeyfeature "github.com/naodEthiop/lalibela-cli/internal/features/apikey"
 	authfeature "github.com/naodEthiop/lalibela-cli/internal/features/auth"
+	authflowfeature "github.com/naodEthiop/lalibela-cli/internal/features/authflow"
 	configfeature "github.com/naodEthiop/lalibela-cli/internal/features/config"
 	corsfeature "github.com/naodEthiop/lalibela-cli/internal/features/cors"
-	dockerfeature "github.com/naodEthiop/lalibela-cli/internal/features/docker"
-	errorhandlerfeature "github.com/naodEthiop/lalibela-cli/internal/features/errorhandler"
 	gracefulshutdownfeature "github.com/naodEthiop/lalibela-cli/internal/features/gracefulshutdown"
-	healthfeature "github.com/naodEthiop/lalibela-cli/internal/features/health"
+	hardeningfeature "github.com/naodEthiop/lalibela-cli/internal/features/hardening"
 	loggerfeature "github.com/naodEthiop/lalibela-cli/internal/features/logger"
+	oidcfeature "github.com/naodEthiop/lalibela-cli/internal/features/oidc"
 	postgresfeature "github.com/naodEthiop/lalibela-cli/internal/features/postgres"
 	ratelimitfeature "github.com/naodEthiop/lalibela-cli/internal/features/ratelimit"
+	rbacfeature "github.com/naodEthiop/lalibela-cli/internal/features/rbac"
 	redisfeature "github.com/naodEthiop/lalibela-cli/internal/features/redis"
-	swaggerfeature "github.com/naodEthiop/lalibela-cli/internal/features/swagger"
+	sessionsfeature "github.com/naodEthiop/lalibela-cli/internal/features/sessions"
 )
 
 // Registry maps feature names to their installers.
 var Registry = map[string]Feature{
+	"api-key":           apikeyfeature.New(),
 	"auth":              authfeature.New(),
+	"auth-flow":         authflowfeature.New(),
 	"config":            configfeature.New(),
 	"cors":              corsfeature.New(),
-	"docker":            dockerfeature.New(),
-	"error-handler":     errorhandlerfeature.New(),
 	"graceful-shutdown": gracefulshutdownfeature.New(),
-	"health":            healthfeature.New(),
+	"hardening":         hardeningfeature.New(),
 	"logger":            loggerfeature.New(),
+	"oidc":              oidcfeature.New(),
 	"postgres":          postgresfeature.New(),
 	"rate-limit":        ratelimitfeature.New(),
+	"rbac":              rbacfeature.New(),
 	"redis":             redisfeature.New(),
-	"swagger":           swaggerfeature.New(),
+	"sessions":          sessionsfeature.New(),
 }
 
 // DefaultProductionFeatures is the set of feature names installed by default
@@ -0,0 +1,313 @@
+package features
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+	"github.com/naodEthiop/lalibela-cli/internal/features/wiring"
+)
+
+// DependentsError reports that features being removed are still required by
+// installed features that are not.
+type DependentsError struct {
+	// Dependents maps each feature to the installed features requiring it.
+	Dependents map[string][]string
+}
+
+func (e *DependentsError) Error() string {
+	names := make([]string, 0, len(e.Dependents))
+	for name := range e.Dependents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	problems := make([]string, 0, len(names))
+	for _, name := range names {
+		problems = append(problems, fmt.Sprintf("feature %q is required by %s", name, strings.Join(e.Dependents[name], ", ")))
+	}
+	return strings.Join(problems, "; ")
+}
+
+// RemoveFeatures removes installed features from the given project directory.
+//
+// Only files whose content still matches the hash recorded at install time are
+// deleted; modified files are kept and reported unless force is set. Each
+// feature is dropped from the project state, and if a runner is provided,
+// `go mod tidy` runs once after all features have been removed.
+//
+// A feature required by another installed feature is not removed: a
+// *DependentsError lists the features requiring it, unless force is set. The
+// removal is transactional like InstallFeatures: a failure restores every
+// file, wiring edit and env var it changed.
+func RemoveFeatures(projectRoot string, featureNames []string, force bool, runner CommandRunner) ([]RemoveResult, error) {
+	return removeFeatures(projectRoot, featureNames, force, false, runner)
+}
+
+// RemoveFeaturesCascade is like RemoveFeatures, but also removes the installed
+// features that require the named ones, before the features they require.
+func RemoveFeaturesCascade(projectRoot string, featureNames []string, force bool, runner CommandRunner) ([]RemoveResult, error) {
+	return removeFeatures(projectRoot, featureNames, force, true, runner)
+}
+
+func removeFeatures(projectRoot string, featureNames []string, force, cascade bool, runner CommandRunner) ([]RemoveResult, error) {
+	state, err := loadState(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(featureNames))
+	for _, featureName := range featureNames {
+		normalized := strings.ToLower(strings.TrimSpace(featureName))
+		if !contains(state.Installed, normalized) {
+			return nil, fmt.Errorf("feature %q is not installed", featureName)
+		}
+		if !contains(names, normalized) {
+			names = append(names, normalized)
+		}
+	}
+	dependents := installedDependents(state, names)
+	for cascade && len(dependents) > 0 {
+		for _, required := range dependents {
+			for _, dependent := range required {
+				if !contains(names, dependent) {
+					names = append(names, dependent)
+				}
+			}
+		}
+		dependents = installedDependents(state, names)
+	}
+	if len(dependents) > 0 && !force {
+		return nil, &DependentsError{Dependents: dependents}
+	}
+	names = removalOrder(names)
+
+	// The journal target preserves every file the removal may change, so a
+	// failure restores the project as it was.
+	journal := shared.NewTarget(projectRoot, state.Framework)
+	preserve := append([]string{".env", shared.EnvExampleFile}, transactionFiles...)
+	for _, name := range names {
+		record := state.Features[name]
+		for _, owned := range record.Files {
+			if !fs.ValidPath(owned.Path) {
+				return nil, fmt.Errorf("feature %s: owned file %q must be a relative slash-separated path", name, owned.Path)
+			}
+			preserve = append(preserve, owned.Path)
+		}
+		for _, patch := range record.Wiring {
+			preserve = append(preserve, patch.File)
+		}
+	}
+	for _, path := range preserve {
+		if err := journal.Preserve(path); err != nil {
+			return nil, fmt.Errorf("preparing feature removal: %w", err)
+		}
+	}
+	abort := func(err error) error {
+		if rollbackErr := journal.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback incomplete: %v)", err, rollbackErr)
+		}
+		return fmt.Errorf("%w (all changes were rolled back)", err)
+	}
+
+	results := make([]RemoveResult, 0, len(names))
+	for _, name := range names {
+		result, err := removeFeatureFiles(projectRoot, name, state, force)
+		if err != nil {
+			return nil, abort(err)
+		}
+		result.RequiredBy = dependents[name]
+		state.Installed = without(state.Installed, name)
+		delete(state.Features, name)
+		results = append(results, result)
+	}
+
+	if err := saveState(projectRoot, state); err != nil {
+		return nil, abort(err)
+	}
+	if runner != nil {
+		if err := runner(projectRoot, "go", "mod", "tidy"); err != nil {
+			return nil, abort(fmt.Errorf("go mod tidy after feature removal: %w", err))
+		}
+	}
+	return results, nil
+}
+
+// installedDependents maps each of names to the installed features outside
+// names that require it.
+func installedDependents(state State, names []string) map[string][]string {
+	dependents := make(map[string][]string)
+	for _, other := range state.Installed {
+		if contains(names, other) {
+			continue
+		}
+		for _, required := range featureRequires(other) {
+			if contains(names, required) {
+				dependents[required] = append(dependents[required], other)
+			}
+		}
+	}
+	return dependents
+}
+
+// removalOrder orders names so every feature is removed before the features
+// it requires.
+func removalOrder(names []string) []string {
+	remaining := append([]string(nil), names...)
+	ordered := make([]string, 0, len(names))
+	for len(remaining) > 0 {
+		next := 0
+		for i, name := range remaining {
+			required := false
+			for _, other := range remaining {
+				if contains(featureRequires(other), name) {
+					required = true
+					break
+				}
+			}
+			if !required {
+				next = i
+				break
+			}
+		}
+		ordered = append(ordered, remaining[next])
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+	return ordered
+}
+
+// featureRequires returns the features name requires, or nil when it is not
+// a known feature.
+func featureRequires(name string) []string {
+	feature, err := lookupFeature(name)
+	if err != nil {
+		return nil
+	}
+	if dependent, ok := feature.(Dependent); ok {
+		return dependent.Requires()
+	}
+	return nil
+}
+
+func removeFeatureFiles(projectRoot, name string, state State, force bool) (RemoveResult, error) {
+	result := RemoveResult{Name: name}
+	record, ok := state.Features[name]
+	if !ok {
+		result.Untracked = true
+		return result, nil
+	}
+	result.Edited = record.Edited
+
+	if err := removeFeatureEnv(projectRoot, name, record, state, &result); err != nil {
+		return result, err
+	}
+
+	stale, err := wiring.Revert(projectRoot, record.Wiring)
+	if err != nil {
+		return result, fmt.Errorf("reverting wiring for %s: %w", name, err)
+	}
+	for _, patch := range stale {
+		result.StaleWiring = append(result.StaleWiring, fmt.Sprintf("%s: %s", patch.File, firstLine(patch.After)))
+	}
+
+	for _, owned := range record.Files {
+		fullPath := filepath.Join(projectRoot, filepath.FromSlash(owned.Path))
+		current, err := os.ReadFile(fullPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				result.Missing = append(result.Missing, owned.Path)
+				continue
+			}
+			return result, fmt.Errorf("reading %s: %w", owned.Path, err)
+		}
+
+		if shared.HashContent(current) != owned.SHA256 {
+			result.Modified = append(result.Modified, owned.Path)
+			if !force {
+				continue
+			}
+		}
+
+		if err := os.Remove(fullPath); err != nil {
+			return result, fmt.Errorf("removing %s: %w", owned.Path, err)
+		}
+		result.Deleted = append(result.Deleted, owned.Path)
+		pruneEmptyDirs(projectRoot, filepath.Dir(fullPath))
+	}
+	return result, nil
+}
+
+// removeFeatureEnv takes the variables the feature added out of .env and
+// .env.example, except those another installed feature still reads.
+func removeFeatureEnv(projectRoot, name string, record FeatureRecord, state State, result *RemoveResult) error {
+	if len(record.Env) == 0 {
+		return nil
+	}
+	needed := make(map[string]bool)
+	for _, other := range state.Installed {
+		if other == name {
+			continue
+		}
+		for _, envVar := range state.Features[other].Env {
+			needed[envVar.Name] = true
+		}
+		if feature, err := lookupFeature(other); err == nil {
+			if declarer, ok := feature.(EnvDeclarer); ok {
+				for _, envVar := range declarer.EnvVars() {
+					needed[envVar.Name] = true
+				}
+			}
+		}
+	}
+	var vars []shared.EnvVar
+	for _, envVar := range record.Env {
+		if !needed[envVar.Name] {
+			vars = append(vars, envVar)
+			result.Env = append(result.Env, envVar.Name)
+		}
+	}
+	if err := shared.RemoveEnvFiles(projectRoot, vars); err != nil {
+		return fmt.Errorf("removing env vars for %s: %w", name, err)
+	}
+	return nil
+}
+
+// pruneEmptyDirs removes dir and its parents while they are empty, stopping at
+// projectRoot.
+func pruneEmptyDirs(projectRoot, dir string) {
+	root := filepath.Clean(projectRoot)
+	for {
+		dir = filepath.Clean(dir)
+		if dir == root || !strings.HasPrefix(dir, root+string(filepath.Separator)) {
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil || len(entries) > 0 {
+			return
+		}
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func without(values []string, target string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != target {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func firstLine(text string) string {
+	if idx := strings.IndexByte(text, '\n'); idx >= 0 {
+		return text[:idx] + " ..."
+	}
+	return text
+}
@@ -0,0 +1,232 @@
+package features
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallFeatureRecordsOwnedFiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if _, err := InstallFeature(root, "gin", "postgres", nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+
+	state, err := loadState(root)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	record, ok := state.Features["postgres"]
+	if !ok {
+		t.Fatalf("expected postgres record in state, got %+v", state.Features)
+	}
+	if len(record.Files) != 3 {
+		t.Fatalf("expected 3 owned files, got %+v", record.Files)
+	}
+	for _, file := range record.Files {
+		if file.SHA256 == "" || strings.Contains(file.Path, "\\") {
+			t.Fatalf("unexpected owned file entry: %+v", file)
+		}
+	}
+}
+
+func TestRemoveFeaturesDeletesUnchangedFiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if _, err := InstallFeature(root, "gin", "postgres", nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+
+	var tidyCalls int
+	runner := func(dir string, name string, args ...string) error {
+		tidyCalls++
+		return nil
+	}
+	results, err := RemoveFeatures(root, []string{"postgres"}, false, runner)
+	if err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if len(results) != 1 || len(results[0].Deleted) != 3 {
+		t.Fatalf("unexpected remove results: %+v", results)
+	}
+	if tidyCalls != 1 {
+		t.Fatalf("expected go mod tidy to run once, got %d", tidyCalls)
+	}
+	if _, err := os.Stat(filepath.Join(root, "internal", "storage")); !os.IsNotExist(err) {
+		t.Fatalf("expected empty internal/storage/ directory to be pruned, err=%v", err)
+	}
+	if config := readFile(t, filepath.Join(root, "internal", "config", "config.go")); strings.Contains(config, "Postgres") {
+		t.Fatalf("expected the Postgres section to be removed from Config:\n%s", config)
+	}
+
+	state, err := loadState(root)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if contains(state.Installed, "postgres") {
+		t.Fatalf("expected postgres to be dropped from state, got %v", state.Installed)
+	}
+	if _, ok := state.Features["postgres"]; ok {
+		t.Fatalf("expected postgres record to be dropped from state")
+	}
+}
+
+func TestRemoveFeaturesKeepsModifiedFiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if _, err := InstallFeature(root, "gin", "cors", nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	corsPath := filepath.Join(root, "internal", "server", "cors.go")
+	if err := os.WriteFile(corsPath, []byte("package server // edited\n"), 0o644); err != nil {
+		t.Fatalf("edit cors.go: %v", err)
+	}
+
+	results, err := RemoveFeatures(root, []string{"cors"}, false, nil)
+	if err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if len(results[0].Modified) != 1 || contains(results[0].Deleted, "internal/server/cors.go") {
+		t.Fatalf("expected modified file to be kept, got %+v", results[0])
+	}
+	if _, err := os.Stat(corsPath); err != nil {
+		t.Fatalf("expected modified cors.go to remain: %v", err)
+	}
+}
+
+func TestRemoveFeaturesForceDeletesModifiedFiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if _, err := InstallFeature(root, "gin", "cors", nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	corsPath := filepath.Join(root, "internal", "server", "cors.go")
+	if err := os.WriteFile(corsPath, []byte("package server // edited\n"), 0o644); err != nil {
+		t.Fatalf("edit cors.go: %v", err)
+	}
+
+	results, err := RemoveFeatures(root, []string{"cors"}, true, nil)
+	if err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if len(results[0].Modified) != 1 || len(results[0].Deleted) != 2 {
+		t.Fatalf("expected modified file to be deleted, got %+v", results[0])
+	}
+	if _, err := os.Stat(corsPath); !os.IsNotExist(err) {
+		t.Fatalf("expected cors.go to be removed, err=%v", err)
+	}
+}
+
+func TestRemoveFeaturesRejectsPathsOutsideProject(t *testing.T) {
+	t.Parallel()
+
+	root := filepath.Join(t.TempDir(), "project")
+	if _, err := InstallFeature(root, "gin", "cors", nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	outside := filepath.Join(filepath.Dir(root), "outside.go")
+	if err := os.WriteFile(outside, []byte("package outside\n"), 0o644); err != nil {
+		t.Fatalf("write outside.go: %v", err)
+	}
+	downgradeRecord(t, root, "cors", func(record *FeatureRecord) {
+		record.Files[0].Path = "../outside.go"
+	})
+
+	if _, err := RemoveFeatures(root, []string{"cors"}, true, nil); err == nil || !strings.Contains(err.Error(), `"../outside.go"`) {
+		t.Fatalf("expected the owned path to be rejected, got %v", err)
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Fatalf("expected the file outside the project to be kept: %v", err)
+	}
+}
+
+func TestRemoveFeaturesRefusesRequiredFeatures(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	requests := []FeatureRequest{{Name: "oidc"}, {Name: "rbac"}, {Name: "auth-flow"}, {Name: "cors"}}
+	if _, err := InstallFeatures(root, "gin", requests, nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+
+	_, err := RemoveFeatures(root, []string{"auth"}, false, nil)
+	var dependents *DependentsError
+	if !errors.As(err, &dependents) || strings.Join(dependents.Dependents["auth"], ",") != "auth-flow,oidc,rbac" {
+		t.Fatalf("expected auth to be refused with its dependents, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "internal", "server", "auth_jwt.go")); err != nil {
+		t.Fatalf("expected nothing to be removed: %v", err)
+	}
+
+	results, err := RemoveFeaturesCascade(root, []string{"auth"}, false, nil)
+	if err != nil {
+		t.Fatalf("remove with dependents: %v", err)
+	}
+	if len(results) != 4 || results[3].Name != "auth" {
+		t.Fatalf("expected the dependents to be removed before auth, got %+v", results)
+	}
+	state, err := loadState(root)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if strings.Join(state.Installed, ",") != "cors" {
+		t.Fatalf("expected only cors to remain, got %v", state.Installed)
+	}
+}
+
+func TestRemoveFeaturesForceIgnoresDependents(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if _, err := InstallFeature(root, "gin", "rbac", nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	results, err := RemoveFeatures(root, []string{"auth"}, true, nil)
+	if err != nil {
+		t.Fatalf("forced remove: %v", err)
+	}
+	if len(results) != 1 || strings.Join(results[0].RequiredBy, ",") != "rbac" {
+		t.Fatalf("expected auth removed and reported as required by rbac, got %+v", results)
+	}
+}
+
+func TestRemoveFeaturesRollsBackOnFailure(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if _, err := InstallFeature(root, "gin", "postgres", nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	before := map[string]string{}
+	for _, name := range []string{".env", ".env.example", ".lalibela/features.json", "internal/config/config.go", "internal/storage/postgres.go"} {
+		before[name] = readFile(t, filepath.Join(root, filepath.FromSlash(name)))
+	}
+
+	failing := func(dir string, name string, args ...string) error {
+		return errors.New("tidy failed")
+	}
+	if _, err := RemoveFeatures(root, []string{"postgres"}, false, failing); err == nil || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("expected the removal to be rolled back, got %v", err)
+	}
+	for name, content := range before {
+		if got := readFile(t, filepath.Join(root, filepath.FromSlash(name))); got != content {
+			t.Fatalf("expected %s to be restored, got:\n%s", name, got)
+		}
+	}
+}
+
+func TestRemoveFeaturesRejectsUnknownInstall(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if _, err := RemoveFeatures(root, []string{"redis"}, false, nil); err == nil {
+		t.Fatal("expected error when removing a feature that is not installed")
+	}
+}
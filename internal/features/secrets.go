@@ -0,0 +1,188 @@
+package features
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+	"github.com/naodEthiop/lalibela-cli/internal/secrets"
+)
+
+// Files GenerateSigningKey writes when .env does not name others, relative to
+// the project root.
+const (
+	SigningKeyFile = "keys/jwt_signing.pem"
+	JWKSFile       = "keys/jwks.json"
+)
+
+// signingKeyEnv names the signing key file in .env. Rotating it replaces the
+// key pair.
+const signingKeyEnv = "JWT_PRIVATE_KEY_FILE"
+
+// signingKeyVars are the variables GenerateSigningKey sets in .env.
+var signingKeyVars = []shared.EnvVar{
+	{Name: signingKeyEnv, Default: SigningKeyFile, Description: "PEM key signing JWTs with RS256 or EdDSA, used instead of JWT_SECRET"},
+	{Name: "JWT_JWKS_FILE", Default: JWKSFile, Description: "JWKS file with the verification keys, used instead of JWT_SECRET"},
+	{Name: "JWT_KID", Description: "Key ID of the current signing key"},
+}
+
+// GeneratedSecrets returns the env vars registered features fill with random
+// values, sorted by name.
+func GeneratedSecrets() []shared.EnvVar {
+	byName := make(map[string]shared.EnvVar)
+	for _, feature := range Registry {
+		declarer, ok := feature.(EnvDeclarer)
+		if !ok {
+			continue
+		}
+		for _, envVar := range declarer.EnvVars() {
+			if envVar.Generate {
+				byName[envVar.Name] = envVar
+			}
+		}
+	}
+	out := make([]shared.EnvVar, 0, len(byName))
+	for _, envVar := range byName {
+		out = append(out, envVar)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// RotateSecrets gives the named generated secrets new random values in the
+// project's .env, leaving the blank placeholders of .env.example as they are.
+// Without names it rotates every generated secret assigned in .env, and the
+// signing key when JWT_PRIVATE_KEY_FILE is set. Naming JWT_PRIVATE_KEY_FILE
+// replaces the signing key with a new one of the same algorithm. It returns
+// the rotated names, sorted.
+func RotateSecrets(projectRoot string, names []string) ([]string, error) {
+	values, err := shared.EnvFileValues(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	generated := make(map[string]bool)
+	for _, envVar := range GeneratedSecrets() {
+		generated[envVar.Name] = true
+	}
+
+	rotateKey := false
+	var rotate []string
+	if len(names) == 0 {
+		for name := range values {
+			if generated[name] {
+				rotate = append(rotate, name)
+			}
+		}
+		rotateKey = values[signingKeyEnv] != ""
+		if len(rotate) == 0 && !rotateKey {
+			return nil, errors.New("no generated secrets found in .env")
+		}
+	}
+	for _, name := range names {
+		switch {
+		case name == signingKeyEnv:
+			if values[signingKeyEnv] == "" {
+				return nil, fmt.Errorf("%s is not set; create a signing key with 'lalibela secrets keygen'", signingKeyEnv)
+			}
+			rotateKey = true
+		case generated[name]:
+			rotate = append(rotate, name)
+		default:
+			return nil, fmt.Errorf("%s is not a secret lalibela generates; set it in .env yourself", name)
+		}
+	}
+
+	if rotateKey {
+		current, err := secrets.ReadPrivateKey(projectPath(projectRoot, values[signingKeyEnv]))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := GenerateSigningKey(projectRoot, current.Alg); err != nil {
+			return nil, err
+		}
+		rotate = append(rotate, signingKeyEnv)
+	}
+	updated := make(map[string]string, len(rotate))
+	for _, name := range rotate {
+		if name == signingKeyEnv {
+			continue
+		}
+		value, err := secrets.Generate()
+		if err != nil {
+			return nil, err
+		}
+		updated[name] = value
+	}
+	if err := shared.SetEnvFile(projectRoot, updated); err != nil {
+		return nil, err
+	}
+	if err := shared.EnsureGitignored(projectRoot, ".env"); err != nil {
+		return nil, err
+	}
+	sort.Strings(rotate)
+	return rotate, nil
+}
+
+// GenerateSigningKey creates an RS256 or EdDSA key pair for signing JWTs. The
+// private key is written as PEM, readable by its owner only, to the file .env
+// names in JWT_PRIVATE_KEY_FILE or else SigningKeyFile, and gitignored. The
+// public key is written to the JWKS file of JWT_JWKS_FILE or else JWKSFile,
+// next to the key it replaces so tokens signed before still verify. .env is
+// pointed at both files and JWT_KID set to the new key's ID.
+func GenerateSigningKey(projectRoot, alg string) (secrets.Key, error) {
+	key, err := secrets.NewKey(alg)
+	if err != nil {
+		return secrets.Key{}, err
+	}
+	values, err := shared.EnvFileValues(projectRoot)
+	if err != nil {
+		return secrets.Key{}, err
+	}
+	keyFile, jwksFile := values[signingKeyEnv], values["JWT_JWKS_FILE"]
+	if keyFile == "" {
+		keyFile = SigningKeyFile
+	}
+	if jwksFile == "" {
+		jwksFile = JWKSFile
+	}
+
+	if err := secrets.WritePrivateKey(projectPath(projectRoot, keyFile), key); err != nil {
+		return secrets.Key{}, err
+	}
+	if err := secrets.UpdateJWKS(projectPath(projectRoot, jwksFile), key, values["JWT_KID"]); err != nil {
+		return secrets.Key{}, err
+	}
+	if _, err := shared.MergeEnvFiles(projectRoot, signingKeyVars); err != nil {
+		return secrets.Key{}, err
+	}
+	err = shared.SetEnvFile(projectRoot, map[string]string{
+		signingKeyEnv:   keyFile,
+		"JWT_JWKS_FILE": jwksFile,
+		"JWT_KID":       key.ID,
+	})
+	if err != nil {
+		return secrets.Key{}, err
+	}
+	if !filepath.IsAbs(keyFile) {
+		pattern := "/" + filepath.ToSlash(filepath.Clean(keyFile))
+		if matched, _ := path.Match("/keys/*.pem", pattern); matched {
+			pattern = "keys/*.pem"
+		}
+		if err := shared.EnsureGitignored(projectRoot, pattern); err != nil {
+			return secrets.Key{}, err
+		}
+	}
+	return key, nil
+}
+
+// projectPath resolves a path from .env, which the generated code reads
+// relative to the project root.
+func projectPath(projectRoot, name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(projectRoot, filepath.FromSlash(name))
+}
@@ -0,0 +1,128 @@
+package features
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+	"github.com/naodEthiop/lalibela-cli/internal/secrets"
+)
+
+func TestGeneratedSecretsCoverFeatureSecrets(t *testing.T) {
+	t.Parallel()
+
+	var names []string
+	for _, envVar := range GeneratedSecrets() {
+		names = append(names, envVar.Name)
+	}
+	if got := strings.Join(names, ","); got != "JWT_SECRET,OIDC_COOKIE_SECRET,SESSION_SECRET" {
+		t.Fatalf("unexpected generated secrets: %s", got)
+	}
+}
+
+func TestRotateSecrets(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeProjectFile(t, root, ".env", "PORT=8080\nJWT_SECRET=old-jwt-secret\nSESSION_SECRET=\"old-session-secret\"\nDB_PASSWORD=password\n")
+	writeProjectFile(t, root, shared.EnvExampleFile, "# Secret used to sign and verify JWTs\nJWT_SECRET=\n")
+
+	rotated, err := RotateSecrets(root, nil)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if got := strings.Join(rotated, ","); got != "JWT_SECRET,SESSION_SECRET" {
+		t.Fatalf("unexpected rotated secrets: %s", got)
+	}
+	values, err := shared.EnvFileValues(root)
+	if err != nil {
+		t.Fatalf("read .env: %v", err)
+	}
+	for _, name := range rotated {
+		if len(values[name]) < 32 || strings.HasPrefix(values[name], "old-") {
+			t.Fatalf("expected %s to get a random value, got %q", name, values[name])
+		}
+	}
+	if values["DB_PASSWORD"] != "password" || values["PORT"] != "8080" {
+		t.Fatalf("expected other variables to be kept, got %v", values)
+	}
+	if example := readFile(t, filepath.Join(root, shared.EnvExampleFile)); example != "# Secret used to sign and verify JWTs\nJWT_SECRET=\n" {
+		t.Fatalf("expected .env.example to keep its placeholders, got:\n%s", example)
+	}
+	if gitignore := readFile(t, filepath.Join(root, shared.GitignoreFile)); gitignore != ".env\n" {
+		t.Fatalf("expected .env to be gitignored, got:\n%s", gitignore)
+	}
+
+	if _, err := RotateSecrets(root, []string{"DB_PASSWORD"}); err == nil {
+		t.Fatalf("expected rotating a credential issued elsewhere to fail")
+	}
+	if rotated, err := RotateSecrets(root, []string{"OIDC_COOKIE_SECRET"}); err != nil || len(rotated) != 1 {
+		t.Fatalf("expected a missing generated secret to be added, got %v, %v", rotated, err)
+	}
+	if values, _ := shared.EnvFileValues(root); values["JWT_SECRET"] == "" || values["OIDC_COOKIE_SECRET"] == "" {
+		t.Fatalf("unexpected .env after rotating one secret: %v", values)
+	}
+}
+
+func TestGenerateSigningKeyAndRotate(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeProjectFile(t, root, ".env", "PORT=8080\n")
+	first, err := GenerateSigningKey(root, secrets.AlgEdDSA)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(root, SigningKeyFile))
+	if err != nil {
+		t.Fatalf("stat key: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("expected the private key to be readable by its owner only, got %v", perm)
+	}
+	if gitignore := readFile(t, filepath.Join(root, shared.GitignoreFile)); !strings.Contains(gitignore, "keys/*.pem\n") {
+		t.Fatalf("expected the private key to be gitignored, got:\n%s", gitignore)
+	}
+	if example := readFile(t, filepath.Join(root, shared.EnvExampleFile)); !strings.Contains(example, "JWT_PRIVATE_KEY_FILE="+SigningKeyFile+"\n") {
+		t.Fatalf("expected the key file in .env.example, got:\n%s", example)
+	}
+	values, _ := shared.EnvFileValues(root)
+	if values["JWT_KID"] != first.ID || values["JWT_JWKS_FILE"] != JWKSFile {
+		t.Fatalf("unexpected .env after keygen: %v", values)
+	}
+
+	rotated, err := RotateSecrets(root, []string{"JWT_PRIVATE_KEY_FILE"})
+	if err != nil || strings.Join(rotated, ",") != "JWT_PRIVATE_KEY_FILE" {
+		t.Fatalf("rotate key: %v, %v", rotated, err)
+	}
+	second, err := secrets.ReadPrivateKey(filepath.Join(root, SigningKeyFile))
+	if err != nil {
+		t.Fatalf("read rotated key: %v", err)
+	}
+	if second.ID == first.ID || second.Alg != secrets.AlgEdDSA {
+		t.Fatalf("expected a new EdDSA key, got %s %s", second.Alg, second.ID)
+	}
+	var jwks struct {
+		Keys []secrets.JWK `json:"keys"`
+	}
+	if err := json.Unmarshal([]byte(readFile(t, filepath.Join(root, JWKSFile))), &jwks); err != nil {
+		t.Fatalf("parse JWKS: %v", err)
+	}
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kid != second.ID || jwks.Keys[1].Kid != first.ID {
+		t.Fatalf("expected the new key followed by the replaced one, got %+v", jwks.Keys)
+	}
+	if values, _ := shared.EnvFileValues(root); values["JWT_KID"] != second.ID {
+		t.Fatalf("expected JWT_KID to name the new key, got %q", values["JWT_KID"])
+	}
+}
+
+func writeProjectFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+}
@@ -0,0 +1,2 @@
+// Package sessions provides the "sessions" scaffold feature installer.
+package sessions
@@ -0,0 +1,102 @@
+package sessions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// Feature installs the "sessions" scaffold feature.
+type Feature struct{}
+
+// New returns a new "sessions" feature installer.
+func New() Feature { return Feature{} }
+
+// Name returns the registry name of the feature.
+func (Feature) Name() string { return "sessions" }
+
+// Description returns a one-line summary of the feature.
+func (Feature) Description() string {
+	return "Encrypted cookie sessions (memory, Redis or PostgreSQL store) with rotation and CSRF protection"
+}
+
+// Version returns the version of the feature's installer. It changes
+// whenever the files the feature writes change.
+func (Feature) Version() string { return "1.0.0" }
+
+// EnvVars returns the environment variables the feature reads.
+func (Feature) EnvVars() []shared.EnvVar {
+	return []shared.EnvVar{
+		{Name: "SESSION_SECRET", Description: "Secret encrypting the session cookie (at least 32 bytes)", Secret: true, Generate: true},
+		{Name: "SESSION_TTL", Default: "24h", Description: "How long an unused session lasts"},
+		{Name: "SESSION_COOKIE_SECURE", Default: "true", Description: "Send the session cookie over HTTPS only"},
+	}
+}
+
+// Compatible reports whether the feature supports a given framework.
+func (Feature) Compatible(framework string) bool {
+	return shared.IsFeatureCompatible("sessions", framework)
+}
+
+// stores maps the store option to the file holding that store, if any. The
+// memory store is always written.
+var stores = map[string]string{
+	"memory":   "",
+	"redis":    redisStoreSource,
+	"postgres": postgresStoreSource,
+}
+
+// Options returns the settings the feature accepts at install time.
+func (Feature) Options() []shared.Option {
+	return []shared.Option{
+		{Name: "store", Type: shared.OptionString, Default: "memory", Description: "Where sessions are kept: memory, redis or postgres", Validate: validateStore},
+	}
+}
+
+func validateStore(value string) error {
+	if _, ok := stores[value]; !ok {
+		return fmt.Errorf("must be memory, redis or postgres, got %q", value)
+	}
+	return nil
+}
+
+// Install writes the session manager, the store chosen with --store and the
+// middleware variant for the target's framework.
+func (Feature) Install(target *shared.Target) error {
+	store := target.Options.String("store")
+	files := []struct{ name, source string }{
+		{"sessions.go", sessionSource},
+		{"sessions_test.go", sessionTestSource},
+		{"sessions_middleware.go", shared.Variant(middlewareSources, target.Framework)},
+		{"sessions_middleware_test.go", shared.Variant(testHarnesses, target.Framework) + testCases},
+	}
+	if source := stores[store]; source != "" {
+		files = append(files, struct{ name, source string }{"session_store_" + store + ".go", source})
+	}
+	for _, file := range files {
+		if err := target.WriteGoFile(shared.RoleMiddleware, file.name, file.source); err != nil {
+			return err
+		}
+	}
+	switch store {
+	case "postgres":
+		return target.WriteFile(target.Path(shared.RoleMigrations, "0004_sessions.sql"), []byte(migration))
+	case "redis":
+		redisClient := filepath.Join(target.Root, filepath.FromSlash(target.Path(shared.RoleStorage, "redis.go")))
+		if _, err := os.Stat(redisClient); err != nil {
+			target.AddManualStep("Run `lalibela add redis` for the Redis client the session store uses")
+		}
+	}
+	return nil
+}
+
+// Usage returns a snippet showing how to enable sessions and CSRF checks on
+// the target's framework with the store chosen with --store.
+func (Feature) Usage(target *shared.Target) string {
+	snippet := storeUsage[target.Options.String("store")] + "\n" + shared.Variant(usage, target.Framework)
+	snippet = target.Qualify(shared.RoleStorage, snippet)
+	return target.Qualify(shared.RoleMiddleware, strings.TrimSpace(snippet))
+}
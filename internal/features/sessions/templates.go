@@ -0,0 +1,50 @@
+package sessions
+
+import (
+	"embed"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// templateFS holds the files the feature renders and the snippets it shows,
+// one file per template and one directory per set of framework variants.
+//
+//go:embed templates
+var templateFS embed.FS
+
+// sessionSource holds the framework-independent session manager, the
+// memory store and the CSRF and template helpers.
+var sessionSource = shared.MustReadTemplate(templateFS, "templates/session.go.tmpl")
+
+// sessionTestSource holds the session manager's tests.
+var sessionTestSource = shared.MustReadTemplate(templateFS, "templates/session_test.go.tmpl")
+
+// redisStoreSource holds the Redis store, written with --store redis. It
+// uses the client from the redis feature's storage.NewRedisClient.
+var redisStoreSource = shared.MustReadTemplate(templateFS, "templates/redis_store.go.tmpl")
+
+// postgresStoreSource holds the PostgreSQL store, written with --store
+// postgres along with its migration.
+var postgresStoreSource = shared.MustReadTemplate(templateFS, "templates/postgres_store.go.tmpl")
+
+// migration creates the table of the PostgreSQL store.
+var migration = shared.MustReadTemplate(templateFS, "templates/migration.sql.tmpl")
+
+// middlewareSources holds the session and CSRF middleware rendered for each
+// framework. The session cookie is set just before the response headers
+// are written, so handlers may change the session until they respond.
+var middlewareSources = shared.MustReadVariants(templateFS, "templates/middleware")
+
+// testCases is shared by every framework's test: it logs in and out through
+// the app's router and checks the cookies and CSRF tokens on the way.
+var testCases = shared.MustReadTemplate(templateFS, "templates/test_cases.go.tmpl")
+
+// testHarnesses runs testCases through each framework's router.
+var testHarnesses = shared.MustReadVariants(templateFS, "templates/test_harnesses")
+
+// storeUsage shows how each --store value's store is created.
+var storeUsage = shared.MustReadVariants(templateFS, "templates/store_usage")
+
+// usage shows how to enable sessions and CSRF checks, rotate the session on
+// login and render the CSRF field in a form.
+var usage = shared.MustReadVariants(templateFS, "templates/usage")
@@ -3,20 +3,19 @@ package shared
 import "strings"
 
 // IsFeatureCompatible reports whether a feature should be offered/installed for
-// a given framework.
+// a given framework. It covers the features implemented in Go; features
+// defined by manifests declare their frameworks in feature.json.
 func IsFeatureCompatible(featureName, framework string) bool {
 	feature := strings.ToLower(strings.TrimSpace(featureName))
 	fw := strings.ToLower(strings.TrimSpace(framework))
 
 	switch fw {
-	case "gin", "echo":
+	case "gin", "echo", "fiber":
 		return true
-	case "fiber":
-		// Swagger is intentionally excluded for Fiber auto-wiring.
-		return feature != "swagger"
 	case "nethttp":
 		switch feature {
-		case "docker", "logger", "postgres", "redis", "config", "graceful-shutdown", "health", "swagger":
+		case "logger", "postgres", "redis", "config", "graceful-shutdown", "hardening",
+			"cors", "rate-limit", "auth", "auth-flow", "api-key", "oidc", "rbac", "sessions":
 			return true
 		default:
 			return false
@@ -0,0 +1,62 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NewFileSuffix is appended to a conflicting file's path when the feature's
+// version is written alongside the existing file.
+const NewFileSuffix = ".lalibela-new"
+
+// Resolution is how a conflict between an existing project file and a
+// feature's version of it is settled.
+type Resolution string
+
+const (
+	// ResolveKeep leaves the existing file alone and skips the feature's version.
+	ResolveKeep Resolution = "keep"
+	// ResolveOverwrite replaces the existing file with the feature's version.
+	ResolveOverwrite Resolution = "overwrite"
+	// ResolveWriteNew keeps the existing file and writes the feature's version
+	// next to it with NewFileSuffix.
+	ResolveWriteNew Resolution = "new"
+)
+
+// Resolutions lists the valid resolutions, in the order they are offered.
+func Resolutions() []Resolution {
+	return []Resolution{ResolveKeep, ResolveOverwrite, ResolveWriteNew}
+}
+
+// ParseResolution parses a --on-conflict policy value.
+func ParseResolution(value string) (Resolution, error) {
+	normalized := Resolution(strings.ToLower(strings.TrimSpace(value)))
+	for _, resolution := range Resolutions() {
+		if normalized == resolution {
+			return resolution, nil
+		}
+	}
+	return "", fmt.Errorf("unknown conflict policy %q (use keep, overwrite or new)", value)
+}
+
+// Conflict is a file a feature wants to write that already exists in the
+// project with different content.
+type Conflict struct {
+	// Feature is the name of the feature being installed, when known.
+	Feature string
+	// Path is the slash-separated path relative to the project root.
+	Path     string
+	Existing []byte
+	Proposed []byte
+}
+
+// Resolver decides how a conflict is settled.
+type Resolver func(Conflict) (Resolution, error)
+
+// ConflictOutcome records how a conflict was settled during an install.
+type ConflictOutcome struct {
+	Path       string     `json:"path"`
+	Resolution Resolution `json:"resolution"`
+	// NewPath is where the feature's version was written for ResolveWriteNew.
+	NewPath string `json:"new_path,omitempty"`
+}
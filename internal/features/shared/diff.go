@@ -0,0 +1,138 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// diffLine is one line of an edit script: ' ' kept, '-' removed, '+' added.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// Diff returns a unified diff turning old into new, labelled with oldName and
+// newName. It returns an empty string when the contents are equal.
+func Diff(oldName, newName string, old, new []byte) string {
+	if string(old) == string(new) {
+		return ""
+	}
+	lines := editScript(splitLines(string(old)), splitLines(string(new)))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+	for start := 0; start < len(lines); {
+		// Find the next change and the extent of its hunk.
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		from := max(first-diffContext, start)
+		to := first
+		for i := first; i < len(lines); i++ {
+			if lines[i].op != ' ' {
+				to = i + 1
+				continue
+			}
+			if i-to >= 2*diffContext {
+				break
+			}
+		}
+		to = min(to+diffContext, len(lines))
+
+		oldStart, newStart := 1, 1
+		for _, line := range lines[:from] {
+			if line.op != '+' {
+				oldStart++
+			}
+			if line.op != '-' {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, line := range lines[from:to] {
+			if line.op != '+' {
+				oldCount++
+			}
+			if line.op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		for _, line := range lines[from:to] {
+			b.WriteByte(line.op)
+			b.WriteString(line.text)
+			b.WriteByte('\n')
+		}
+		start = to
+	}
+	return b.String()
+}
+
+// hunkRange formats a hunk header range, which starts one line earlier when
+// it covers no lines.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits content into lines without their terminators.
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// editScript computes a line edit script from a to b using their longest
+// common subsequence.
+func editScript(a, b []string) []diffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]diffLine, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, diffLine{'+', b[j]})
+	}
+	return lines
+}
@@ -0,0 +1,283 @@
+package shared
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/naodEthiop/lalibela-cli/internal/secrets"
+)
+
+const (
+	envFile = ".env"
+	// EnvExampleFile is the committed copy of .env that documents every
+	// variable, with secrets left blank.
+	EnvExampleFile = ".env.example"
+)
+
+// SetEnv sets key to value in the project's .env file, replacing an existing
+// assignment of key or appending one. The file is created when missing.
+func (t *Target) SetEnv(key, value string) error {
+	if t.dryRun {
+		return nil
+	}
+	if err := t.remember(envFile); err != nil {
+		return fmt.Errorf("reading %s: %w", envFile, err)
+	}
+	return SetEnvFile(t.Root, map[string]string{key: value})
+}
+
+// SetEnvFile sets each key of values in the .env file in projectRoot,
+// replacing existing assignments in place and appending the others in key
+// order. The file is created when missing.
+func SetEnvFile(projectRoot string, values map[string]string) error {
+	path := filepath.Join(projectRoot, envFile)
+	lines, err := readEnvLines(path)
+	if err != nil {
+		return err
+	}
+
+	set := make(map[string]bool, len(values))
+	for i, existing := range lines {
+		key := envKey(existing)
+		if value, ok := values[key]; ok && !set[key] {
+			lines[i] = key + "=" + value
+			set[key] = true
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if !set[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines = append(lines, key+"="+values[key])
+	}
+	return writeEnvLines(path, lines)
+}
+
+// EnvFileValues returns the assignments of the .env file in projectRoot, with
+// surrounding quotes removed. A missing file has no assignments.
+func EnvFileValues(projectRoot string) (map[string]string, error) {
+	lines, err := readEnvLines(filepath.Join(projectRoot, envFile))
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(lines))
+	for _, line := range lines {
+		key := envKey(line)
+		if key == "" {
+			continue
+		}
+		_, value, _ := strings.Cut(line, "=")
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// EnvVar is an environment variable read by a feature's generated code.
+type EnvVar struct {
+	Name        string `json:"name"`
+	Default     string `json:"default,omitempty"`
+	Description string `json:"description"`
+	// Secret marks values that must not be committed. They are left blank in
+	// .env.example.
+	Secret bool `json:"secret,omitempty"`
+	// Generate marks secrets lalibela fills with a random value in .env
+	// instead of Default, and that 'lalibela secrets rotate' regenerates.
+	// Credentials issued elsewhere, such as database passwords, are not
+	// generated.
+	Generate bool `json:"generate,omitempty"`
+}
+
+// MergeEnv adds vars to the project's .env and .env.example like
+// MergeEnvFiles, preserving both files and .gitignore for Rollback. A dry run
+// changes nothing.
+func (t *Target) MergeEnv(vars []EnvVar) ([]EnvVar, error) {
+	if t.dryRun || len(vars) == 0 {
+		return nil, nil
+	}
+	for _, name := range []string{envFile, EnvExampleFile, GitignoreFile} {
+		if err := t.remember(name); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+	}
+	return MergeEnvFiles(t.Root, vars)
+}
+
+// MergeEnvFiles appends the vars that are missing from the .env and
+// .env.example files in projectRoot, creating the files when needed. Values
+// already assigned are never changed, so merging is idempotent. .env gets
+// each var's default, or a random value for generated secrets, and is added to
+// .gitignore; .env.example gets the description as a comment and the default,
+// or a blank value for secrets. It returns the vars that were added to either
+// file.
+func MergeEnvFiles(projectRoot string, vars []EnvVar) ([]EnvVar, error) {
+	added := make(map[string]bool)
+	for _, name := range []string{envFile, EnvExampleFile} {
+		path := filepath.Join(projectRoot, name)
+		lines, err := readEnvLines(path)
+		if err != nil {
+			return nil, err
+		}
+		present := make(map[string]bool, len(lines))
+		for _, line := range lines {
+			present[envKey(line)] = true
+		}
+		changed := false
+		for _, envVar := range vars {
+			if present[envVar.Name] {
+				continue
+			}
+			present[envVar.Name] = true
+			if name == EnvExampleFile {
+				lines = append(lines, envExampleLines(envVar)...)
+			} else {
+				value, err := envValue(envVar)
+				if err != nil {
+					return nil, err
+				}
+				lines = append(lines, envVar.Name+"="+value)
+			}
+			added[envVar.Name] = true
+			changed = true
+		}
+		if changed {
+			if err := writeEnvLines(path, lines); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(added) > 0 {
+		if err := EnsureGitignored(projectRoot, envFile); err != nil {
+			return nil, err
+		}
+	}
+
+	var out []EnvVar
+	for _, envVar := range vars {
+		if added[envVar.Name] {
+			out = append(out, envVar)
+			delete(added, envVar.Name)
+		}
+	}
+	return out, nil
+}
+
+// RemoveEnvFiles removes the assignments of vars from the .env and
+// .env.example files in projectRoot, together with the description comments
+// MergeEnvFiles wrote for them. Missing files are ignored.
+func RemoveEnvFiles(projectRoot string, vars []EnvVar) error {
+	if len(vars) == 0 {
+		return nil
+	}
+	for _, name := range []string{envFile, EnvExampleFile} {
+		path := filepath.Join(projectRoot, name)
+		lines, err := readEnvLines(path)
+		if err != nil {
+			return err
+		}
+		if lines == nil {
+			continue
+		}
+		kept := make([]string, 0, len(lines))
+		for _, line := range lines {
+			removed := false
+			for _, envVar := range vars {
+				if envKey(line) != envVar.Name {
+					continue
+				}
+				if n := len(kept); n > 0 && envVar.Description != "" && kept[n-1] == "# "+envVar.Description {
+					kept = kept[:n-1]
+				}
+				removed = true
+				break
+			}
+			if !removed {
+				kept = append(kept, line)
+			}
+		}
+		if len(kept) != len(lines) {
+			if err := writeEnvLines(path, kept); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// envValue returns the .env value of envVar.
+func envValue(envVar EnvVar) (string, error) {
+	if envVar.Generate {
+		return secrets.Generate()
+	}
+	return envVar.Default, nil
+}
+
+// envExampleLines returns the .env.example entry for envVar.
+func envExampleLines(envVar EnvVar) []string {
+	value := envVar.Default
+	if envVar.Secret || envVar.Generate {
+		value = ""
+	}
+	line := envVar.Name + "=" + value
+	if envVar.Description == "" {
+		return []string{line}
+	}
+	return []string{"# " + envVar.Description, line}
+}
+
+// envKey returns the name assigned by an env file line, or "" for comments
+// and blank lines.
+func envKey(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return ""
+	}
+	name, _, ok := strings.Cut(trimmed, "=")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(name, "export "))
+}
+
+// readEnvLines returns the lines of the env file at path, or nil when it does
+// not exist or is empty.
+func readEnvLines(path string) ([]string, error) {
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filepath.Base(path), err)
+	}
+	if len(strings.TrimSpace(string(raw))) == 0 {
+		return nil, nil
+	}
+	return strings.Split(strings.TrimRight(string(raw), "\n"), "\n"), nil
+}
+
+// writeEnvLines writes lines to the env file at path. A new .env is readable
+// by its owner only, since it holds secrets.
+func writeEnvLines(path string, lines []string) error {
+	content := ""
+	if len(lines) > 0 {
+		content = strings.Join(lines, "\n") + "\n"
+	}
+	perm := os.FileMode(0o644)
+	if filepath.Base(path) == envFile {
+		perm = 0o600
+	}
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		return fmt.Errorf("writing %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
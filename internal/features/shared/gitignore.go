@@ -0,0 +1,46 @@
+package shared
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GitignoreFile is the project's root .gitignore.
+const GitignoreFile = ".gitignore"
+
+// EnsureGitignored appends the patterns missing from the .gitignore file in
+// projectRoot, creating the file when needed. A pattern counts as present
+// when a line equals it, so existing rules are never rewritten.
+func EnsureGitignored(projectRoot string, patterns ...string) error {
+	path := filepath.Join(projectRoot, GitignoreFile)
+	raw, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading %s: %w", GitignoreFile, err)
+	}
+	content := string(raw)
+	present := make(map[string]bool)
+	for _, line := range strings.Split(content, "\n") {
+		present[strings.TrimSpace(line)] = true
+	}
+
+	var missing []string
+	for _, pattern := range patterns {
+		if !present[pattern] {
+			missing = append(missing, pattern)
+			present[pattern] = true
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	content += strings.Join(missing, "\n") + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", GitignoreFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package shared
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LayoutFile is where a project's layout overrides are stored, relative to
+// the project root.
+const LayoutFile = ".lalibela/layout.json"
+
+// Role names a kind of code or asset a feature writes. A project's layout maps
+// each role to the directory the files are written to.
+type Role string
+
+const (
+	// RoleServer holds HTTP helpers such as health checks, error responses
+	// and graceful shutdown.
+	RoleServer Role = "server"
+	// RoleMiddleware holds HTTP middleware such as CORS, rate limiting and
+	// auth.
+	RoleMiddleware Role = "middleware"
+	// RoleStorage holds database and cache clients.
+	RoleStorage Role = "storage"
+	// RoleLogger holds the logger constructor.
+	RoleLogger Role = "logger"
+	// RoleConfig holds configuration loading.
+	RoleConfig Role = "config"
+	// RoleMigrations holds SQL migrations.
+	RoleMigrations Role = "migrations"
+	// RoleDeployments holds container and deployment files.
+	RoleDeployments Role = "deployments"
+	// RoleDocs holds API documentation.
+	RoleDocs Role = "docs"
+)
+
+// defaultLayout is where each role is written when the project does not
+// override it.
+var defaultLayout = map[Role]string{
+	RoleServer:      "internal/server",
+	RoleMiddleware:  "internal/server",
+	RoleStorage:     "internal/storage",
+	RoleLogger:      "internal/logger",
+	RoleConfig:      "internal/config",
+	RoleMigrations:  "db/migrations",
+	RoleDeployments: "deployments",
+	RoleDocs:        "docs/swagger",
+}
+
+// Layout maps roles to slash-separated directories relative to the project
+// root.
+type Layout map[Role]string
+
+// DefaultLayout returns the layout used when a project has no overrides.
+func DefaultLayout() Layout {
+	layout := make(Layout, len(defaultLayout))
+	for role, dir := range defaultLayout {
+		layout[role] = dir
+	}
+	return layout
+}
+
+// Roles returns every role, sorted by name.
+func Roles() []Role {
+	roles := make([]Role, 0, len(defaultLayout))
+	for role := range defaultLayout {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i] < roles[j] })
+	return roles
+}
+
+// LoadLayout reads the layout overrides in the project's LayoutFile, a JSON
+// object such as {"middleware": "internal/http/middleware"}, and returns them
+// merged over the defaults. A project without the file gets DefaultLayout.
+func LoadLayout(projectRoot string) (Layout, error) {
+	layout := DefaultLayout()
+	if projectRoot == "" {
+		return layout, nil
+	}
+	raw, err := os.ReadFile(filepath.Join(projectRoot, filepath.FromSlash(LayoutFile)))
+	if os.IsNotExist(err) {
+		return layout, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", LayoutFile, err)
+	}
+	var overrides map[string]string
+	if err := json.Unmarshal(raw, &overrides); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", LayoutFile, err)
+	}
+	for name, dir := range overrides {
+		role := Role(name)
+		if _, ok := defaultLayout[role]; !ok {
+			return nil, fmt.Errorf("%s: unknown role %q", LayoutFile, name)
+		}
+		cleaned := path.Clean(filepath.ToSlash(strings.TrimSpace(dir)))
+		if cleaned == "." || path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			return nil, fmt.Errorf("%s: %s must be a directory inside the project, got %q", LayoutFile, name, dir)
+		}
+		layout[role] = cleaned
+	}
+	return layout, nil
+}
+
+// Dir returns the directory for role.
+func (l Layout) Dir(role Role) string {
+	if dir, ok := l[role]; ok {
+		return dir
+	}
+	return defaultLayout[role]
+}
+
+// identifierUnsafe matches the characters dropped from a directory name to
+// form a package name.
+var identifierUnsafe = regexp.MustCompile(`[^a-z0-9_]`)
+
+// PackageName returns the Go package name for files in dir: its last element,
+// lowercased, without characters that are not valid in an identifier.
+func PackageName(dir string) string {
+	name := identifierUnsafe.ReplaceAllString(strings.ToLower(path.Base(dir)), "")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "pkg" + name
+	}
+	return name
+}
+
+// packageClause matches the package clause of a Go source file.
+var packageClause = regexp.MustCompile(`(?m)^package [A-Za-z_][A-Za-z0-9_]*$`)
+
+// SetPackage returns source with its package clause set to name.
+func SetPackage(source, name string) string {
+	return packageClause.ReplaceAllLiteralString(source, "package "+name)
+}
+
+// Path returns the project-relative path of file name written for role.
+func (t *Target) Path(role Role, name string) string {
+	return path.Join(t.Layout.Dir(role), name)
+}
+
+// Package returns the package name of the Go files written for role.
+func (t *Target) Package(role Role) string {
+	return PackageName(t.Layout.Dir(role))
+}
+
+// Import returns the import path of the package written for role, qualified
+// with the project's module when it is known.
+func (t *Target) Import(role Role) string {
+	if t.Module == "" {
+		return t.Layout.Dir(role)
+	}
+	return t.Module + "/" + t.Layout.Dir(role)
+}
+
+// Qualify rewrites references to role's default package in code, such as
+// "server.WriteHealth", to the package the project's layout uses.
+func (t *Target) Qualify(role Role, code string) string {
+	from, to := PackageName(defaultLayout[role]), t.Package(role)
+	if from == to {
+		return code
+	}
+	return regexp.MustCompile(`\b`+from+`\.`).ReplaceAllLiteralString(code, to+".")
+}
+
+// WriteGoFile writes a Go source file named name into role's directory, with
+// its package clause set to the directory's package. Conflicts are handled as
+// in WriteFile.
+func (t *Target) WriteGoFile(role Role, name, source string) error {
+	return t.WriteFile(t.Path(role, name), []byte(SetPackage(source, t.Package(role))))
+}
@@ -0,0 +1,150 @@
+package shared
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OptionType is the type of a feature option value.
+type OptionType string
+
+const (
+	OptionString OptionType = "string"
+	OptionInt    OptionType = "int"
+	OptionBool   OptionType = "bool"
+)
+
+// Option describes a setting a feature accepts at install time, passed on
+// the command line as --<Name> after the feature name.
+type Option struct {
+	Name        string
+	Type        OptionType
+	Default     string
+	Description string
+	// Validate checks a value that already parsed as Type. It may be nil.
+	Validate func(value string) error
+}
+
+// Options holds resolved option values keyed by option name.
+type Options map[string]string
+
+// String returns the value of the named option.
+func (o Options) String(name string) string { return o[name] }
+
+// Int returns the value of the named option as an int. Values are checked by
+// ResolveOptions, so unparsable values only occur for undeclared options and
+// yield 0.
+func (o Options) Int(name string) int {
+	value, _ := strconv.Atoi(o[name])
+	return value
+}
+
+// Bool returns the value of the named option as a bool.
+func (o Options) Bool(name string) bool {
+	value, _ := strconv.ParseBool(o[name])
+	return value
+}
+
+// ResolveOptions checks values against schema and fills in defaults for
+// options that were not given. Values for options missing from schema are an
+// error.
+func ResolveOptions(schema []Option, values map[string]string) (Options, error) {
+	known := make(map[string]Option, len(schema))
+	for _, option := range schema {
+		known[option.Name] = option
+	}
+	unknown := make([]string, 0)
+	for name := range values {
+		if _, ok := known[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown option(s): %s", strings.Join(unknown, ", "))
+	}
+
+	resolved := make(Options, len(schema))
+	for _, option := range schema {
+		value, ok := values[option.Name]
+		if !ok {
+			value = option.Default
+		}
+		value = strings.TrimSpace(value)
+		if err := option.Check(value); err != nil {
+			return nil, err
+		}
+		resolved[option.Name] = value
+	}
+	return resolved, nil
+}
+
+// Check reports whether value is valid for the option.
+func (o Option) Check(value string) error {
+	switch o.Type {
+	case OptionInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("option --%s: %q is not an integer", o.Name, value)
+		}
+	case OptionBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("option --%s: %q is not true or false", o.Name, value)
+		}
+	}
+	if o.Validate != nil {
+		if err := o.Validate(value); err != nil {
+			return fmt.Errorf("option --%s: %w", o.Name, err)
+		}
+	}
+	return nil
+}
+
+// PositiveInt validates that an int option is greater than zero.
+func PositiveInt(value string) error {
+	if n, _ := strconv.Atoi(value); n <= 0 {
+		return fmt.Errorf("must be greater than 0, got %s", value)
+	}
+	return nil
+}
+
+// NotEmpty validates that a string option has a value.
+func NotEmpty(value string) error {
+	if value == "" {
+		return fmt.Errorf("must not be empty")
+	}
+	return nil
+}
+
+// OriginList validates a comma-separated list of CORS origins: either "*" or
+// absolute http(s) URLs without a path.
+func OriginList(value string) error {
+	origins := SplitList(value)
+	if len(origins) == 0 {
+		return fmt.Errorf("must list at least one origin")
+	}
+	for _, origin := range origins {
+		if origin == "*" {
+			continue
+		}
+		parsed, err := url.Parse(origin)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || strings.Trim(parsed.Path, "/") != "" {
+			return fmt.Errorf("%q is not an origin like https://example.com", origin)
+		}
+	}
+	return nil
+}
+
+// SplitList splits a comma-separated option value, dropping empty entries.
+func SplitList(value string) []string {
+	parts := strings.Split(value, ",")
+	out := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,270 @@
+package shared
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WrittenFile is a project file written by a feature installer.
+type WrittenFile struct {
+	// Path is the slash-separated path relative to the project root.
+	Path string
+	// SHA256 is the hex-encoded SHA-256 of the content that was written.
+	SHA256 string
+}
+
+// Target is the project a feature installs into. It records every file the
+// installer writes so the engine can track which files a feature owns.
+type Target struct {
+	Root string
+	// Framework is the project's framework (gin, echo, fiber or nethttp),
+	// used by installers that render framework-specific code.
+	Framework string
+	// Options holds the feature's resolved install options.
+	Options Options
+
+	// Layout maps roles to the directories features write into. A nil
+	// Layout uses the defaults.
+	Layout Layout
+	// Module is the project's module path from go.mod, used to qualify
+	// imports of the project's own packages. It is empty when unknown.
+	Module string
+	// Feature is the name of the feature being installed, reported on
+	// conflicts.
+	Feature string
+	// Resolver settles conflicts with existing files. When nil, existing
+	// files are kept.
+	Resolver Resolver
+
+	written     []WrittenFile
+	conflicts   []ConflictOutcome
+	pending     []Conflict
+	edited      []string
+	manualSteps []string
+	dryRun      bool
+	// originals holds the prior state of existing project files the target
+	// edited, keyed by relative path, so Rollback can restore them.
+	originals map[string]original
+}
+
+type original struct {
+	content []byte
+	existed bool
+}
+
+// NewTarget returns a Target rooted at projectRoot for framework.
+func NewTarget(projectRoot, framework string) *Target {
+	return &Target{Root: projectRoot, Framework: framework}
+}
+
+// NewDryRunTarget returns a Target for framework that records the files an
+// installer would write without touching the filesystem. With a projectRoot,
+// files that would conflict with existing ones are reported by Conflicts.
+func NewDryRunTarget(projectRoot, framework string) *Target {
+	return &Target{Root: projectRoot, Framework: framework, dryRun: true}
+}
+
+// WriteFile writes content to relativePath. When the file already exists with
+// different content, the target's Resolver decides whether it is kept,
+// overwritten, or the content is written alongside it with NewFileSuffix;
+// without a Resolver the existing file is kept. Files that are written are
+// recorded on the target.
+func (t *Target) WriteFile(relativePath string, content []byte) error {
+	relativePath = filepath.ToSlash(filepath.Clean(relativePath))
+	if t.dryRun && t.Root == "" {
+		t.record(relativePath, content)
+		return nil
+	}
+
+	fullPath := filepath.Join(t.Root, filepath.FromSlash(relativePath))
+	existing, err := os.ReadFile(fullPath)
+	switch {
+	case os.IsNotExist(err):
+		return t.write(relativePath, content)
+	case err != nil:
+		return err
+	case bytes.Equal(existing, content):
+		return nil
+	}
+
+	conflict := Conflict{Feature: t.Feature, Path: relativePath, Existing: existing, Proposed: content}
+	if t.dryRun {
+		t.pending = append(t.pending, conflict)
+		return nil
+	}
+	resolution := ResolveKeep
+	if t.Resolver != nil {
+		if resolution, err = t.Resolver(conflict); err != nil {
+			return err
+		}
+	}
+	outcome := ConflictOutcome{Path: relativePath, Resolution: resolution}
+	switch resolution {
+	case ResolveKeep:
+	case ResolveOverwrite:
+		if err := t.remember(relativePath); err != nil {
+			return err
+		}
+		if err := t.write(relativePath, content); err != nil {
+			return err
+		}
+	case ResolveWriteNew:
+		outcome.NewPath = relativePath + NewFileSuffix
+		if err := t.remember(outcome.NewPath); err != nil {
+			return err
+		}
+		if err := t.write(outcome.NewPath, content); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown conflict resolution %q for %s", resolution, relativePath)
+	}
+	t.conflicts = append(t.conflicts, outcome)
+	return nil
+}
+
+// write writes content to relativePath and records it, unless the target is
+// a dry run.
+func (t *Target) write(relativePath string, content []byte) error {
+	if !t.dryRun {
+		fullPath := filepath.Join(t.Root, filepath.FromSlash(relativePath))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(fullPath, content, 0o644); err != nil {
+			return err
+		}
+	}
+	t.record(relativePath, content)
+	return nil
+}
+
+// Conflicts returns how conflicting files were settled, in write order.
+func (t *Target) Conflicts() []ConflictOutcome {
+	out := make([]ConflictOutcome, len(t.conflicts))
+	copy(out, t.conflicts)
+	return out
+}
+
+// PendingConflicts returns the conflicts a dry run found.
+func (t *Target) PendingConflicts() []Conflict {
+	out := make([]Conflict, len(t.pending))
+	copy(out, t.pending)
+	return out
+}
+
+func (t *Target) record(relativePath string, content []byte) {
+	t.written = append(t.written, WrittenFile{
+		Path:   relativePath,
+		SHA256: HashContent(content),
+	})
+}
+
+// Written returns the files written through the target, in write order.
+func (t *Target) Written() []WrittenFile {
+	out := make([]WrittenFile, len(t.written))
+	copy(out, t.written)
+	return out
+}
+
+// DryRun reports whether the target records writes without touching the
+// filesystem.
+func (t *Target) DryRun() bool { return t.dryRun }
+
+// Edit declares that the installer is about to change the existing project
+// file relativePath by other means than WriteFile, such as an external
+// program. The file is preserved for Rollback and reported by Edited.
+func (t *Target) Edit(relativePath string) error {
+	cleaned := filepath.ToSlash(filepath.Clean(relativePath))
+	if err := t.remember(cleaned); err != nil {
+		return err
+	}
+	for _, path := range t.edited {
+		if path == cleaned {
+			return nil
+		}
+	}
+	t.edited = append(t.edited, cleaned)
+	return nil
+}
+
+// Edited returns the files declared with Edit, in order.
+func (t *Target) Edited() []string {
+	out := make([]string, len(t.edited))
+	copy(out, t.edited)
+	return out
+}
+
+// AddManualStep records a step the user has to complete by hand.
+func (t *Target) AddManualStep(step string) {
+	t.manualSteps = append(t.manualSteps, step)
+}
+
+// ManualSteps returns the steps recorded with AddManualStep.
+func (t *Target) ManualSteps() []string {
+	out := make([]string, len(t.manualSteps))
+	copy(out, t.manualSteps)
+	return out
+}
+
+// Rollback undoes the target's changes: files it wrote are deleted and files
+// it edited are restored to their content before the first edit.
+func (t *Target) Rollback() error {
+	var errs []error
+	for i := len(t.written) - 1; i >= 0; i-- {
+		path := filepath.Join(t.Root, filepath.FromSlash(t.written[i].Path))
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	for relativePath, prior := range t.originals {
+		path := filepath.Join(t.Root, filepath.FromSlash(relativePath))
+		var err error
+		if prior.existed {
+			if err = os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+				err = os.WriteFile(path, prior.content, 0o644)
+			}
+		} else {
+			err = os.Remove(path)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Preserve records the current content of relativePath, or that it does not
+// exist, so Rollback restores it even when the file is changed outside the
+// target.
+func (t *Target) Preserve(relativePath string) error {
+	return t.remember(filepath.ToSlash(filepath.Clean(relativePath)))
+}
+
+// remember records the current content of relativePath before the target
+// edits it for the first time.
+func (t *Target) remember(relativePath string) error {
+	if _, ok := t.originals[relativePath]; ok {
+		return nil
+	}
+	raw, err := os.ReadFile(filepath.Join(t.Root, filepath.FromSlash(relativePath)))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if t.originals == nil {
+		t.originals = make(map[string]original)
+	}
+	t.originals[relativePath] = original{content: raw, existed: err == nil}
+	return nil
+}
+
+// HashContent returns the hex-encoded SHA-256 of content.
+func HashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
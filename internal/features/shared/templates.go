@@ -0,0 +1,38 @@
+package shared
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// MustReadTemplate returns the content of the template name in fsys. Feature
+// templates are embedded in the binary, so a missing one is a build mistake
+// and panics when the feature package is initialised.
+func MustReadTemplate(fsys fs.FS, name string) string {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		panic(fmt.Sprintf("reading feature template: %v", err))
+	}
+	return string(data)
+}
+
+// MustReadVariants returns the templates in dir of fsys keyed by the part of
+// their file name before the first dot, so dir/gin.go.tmpl is the "gin"
+// variant. Like MustReadTemplate, it panics when dir cannot be read.
+func MustReadVariants(fsys fs.FS, dir string) map[string]string {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		panic(fmt.Sprintf("reading feature templates: %v", err))
+	}
+	variants := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		key, _, _ := strings.Cut(entry.Name(), ".")
+		variants[key] = MustReadTemplate(fsys, path.Join(dir, entry.Name()))
+	}
+	return variants
+}
@@ -0,0 +1,10 @@
+package shared
+
+// Variant returns the entry of variants for framework. Frameworks without a
+// dedicated entry use the net/http variant.
+func Variant(variants map[string]string, framework string) string {
+	if source, ok := variants[framework]; ok {
+		return source
+	}
+	return variants["nethttp"]
+}
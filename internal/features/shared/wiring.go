@@ -0,0 +1,73 @@
+package shared
+
+// Wiring describes how a feature registers itself in a generated project's
+// main.go and internal/routes/routes.go.
+type Wiring struct {
+	// Middleware is a Go expression for the feature's middleware. For gin,
+	// echo and fiber it is registered with app.Use before the routes; for
+	// net/http it is a function that wraps the root http.Handler.
+	Middleware string
+	// GracefulShutdown replaces the blocking Serve call in main.go with a
+	// goroutine and a call to Shutdown.
+	GracefulShutdown bool
+	// Shutdown is the qualified name of the function main.go calls to wait
+	// for a signal and shut down. It defaults to server.WaitForShutdown.
+	Shutdown string
+	// Timeouts sets the read, write and idle timeouts of the server main.go
+	// starts. Gin's RunListener, which takes no timeouts, is replaced with an
+	// http.Server.
+	Timeouts *Timeouts
+	// Settings loads the project's configuration in main.go and takes the
+	// server settings from it.
+	Settings *Settings
+	// Imports lists the import paths main.go needs for Middleware,
+	// GracefulShutdown, Timeouts or Settings. Paths starting with "internal/"
+	// are resolved against the project's module.
+	Imports []string
+	// Route replaces the handler of an existing route in routes.go.
+	Route *Route
+	// Field adds a field to a struct type declared in the project, such as
+	// the config feature's Config.
+	Field *Field
+}
+
+// Settings describes how main.go takes its settings from the project's
+// configuration.
+type Settings struct {
+	// Load is the statement that loads the configuration, for example
+	// "cfg := config.MustLoad()". It runs before the first of the settings
+	// Values replaces.
+	Load string
+	// Values maps variables main.go declares, such as port and tlsSettings,
+	// to the Go expressions that replace their values.
+	Values map[string]string
+}
+
+// Field describes a struct field added by a feature.
+type Field struct {
+	// File is the slash-separated path of the file declaring Struct.
+	File string
+	// Struct is the name of the struct type.
+	Struct string
+	// Decl is the field declaration, including its tag.
+	Decl string
+}
+
+// Timeouts holds Go expressions for server timeouts, for example
+// "15 * time.Second". Empty fields are left unset.
+type Timeouts struct {
+	Read  string
+	Write string
+	Idle  string
+}
+
+// Route describes a route handler registered by a feature.
+type Route struct {
+	// Path is the route path, for example "/health".
+	Path string
+	// Handler is a Go expression for the framework's handler function.
+	Handler string
+	// Imports lists the import paths routes.go needs for Handler, resolved
+	// like Wiring.Imports.
+	Imports []string
+}
@@ -1,2 +0,0 @@
-// Package swagger provides the "swagger" scaffold feature installer.
-package swagger
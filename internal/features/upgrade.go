@@ -0,0 +1,447 @@
+package features
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+	"github.com/naodEthiop/lalibela-cli/internal/features/wiring"
+)
+
+// unversioned is the version assumed for features installed before versions
+// were recorded, and for features that do not declare one.
+const unversioned = "0.0.0"
+
+// OutdatedFeature is an installed feature whose installer has a newer version
+// than the one recorded in the project.
+type OutdatedFeature struct {
+	Name      string `json:"name"`
+	Installed string `json:"installed"`
+	Available string `json:"available"`
+}
+
+// FileDiff is a unified diff between a project file and a feature's new
+// version of it.
+type FileDiff struct {
+	Path string `json:"path"`
+	Diff string `json:"diff"`
+}
+
+// UpgradeResult describes the outcome of upgrading a feature.
+type UpgradeResult struct {
+	Name string
+	From string
+	To   string
+	// UpToDate reports that the installed version is already current and
+	// nothing was changed.
+	UpToDate bool
+	// Updated lists files that were rewritten with the new version, including
+	// files the new version adds.
+	Updated []string
+	// Removed lists files the new version no longer writes that were deleted
+	// because they had not been modified.
+	Removed []string
+	// Diffs holds the changes the new version would make to files that were
+	// modified since install. Those files are settled by the resolver.
+	Diffs     []FileDiff
+	Conflicts []shared.ConflictOutcome
+	// Added lists features the new version requires that were installed
+	// with it.
+	Added []string
+	// Wired lists the files the new version's wiring edited.
+	Wired []string
+	// ManualSteps describes wiring the new version needs that could not be
+	// applied automatically.
+	ManualSteps []string
+	// StaleWiring describes wiring of the previous version that could not be
+	// reverted because the edited code changed since install.
+	StaleWiring []string
+}
+
+// featureVersion returns the version feature declares, or unversioned.
+func featureVersion(feature Feature) string {
+	if versioned, ok := feature.(Versioned); ok {
+		return versioned.Version()
+	}
+	return unversioned
+}
+
+// compareVersions compares dotted numeric versions such as "1.2.0", returning
+// -1, 0 or 1. A leading "v" and missing components are allowed.
+func compareVersions(a, b string) int {
+	left := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	right := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(left) || i < len(right); i++ {
+		var x, y int
+		if i < len(left) {
+			x, _ = strconv.Atoi(left[i])
+		}
+		if i < len(right) {
+			y, _ = strconv.Atoi(right[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// recordedVersion returns the version a feature was installed with.
+func recordedVersion(state State, name string) string {
+	if version := state.Features[name].Version; version != "" {
+		return version
+	}
+	return unversioned
+}
+
+// Outdated lists the installed features whose installers are newer than the
+// versions recorded in the project at projectRoot, sorted by name.
+func Outdated(projectRoot string) ([]OutdatedFeature, error) {
+	state, err := loadState(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	outdated := make([]OutdatedFeature, 0)
+	for _, name := range state.Installed {
+		feature, err := lookupFeature(name)
+		if err != nil {
+			continue
+		}
+		installed, available := recordedVersion(state, name), featureVersion(feature)
+		if compareVersions(installed, available) < 0 {
+			outdated = append(outdated, OutdatedFeature{Name: name, Installed: installed, Available: available})
+		}
+	}
+	sort.Slice(outdated, func(i, j int) bool { return outdated[i].Name < outdated[j].Name })
+	return outdated, nil
+}
+
+// UpgradeFeatures re-renders installed features whose recorded version is
+// older than their installer's, with the option values recorded at install.
+// Options the new version adds take their defaults.
+//
+// The files a feature owns are compared with the hashes recorded at install.
+// Files that were not modified are rewritten with the new version, or deleted
+// when the new version no longer writes them. Files that were modified get a
+// diff in the result and are passed to resolve, which keeps them when nil.
+// The previous version's wiring is reverted and the new version's applied,
+// and features the new version requires are installed first. The upgrade is
+// transactional like InstallFeatures.
+func UpgradeFeatures(projectRoot string, featureNames []string, resolve shared.Resolver, runner CommandRunner) ([]UpgradeResult, error) {
+	state, err := loadState(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, featureName := range featureNames {
+		name := strings.ToLower(strings.TrimSpace(featureName))
+		if _, err := lookupFeature(name); err != nil {
+			problems = append(problems, unknownFeature(featureName, err))
+		} else if !contains(state.Installed, name) {
+			problems = append(problems, fmt.Sprintf("feature %q is not installed", name))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+
+	settings, err := loadProjectSettings(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	requiredBy := make(map[string]string)
+	var missing []FeatureRequest
+	for _, featureName := range featureNames {
+		name := strings.ToLower(strings.TrimSpace(featureName))
+		feature, _ := lookupFeature(name)
+		if compareVersions(recordedVersion(state, name), featureVersion(feature)) >= 0 {
+			continue
+		}
+		for _, dependency := range featureRequires(name) {
+			if _, ok := requiredBy[dependency]; !ok && !contains(state.Installed, dependency) {
+				requiredBy[dependency] = name
+				missing = append(missing, FeatureRequest{Name: dependency})
+			}
+		}
+	}
+	steps, err := planInstall(state, missing, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range steps {
+		if step.result.AlreadyPresent {
+			continue
+		}
+		if err := stageInstall(settings.apply(shared.NewDryRunTarget("", state.Framework)), step); err != nil {
+			return nil, err
+		}
+	}
+
+	journal := shared.NewTarget(projectRoot, state.Framework)
+	for _, path := range transactionFiles {
+		if err := journal.Preserve(path); err != nil {
+			return nil, fmt.Errorf("preparing feature upgrade: %w", err)
+		}
+	}
+	applied := []appliedInstall{{target: journal}}
+	abort := func(err error) error {
+		if rollbackErr := rollbackInstalls(projectRoot, applied); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback incomplete: %v)", err, rollbackErr)
+		}
+		return fmt.Errorf("%w (all changes were rolled back)", err)
+	}
+
+	parents := make(map[string]string, len(steps))
+	for _, step := range steps {
+		parents[step.result.Name] = step.result.RequiredBy
+	}
+	added := make(map[string][]string)
+	for i := range steps {
+		step := &steps[i]
+		if step.result.AlreadyPresent {
+			continue
+		}
+		target := settings.apply(shared.NewTarget(projectRoot, state.Framework))
+		target.Feature = step.result.Name
+		target.Resolver = resolve
+		target.Options = step.options
+		record, err := installOne(target, step)
+		applied = append(applied, appliedInstall{target: target, patches: record.Wiring})
+		if err != nil {
+			return nil, abort(fmt.Errorf("installing feature %q: %w", step.result.Name, err))
+		}
+		state.Installed = append(state.Installed, step.result.Name)
+		if state.Features == nil {
+			state.Features = make(map[string]FeatureRecord)
+		}
+		state.Features[step.result.Name] = record
+		// Requirements of requirements are reported on the upgraded feature
+		// that led to them.
+		dependent := step.result.Name
+		for requiredBy[dependent] == "" && parents[dependent] != "" {
+			dependent = parents[dependent]
+		}
+		added[requiredBy[dependent]] = append(added[requiredBy[dependent]], step.result.Name)
+	}
+	sort.Strings(state.Installed)
+
+	results := make([]UpgradeResult, 0, len(featureNames))
+	changed := false
+	for _, featureName := range featureNames {
+		name := strings.ToLower(strings.TrimSpace(featureName))
+		feature, _ := lookupFeature(name)
+		result := UpgradeResult{Name: name, From: recordedVersion(state, name), To: featureVersion(feature), Added: added[name]}
+		if compareVersions(result.From, result.To) >= 0 {
+			result.UpToDate = true
+			results = append(results, result)
+			continue
+		}
+
+		target := settings.apply(shared.NewTarget(projectRoot, state.Framework))
+		applied = append(applied, appliedInstall{target: target})
+		record, err := upgradeOne(target, journal, feature, state.Features[name], resolve, &result)
+		if err != nil {
+			return nil, abort(fmt.Errorf("upgrading feature %q: %w", name, err))
+		}
+		if state.Features == nil {
+			state.Features = make(map[string]FeatureRecord)
+		}
+		state.Features[name] = record
+		results = append(results, result)
+		changed = true
+	}
+	if !changed {
+		return results, nil
+	}
+
+	if err := saveState(projectRoot, state); err != nil {
+		return nil, abort(err)
+	}
+	if runner != nil {
+		if err := runner(projectRoot, "go", "mod", "tidy"); err != nil {
+			return nil, abort(fmt.Errorf("go mod tidy after feature upgrade: %w", err))
+		}
+	}
+	return results, nil
+}
+
+// upgradeOne installs the current version of feature over the files recorded
+// in previous and returns the updated record. Files deleted by the upgrade are
+// preserved in journal first.
+func upgradeOne(target, journal *shared.Target, feature Feature, previous FeatureRecord, resolve shared.Resolver, result *UpgradeResult) (FeatureRecord, error) {
+	var schema []shared.Option
+	if configurable, ok := feature.(Configurable); ok {
+		schema = configurable.Options()
+	}
+	values := make(map[string]string, len(previous.Options))
+	for name, value := range previous.Options {
+		if optionDeclared(schema, name) {
+			values[name] = value
+		}
+	}
+	options, err := shared.ResolveOptions(schema, values)
+	if err != nil {
+		return previous, err
+	}
+
+	owned := make(map[string]OwnedFile, len(previous.Files))
+	for _, file := range previous.Files {
+		owned[file.Path] = file
+	}
+	untouched := make(map[string]bool, len(owned))
+	for path, file := range owned {
+		content, err := os.ReadFile(filepath.Join(target.Root, filepath.FromSlash(path)))
+		if err == nil && shared.HashContent(content) == file.SHA256 {
+			untouched[path] = true
+		}
+	}
+
+	target.Feature = feature.Name()
+	target.Options = options
+	target.Resolver = func(conflict shared.Conflict) (shared.Resolution, error) {
+		if untouched[conflict.Path] {
+			return shared.ResolveOverwrite, nil
+		}
+		if _, ok := owned[conflict.Path]; ok {
+			result.Diffs = append(result.Diffs, FileDiff{
+				Path: conflict.Path,
+				Diff: shared.Diff(conflict.Path, conflict.Path+" ("+result.To+")", conflict.Existing, conflict.Proposed),
+			})
+		}
+		if resolve == nil {
+			return shared.ResolveKeep, nil
+		}
+		return resolve(conflict)
+	}
+	if err := feature.Install(target); err != nil {
+		return previous, err
+	}
+
+	record := FeatureRecord{Files: []OwnedFile{}, Options: previous.Options, Version: result.To, Edited: previous.Edited, Env: previous.Env}
+	if declarer, ok := feature.(EnvDeclarer); ok {
+		added, err := target.MergeEnv(declarer.EnvVars())
+		if err != nil {
+			return previous, err
+		}
+		record.Env = append(record.Env, added...)
+	}
+	if len(options) > 0 {
+		record.Options = options
+	}
+	written := make(map[string]bool)
+	for _, file := range target.Written() {
+		written[file.Path] = true
+		record.Files = append(record.Files, OwnedFile{Path: file.Path, SHA256: file.SHA256})
+		result.Updated = append(result.Updated, file.Path)
+	}
+	for _, outcome := range target.Conflicts() {
+		if untouched[outcome.Path] {
+			continue
+		}
+		result.Conflicts = append(result.Conflicts, outcome)
+	}
+	result.Updated = withoutConflicts(result.Updated, result.Conflicts)
+
+	// Files rendered identically, or kept after a conflict, stay owned with
+	// their recorded hash. Untouched files the new version dropped are deleted.
+	planned, err := plannedFiles(feature, target.Framework, options, target.Layout)
+	if err != nil {
+		return previous, err
+	}
+	for _, file := range previous.Files {
+		if written[file.Path] {
+			continue
+		}
+		if contains(planned, file.Path) || !untouched[file.Path] {
+			record.Files = append(record.Files, file)
+			continue
+		}
+		if err := journal.Preserve(file.Path); err != nil {
+			return previous, err
+		}
+		fullPath := filepath.Join(target.Root, filepath.FromSlash(file.Path))
+		if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+			return previous, err
+		}
+		pruneEmptyDirs(target.Root, filepath.Dir(fullPath))
+		result.Removed = append(result.Removed, file.Path)
+	}
+	sort.Slice(record.Files, func(i, j int) bool { return record.Files[i].Path < record.Files[j].Path })
+
+	if err := rewire(target, feature, previous, &record, result); err != nil {
+		return previous, err
+	}
+	return record, nil
+}
+
+// rewire replaces the wiring recorded in previous with the wiring of the
+// feature's new version. The files involved are preserved in target first.
+func rewire(target *shared.Target, feature Feature, previous FeatureRecord, record *FeatureRecord, result *UpgradeResult) error {
+	files := make([]string, 0, len(previous.Wiring)+3)
+	for _, patch := range previous.Wiring {
+		files = append(files, patch.File)
+	}
+	if wirer, ok := feature.(Wirer); ok {
+		if spec, ok := wirer.Wiring(target); ok {
+			files = append(files, wiring.Files(spec)...)
+		}
+	}
+	for _, file := range files {
+		if err := target.Preserve(file); err != nil {
+			return err
+		}
+	}
+
+	stale, err := wiring.Revert(target.Root, previous.Wiring)
+	if err != nil {
+		return fmt.Errorf("reverting wiring: %w", err)
+	}
+	for _, patch := range stale {
+		result.StaleWiring = append(result.StaleWiring, fmt.Sprintf("%s: %s", patch.File, firstLine(patch.After)))
+	}
+	var wired InstallResult
+	if err := wireFeature(target, feature, record, &wired); err != nil {
+		return err
+	}
+	result.Wired = wired.Wired
+	result.ManualSteps = wired.ManualSteps
+	return nil
+}
+
+// optionDeclared reports whether schema has an option called name.
+func optionDeclared(schema []shared.Option, name string) bool {
+	for _, option := range schema {
+		if option.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutConflicts returns paths without the files written as the new version
+// of a conflicting file, which are reported with the conflict instead.
+func withoutConflicts(paths []string, conflicts []shared.ConflictOutcome) []string {
+	out := paths[:0]
+	for _, path := range paths {
+		skip := false
+		for _, conflict := range conflicts {
+			if path == conflict.Path || path == conflict.NewPath {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			out = append(out, path)
+		}
+	}
+	return out
+}
@@ -0,0 +1,228 @@
+package features
+
+import (
+	"bytes"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	lalibelacli "github.com/naodEthiop/lalibela-cli"
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+// downgradeRecord rewrites the state of an installed feature as if an older
+// installer version had written it.
+func downgradeRecord(t *testing.T, root, name string, edit func(*FeatureRecord)) {
+	t.Helper()
+	state, err := loadState(root)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	record := state.Features[name]
+	record.Version = "0.9.0"
+	edit(&record)
+	state.Features[name] = record
+	if err := saveState(root, state); err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+}
+
+func TestOutdatedListsOlderInstalls(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if _, err := InstallFeatures(root, "gin", []FeatureRequest{{Name: "config"}, {Name: "docker"}}, nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	outdated, err := Outdated(root)
+	if err != nil {
+		t.Fatalf("outdated: %v", err)
+	}
+	if len(outdated) != 0 {
+		t.Fatalf("expected fresh installs to be current, got %+v", outdated)
+	}
+
+	downgradeRecord(t, root, "docker", func(*FeatureRecord) {})
+	outdated, err = Outdated(root)
+	if err != nil {
+		t.Fatalf("outdated: %v", err)
+	}
+	if len(outdated) != 1 || outdated[0].Name != "docker" || outdated[0].Installed != "0.9.0" || outdated[0].Available != featureVersion(Registry["docker"]) {
+		t.Fatalf("expected docker to be outdated, got %+v", outdated)
+	}
+}
+
+func TestUpgradeFeaturesUpdatesUntouchedFilesAndDiffsModifiedOnes(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if _, err := InstallFeature(root, "gin", "postgres", nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	source := "internal/storage/postgres.go"
+	migration := "db/migrations/0001_init.sql"
+	legacy := "internal/storage/legacy.go"
+	oldSource := []byte("package storage\n\n// written by an older installer\n")
+	legacyContent := []byte("package storage\n")
+	for path, content := range map[string][]byte{source: oldSource, legacy: legacyContent} {
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(path)), content, 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	downgradeRecord(t, root, "postgres", func(record *FeatureRecord) {
+		for i := range record.Files {
+			if record.Files[i].Path == source {
+				record.Files[i].SHA256 = shared.HashContent(oldSource)
+			}
+		}
+		record.Files = append(record.Files, OwnedFile{Path: legacy, SHA256: shared.HashContent(legacyContent)})
+	})
+	// The user edited the migration after install.
+	migrationPath := filepath.Join(root, filepath.FromSlash(migration))
+	if err := os.WriteFile(migrationPath, []byte("-- my schema\n"), 0o644); err != nil {
+		t.Fatalf("write migration: %v", err)
+	}
+
+	results, err := UpgradeFeatures(root, []string{"postgres"}, nil, nil)
+	if err != nil {
+		t.Fatalf("upgrade: %v", err)
+	}
+	result := results[0]
+	if result.From != "0.9.0" || result.To != featureVersion(Registry["postgres"]) {
+		t.Fatalf("unexpected versions %+v", result)
+	}
+	if len(result.Updated) != 1 || result.Updated[0] != source {
+		t.Fatalf("expected %s to be updated, got %v", source, result.Updated)
+	}
+	if content, _ := os.ReadFile(filepath.Join(root, filepath.FromSlash(source))); string(content) == string(oldSource) {
+		t.Fatal("expected the untouched source to be rewritten")
+	}
+	if len(result.Removed) != 1 || result.Removed[0] != legacy {
+		t.Fatalf("expected %s to be removed, got %v", legacy, result.Removed)
+	}
+	if len(result.Diffs) != 1 || result.Diffs[0].Path != migration || !strings.Contains(result.Diffs[0].Diff, "-- my schema") {
+		t.Fatalf("expected a diff for the modified migration, got %+v", result.Diffs)
+	}
+	if content, _ := os.ReadFile(migrationPath); string(content) != "-- my schema\n" {
+		t.Fatalf("expected the modified migration to be kept, got %q", content)
+	}
+
+	outdated, err := Outdated(root)
+	if err != nil {
+		t.Fatalf("outdated: %v", err)
+	}
+	if len(outdated) != 0 {
+		t.Fatalf("expected postgres to be current after upgrade, got %+v", outdated)
+	}
+	results, err = UpgradeFeatures(root, []string{"postgres"}, nil, nil)
+	if err != nil || !results[0].UpToDate {
+		t.Fatalf("expected a second upgrade to be a no-op, got %+v, %v", results, err)
+	}
+}
+
+// writeScaffoldMain renders the scaffold's main.go for framework into root,
+// so features can be wired into it.
+func writeScaffoldMain(t *testing.T, root, framework string) {
+	t.Helper()
+	tmpl, err := template.ParseFS(lalibelacli.EmbeddedTemplates, "templates/main.go.tmpl")
+	if err != nil {
+		t.Fatalf("parse main.go template: %v", err)
+	}
+	var buf bytes.Buffer
+	data := map[string]string{"ModuleName": "demo", "ProjectName": "demo", "Framework": framework, "CLIVersion": "dev"}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatalf("render main.go: %v", err)
+	}
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatalf("format main.go: %v", err)
+	}
+	writeProjectFile(t, root, "main.go", string(formatted))
+	writeProjectFile(t, root, "go.mod", "module demo\n\ngo 1.25\n")
+}
+
+func TestUpgradeFeaturesRewiresAndInstallsRequirements(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeScaffoldMain(t, root, "gin")
+	if _, err := InstallFeatures(root, "gin", []FeatureRequest{{Name: "redis"}, {Name: "rate-limit"}}, nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	// An older redis neither required config nor wired a Config field.
+	if _, err := RemoveFeatures(root, []string{"config"}, true, nil); err != nil {
+		t.Fatalf("remove config: %v", err)
+	}
+	downgradeRecord(t, root, "redis", func(record *FeatureRecord) { record.Wiring = nil })
+	downgradeRecord(t, root, "rate-limit", func(*FeatureRecord) {})
+
+	results, err := UpgradeFeatures(root, []string{"redis", "rate-limit"}, nil, nil)
+	if err != nil {
+		t.Fatalf("upgrade: %v", err)
+	}
+	redis := results[0]
+	if len(redis.Added) != 1 || redis.Added[0] != "config" || !contains(redis.Wired, "internal/config/config.go") {
+		t.Fatalf("expected config to be installed and Config wired for redis, got %+v", redis)
+	}
+	if len(results[1].Added) != 0 || len(results[1].ManualSteps) != 0 {
+		t.Fatalf("expected rate-limit to be rewired without changes, got %+v", results[1])
+	}
+
+	main := readFile(t, filepath.Join(root, "main.go"))
+	if !strings.Contains(main, "cfg := config.MustLoad()") || strings.Count(main, "RateLimitMiddleware(") != 1 {
+		t.Fatalf("expected main.go to load the config and register rate limiting once:\n%s", main)
+	}
+	if config := readFile(t, filepath.Join(root, "internal", "config", "config.go")); !strings.Contains(config, "RedisConfig") || !strings.Contains(config, "RateLimitConfig") {
+		t.Fatalf("expected the Config fields of both features:\n%s", config)
+	}
+	state, err := loadState(root)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if !contains(state.Installed, "config") || len(state.Features["redis"].Wiring) != 1 {
+		t.Fatalf("expected config installed and the redis wiring recorded, got %+v", state)
+	}
+}
+
+func TestUpgradeFeaturesAddsNewEnvVars(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if _, err := InstallFeature(root, "gin", "logger", nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	// Logger 1.0.0 did not declare LOG_LEVEL.
+	if err := shared.RemoveEnvFiles(root, []shared.EnvVar{{Name: "LOG_LEVEL"}}); err != nil {
+		t.Fatalf("remove LOG_LEVEL: %v", err)
+	}
+	downgradeRecord(t, root, "logger", func(record *FeatureRecord) {
+		record.Version = "1.0.0"
+		record.Env = nil
+	})
+
+	outdated, err := Outdated(root)
+	if err != nil {
+		t.Fatalf("outdated: %v", err)
+	}
+	if len(outdated) != 1 || outdated[0].Name != "logger" {
+		t.Fatalf("expected logger 1.0.0 to be outdated, got %+v", outdated)
+	}
+	if _, err := UpgradeFeatures(root, []string{"logger"}, nil, nil); err != nil {
+		t.Fatalf("upgrade: %v", err)
+	}
+	if env := readFile(t, filepath.Join(root, ".env")); !strings.Contains(env, "LOG_LEVEL=info\n") {
+		t.Fatalf("expected the upgrade to add LOG_LEVEL:\n%s", env)
+	}
+}
+
+func TestUpgradeFeaturesRejectsFeaturesNotInstalled(t *testing.T) {
+	t.Parallel()
+
+	_, err := UpgradeFeatures(t.TempDir(), []string{"redis", "nope"}, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), `"redis" is not installed`) || !strings.Contains(err.Error(), `"nope"`) {
+		t.Fatalf("expected validation errors, got %v", err)
+	}
+}
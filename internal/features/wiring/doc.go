@@ -0,0 +1,4 @@
+// Package wiring registers installed features in a generated project by
+// rewriting main.go and internal/routes/routes.go, and reverts those edits
+// when a feature is removed.
+package wiring
@@ -0,0 +1,334 @@
+package wiring
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// source is a parsed Go file that is edited by splicing text at AST-derived
+// line offsets, so unrelated code keeps its original formatting.
+type source struct {
+	path   string
+	module string
+	src    []byte
+	fset   *token.FileSet
+	file   *ast.File
+}
+
+func loadSource(path string) (*source, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &source{path: path}
+	if err := s.reset(raw); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *source) reset(src []byte) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, s.path, src, parser.ParseComments)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", s.path, err)
+	}
+	s.src, s.fset, s.file = src, fset, file
+	return nil
+}
+
+// lineRange returns the byte offsets covering the full lines spanned by node.
+func (s *source) lineRange(node ast.Node) (int, int) {
+	start := s.fset.Position(node.Pos()).Offset
+	end := s.fset.Position(node.End()).Offset
+	for start > 0 && s.src[start-1] != '\n' {
+		start--
+	}
+	for end < len(s.src) && s.src[end] != '\n' {
+		end++
+	}
+	if end < len(s.src) {
+		end++
+	}
+	return start, end
+}
+
+// splice replaces src[start:end] with text and re-parses the file.
+func (s *source) splice(start, end int, text string) error {
+	var buf bytes.Buffer
+	buf.Write(s.src[:start])
+	buf.WriteString(text)
+	buf.Write(s.src[end:])
+	return s.reset(buf.Bytes())
+}
+
+// replaceNode replaces the lines spanned by node with lines, returning the
+// trimmed original lines.
+func (s *source) replaceNode(node ast.Node, lines []string) (string, error) {
+	start, end := s.lineRange(node)
+	before := trimLines(string(s.src[start:end]))
+	return before, s.splice(start, end, indentLines(lines))
+}
+
+// insertBefore inserts lines above the lines spanned by node.
+func (s *source) insertBefore(node ast.Node, lines []string) error {
+	start, _ := s.lineRange(node)
+	return s.splice(start, start, indentLines(lines))
+}
+
+// insertAfter inserts lines below the lines spanned by node.
+func (s *source) insertAfter(node ast.Node, lines []string) error {
+	_, end := s.lineRange(node)
+	return s.splice(end, end, indentLines(lines))
+}
+
+// hasBlock reports whether the file contains lines, ignoring whitespace.
+func (s *source) hasBlock(lines []string) bool {
+	_, ok := findBlock(splitLines(string(s.src)), lines)
+	return ok
+}
+
+// replaceBlock replaces the first occurrence of after with before, comparing
+// lines while ignoring whitespace.
+func (s *source) replaceBlock(after, before []string) bool {
+	fileLines := splitLines(string(s.src))
+	idx, ok := findBlock(fileLines, after)
+	if !ok {
+		return false
+	}
+	out := make([]string, 0, len(fileLines)-len(after)+len(before))
+	out = append(out, fileLines[:idx]...)
+	out = append(out, before...)
+	out = append(out, fileLines[idx+len(after):]...)
+	return s.reset([]byte(strings.Join(out, "\n"))) == nil
+}
+
+// ensureImport adds an import for path unless the file already imports it.
+// It reports whether an import was added.
+func (s *source) ensureImport(path string) (bool, error) {
+	for _, spec := range s.file.Imports {
+		if importPath(spec) == path {
+			return false, nil
+		}
+	}
+
+	line := strconv.Quote(path)
+	for _, decl := range s.file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		if !gen.Lparen.IsValid() {
+			existing := gen.Specs[0].(*ast.ImportSpec)
+			first, second, separator := s.nodeText(existing), line, "\n\t"
+			if s.isStdlib(importPath(existing)) != s.isStdlib(path) {
+				separator = "\n\n\t"
+				if s.isStdlib(path) {
+					first, second = second, first
+				}
+			}
+			start, end := s.lineRange(gen)
+			return true, s.splice(start, end, "import (\n\t"+first+separator+second+"\n)\n")
+		}
+
+		// Keep standard library, project and third-party imports grouped,
+		// appending to the first run of imports of the same kind.
+		var anchor, fallback ast.Spec
+		anchorDone, fallbackDone := false, false
+		for _, spec := range gen.Specs {
+			existing := importPath(spec.(*ast.ImportSpec))
+			sameKind := s.isLocal(existing) == s.isLocal(path) && s.isStdlib(existing) == s.isStdlib(path)
+			switch {
+			case sameKind && !anchorDone:
+				anchor = spec
+			case anchor != nil:
+				anchorDone = true
+			}
+			switch {
+			case s.isStdlib(existing) == s.isStdlib(path) && !fallbackDone:
+				fallback = spec
+			case fallback != nil:
+				fallbackDone = true
+			}
+		}
+		if anchor == nil {
+			anchor = fallback
+		}
+		if anchor != nil {
+			_, end := s.lineRange(anchor)
+			return true, s.splice(end, end, "\t"+line+"\n")
+		}
+		if s.isStdlib(path) {
+			offset := s.fset.Position(gen.Lparen).Offset + 1
+			return true, s.splice(offset, offset, "\n\t"+line+"\n")
+		}
+		offset := s.fset.Position(gen.Rparen).Offset
+		return true, s.splice(offset, offset, "\n\t"+line+"\n")
+	}
+
+	_, end := s.lineRange(s.file.Name)
+	return true, s.splice(end, end, fmt.Sprintf("\nimport %s\n", line))
+}
+
+// removeImportIfUnused removes the import of path when no identifier in the
+// file refers to its package name.
+func (s *source) removeImportIfUnused(path string) error {
+	for _, spec := range s.file.Imports {
+		if importPath(spec) != path {
+			continue
+		}
+		name := packageName(path)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name == "_" || name == "." || s.usesPackage(name) {
+			return nil
+		}
+		start, end := s.lineRange(spec)
+		if err := s.splice(start, end, ""); err != nil {
+			return err
+		}
+		return s.collapseImports()
+	}
+	return nil
+}
+
+// collapseImports rewrites a parenthesized import block that holds a single
+// import as a plain import declaration.
+func (s *source) collapseImports() error {
+	for _, decl := range s.file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT || !gen.Lparen.IsValid() || len(gen.Specs) != 1 {
+			continue
+		}
+		start, end := s.lineRange(gen)
+		return s.splice(start, end, "import "+s.nodeText(gen.Specs[0])+"\n")
+	}
+	return nil
+}
+
+func (s *source) usesPackage(name string) bool {
+	used := false
+	ast.Inspect(s.file, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return !used
+		}
+		if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == name {
+			used = true
+		}
+		return !used
+	})
+	return used
+}
+
+// save formats the file and writes it back to disk.
+func (s *source) save() error {
+	formatted, err := format.Source(s.src)
+	if err != nil {
+		return fmt.Errorf("formatting %s: %w", s.path, err)
+	}
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, formatted, info.Mode().Perm())
+}
+
+// nodeText returns the source text of node.
+func (s *source) nodeText(node ast.Node) string {
+	start := s.fset.Position(node.Pos()).Offset
+	end := s.fset.Position(node.End()).Offset
+	return string(s.src[start:end])
+}
+
+func importPath(spec *ast.ImportSpec) string {
+	path, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		return spec.Path.Value
+	}
+	return path
+}
+
+var majorVersionSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// packageName guesses the package name declared by an import path.
+func packageName(path string) string {
+	parts := strings.Split(path, "/")
+	name := parts[len(parts)-1]
+	if majorVersionSuffix.MatchString(name) && len(parts) > 1 {
+		name = parts[len(parts)-2]
+	}
+	return strings.TrimPrefix(name, "go-")
+}
+
+// isStdlib reports whether path looks like a standard library import path.
+// Packages of the project's own module are never treated as standard library,
+// even when the module path has no dot.
+func (s *source) isStdlib(path string) bool {
+	if s.isLocal(path) {
+		return false
+	}
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+// isLocal reports whether path is a package of the project's own module.
+func (s *source) isLocal(path string) bool {
+	return s.module != "" && (path == s.module || strings.HasPrefix(path, s.module+"/"))
+}
+
+func indentLines(lines []string) string {
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString("\t")
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func splitLines(text string) []string {
+	return strings.Split(text, "\n")
+}
+
+// trimLines strips leading and trailing whitespace from every line in text
+// and drops the trailing newline.
+func trimLines(text string) string {
+	lines := splitLines(strings.TrimRight(text, "\n"))
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func normalizeLine(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
+
+func findBlock(fileLines, block []string) (int, bool) {
+	if len(block) == 0 || len(block) > len(fileLines) {
+		return 0, false
+	}
+	for i := 0; i+len(block) <= len(fileLines); i++ {
+		match := true
+		for j, line := range block {
+			if normalizeLine(fileLines[i+j]) != normalizeLine(line) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i, true
+		}
+	}
+	return 0, false
+}
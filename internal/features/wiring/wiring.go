@@ -0,0 +1,997 @@
+package wiring
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+const (
+	mainFile   = "main.go"
+	routesFile = "internal/routes/routes.go"
+)
+
+// Patch is a reversible edit made to a project source file. Before holds the
+// original lines and After the lines that replaced them; an empty Before means
+// After was inserted. Lines are stored without indentation.
+type Patch struct {
+	File    string   `json:"file"`
+	Before  string   `json:"before,omitempty"`
+	After   string   `json:"after"`
+	Imports []string `json:"imports,omitempty"`
+	// KeepWhile marks an edit other features' wiring builds on: Revert
+	// leaves it in place, without reporting it, while a line of the file
+	// still contains KeepWhile.
+	KeepWhile string `json:"keep_while,omitempty"`
+}
+
+// AnchorError reports that the code a feature wires into could not be found.
+type AnchorError struct {
+	File   string
+	Anchor string
+}
+
+func (e *AnchorError) Error() string {
+	return fmt.Sprintf("could not find %s in %s", e.Anchor, e.File)
+}
+
+// IsAnchorError reports whether err is, or wraps, an *AnchorError.
+func IsAnchorError(err error) bool {
+	var anchorErr *AnchorError
+	return errors.As(err, &anchorErr)
+}
+
+// Apply wires spec into the project rooted at projectRoot and returns the
+// patches it made. Wiring that is already present is skipped, so Apply is
+// idempotent. Every file is edited in memory and saved only once all of spec
+// is wired, so an error leaves the project unchanged.
+func Apply(projectRoot, framework string, spec shared.Wiring) ([]Patch, error) {
+	modulePath, err := ModulePath(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	patches := make([]Patch, 0, 3)
+	edited := make([]*source, 0, 3)
+	if spec.Field != nil {
+		src, err := loadProjectSource(projectRoot, spec.Field.File)
+		if err != nil {
+			return nil, err
+		}
+		patch, err := wireField(src, *spec.Field)
+		if err != nil {
+			return nil, err
+		}
+		if patch != nil {
+			patches = append(patches, *patch)
+		}
+		edited = append(edited, src)
+	}
+
+	if spec.Middleware != "" || spec.GracefulShutdown || spec.Timeouts != nil || spec.Settings != nil {
+		src, err := loadProjectSource(projectRoot, mainFile)
+		if err != nil {
+			return nil, err
+		}
+		src.module = modulePath
+		if spec.Settings != nil {
+			settingsPatches, err := wireSettings(src, *spec.Settings, spec.Imports)
+			if err != nil {
+				return nil, err
+			}
+			patches = append(patches, settingsPatches...)
+		}
+		if spec.Middleware != "" {
+			middlewarePatches, err := wireMiddleware(src, framework, spec.Middleware)
+			if err != nil {
+				return nil, err
+			}
+			if len(middlewarePatches) > 0 {
+				registration := &middlewarePatches[len(middlewarePatches)-1]
+				if registration.Imports, err = ensureImports(src, modulePath, spec.Imports); err != nil {
+					return nil, err
+				}
+				patches = append(patches, middlewarePatches...)
+			}
+		}
+		if spec.Timeouts != nil {
+			patch, err := wireTimeouts(src, framework, *spec.Timeouts, spec.Imports)
+			if err != nil {
+				return nil, err
+			}
+			if patch != nil {
+				patches = append(patches, *patch)
+			}
+		}
+		if spec.GracefulShutdown {
+			patch, err := wireGracefulShutdown(src, modulePath, shutdownFunc(spec), spec.Imports)
+			if err != nil {
+				return nil, err
+			}
+			if patch != nil {
+				patches = append(patches, *patch)
+			}
+		}
+		edited = append(edited, src)
+	}
+
+	if spec.Route != nil {
+		src, err := loadProjectSource(projectRoot, routesFile)
+		if err != nil {
+			return nil, err
+		}
+		src.module = modulePath
+		patch, err := wireRoute(src, *spec.Route)
+		if err != nil {
+			return nil, err
+		}
+		if patch != nil {
+			if patch.Imports, err = ensureImports(src, modulePath, spec.Route.Imports); err != nil {
+				return nil, err
+			}
+			patches = append(patches, *patch)
+		}
+		edited = append(edited, src)
+	}
+
+	for _, src := range edited {
+		if err := src.save(); err != nil {
+			return nil, err
+		}
+	}
+	return patches, nil
+}
+
+// Files returns the project files Apply may edit to wire spec.
+func Files(spec shared.Wiring) []string {
+	var files []string
+	if spec.Field != nil {
+		files = append(files, spec.Field.File)
+	}
+	if spec.Middleware != "" || spec.GracefulShutdown || spec.Timeouts != nil || spec.Settings != nil {
+		files = append(files, mainFile)
+	}
+	if spec.Route != nil {
+		files = append(files, routesFile)
+	}
+	return files
+}
+
+// Revert undoes patches in reverse order and returns the patches whose edited
+// code could no longer be found (for example, because the user changed it).
+func Revert(projectRoot string, patches []Patch) ([]Patch, error) {
+	sources := make(map[string]*source)
+	var failed []Patch
+	for i := len(patches) - 1; i >= 0; i-- {
+		patch := patches[i]
+		src, ok := sources[patch.File]
+		if !ok {
+			loaded, err := loadProjectSource(projectRoot, patch.File)
+			if err != nil {
+				if IsAnchorError(err) {
+					failed = append(failed, patch)
+					continue
+				}
+				return nil, err
+			}
+			src = loaded
+			sources[patch.File] = src
+		}
+
+		if patch.KeepWhile != "" && strings.Contains(string(src.src), patch.KeepWhile) {
+			continue
+		}
+		var before []string
+		if patch.Before != "" {
+			before = splitLines(patch.Before)
+		}
+		if !src.replaceBlock(splitLines(patch.After), before) {
+			failed = append(failed, patch)
+			continue
+		}
+		for _, path := range patch.Imports {
+			if err := src.removeImportIfUnused(path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, src := range sources {
+		if err := src.save(); err != nil {
+			return nil, err
+		}
+	}
+	return failed, nil
+}
+
+// Instructions returns manual wiring steps for spec, used when Apply cannot
+// find the code it would edit.
+func Instructions(framework string, spec shared.Wiring) []string {
+	steps := make([]string, 0, 4)
+	if spec.Settings != nil {
+		steps = append(steps, fmt.Sprintf("main.go: load the configuration before the server settings: %s", spec.Settings.Load))
+		for _, name := range sortedKeys(spec.Settings.Values) {
+			steps = append(steps, fmt.Sprintf("main.go: take %s from it: %s := %s", name, name, spec.Settings.Values[name]))
+		}
+		if spec.Middleware == "" && len(spec.Imports) > 0 {
+			steps = append(steps, fmt.Sprintf("main.go: import %s", strings.Join(spec.Imports, ", ")))
+		}
+	}
+	if spec.Middleware != "" {
+		if framework == "nethttp" {
+			steps = append(steps, fmt.Sprintf("main.go: wrap the root handler before serving: handler = %s(handler)", spec.Middleware))
+		} else {
+			steps = append(steps, fmt.Sprintf("main.go: register the middleware before the routes: app.Use(%s)", spec.Middleware))
+		}
+		if len(spec.Imports) > 0 {
+			steps = append(steps, fmt.Sprintf("main.go: import %s", strings.Join(spec.Imports, ", ")))
+		}
+	}
+	if spec.GracefulShutdown {
+		steps = append(steps, fmt.Sprintf("main.go: serve in a goroutine, then call %s(slog.Default(), 10*time.Second, srv.Shutdown)", shutdownFunc(spec)))
+		if spec.Middleware == "" && len(spec.Imports) > 0 {
+			steps = append(steps, fmt.Sprintf("main.go: import %s", strings.Join(spec.Imports, ", ")))
+		}
+	}
+	if spec.Timeouts != nil {
+		fields := make([]string, 0, 3)
+		for _, field := range timeoutFields(*spec.Timeouts) {
+			fields = append(fields, field.name+": "+field.value)
+		}
+		if framework == "fiber" {
+			steps = append(steps, fmt.Sprintf("main.go: create the app with fiber.New(fiber.Config{%s})", strings.Join(fields, ", ")))
+		} else {
+			steps = append(steps, fmt.Sprintf("main.go: serve with an http.Server that sets %s", strings.Join(fields, ", ")))
+		}
+	}
+	if spec.Field != nil {
+		steps = append(steps, fmt.Sprintf("%s: add the field %s to %s", spec.Field.File, spec.Field.Decl, spec.Field.Struct))
+	}
+	if spec.Route != nil {
+		steps = append(steps, fmt.Sprintf("%s: serve %s with %s", routesFile, spec.Route.Path, spec.Route.Handler))
+		if len(spec.Route.Imports) > 0 {
+			steps = append(steps, fmt.Sprintf("%s: import %s", routesFile, strings.Join(spec.Route.Imports, ", ")))
+		}
+	}
+	return steps
+}
+
+// ModulePath reads the module path from the project's go.mod.
+func ModulePath(projectRoot string) (string, error) {
+	raw, err := os.ReadFile(filepath.Join(projectRoot, "go.mod"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", &AnchorError{File: "go.mod", Anchor: "module declaration"}
+		}
+		return "", fmt.Errorf("reading go.mod: %w", err)
+	}
+	for _, line := range strings.Split(string(raw), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), nil
+		}
+	}
+	return "", &AnchorError{File: "go.mod", Anchor: "module declaration"}
+}
+
+func loadProjectSource(projectRoot, relativePath string) (*source, error) {
+	src, err := loadSource(filepath.Join(projectRoot, filepath.FromSlash(relativePath)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &AnchorError{File: relativePath, Anchor: "file"}
+		}
+		return nil, err
+	}
+	return src, nil
+}
+
+// ensureImports adds any missing imports and returns the resolved paths, which
+// are recorded so Revert can drop the ones that become unused.
+func ensureImports(src *source, modulePath string, paths []string) ([]string, error) {
+	resolved := make([]string, 0, len(paths))
+	for _, path := range paths {
+		full := resolveImport(modulePath, path)
+		if _, err := src.ensureImport(full); err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, full)
+	}
+	return resolved, nil
+}
+
+func resolveImport(modulePath, path string) string {
+	if strings.HasPrefix(path, "internal/") {
+		return modulePath + "/" + path
+	}
+	return path
+}
+
+func wireMiddleware(src *source, framework, expr string) ([]Patch, error) {
+	body, err := mainBody(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if framework == "nethttp" {
+		return wrapNetHTTPHandler(src, body, expr)
+	}
+
+	for _, stmt := range body.List {
+		exprStmt, ok := stmt.(*ast.ExprStmt)
+		if !ok {
+			continue
+		}
+		call, ok := routesRegisterCall(exprStmt.X)
+		if !ok || len(call.Args) == 0 {
+			continue
+		}
+		line := fmt.Sprintf("%s.Use(%s)", src.nodeText(call.Args[0]), expr)
+		if src.hasBlock([]string{line}) {
+			return nil, nil
+		}
+		if err := src.insertBefore(stmt, []string{line}); err != nil {
+			return nil, err
+		}
+		return []Patch{{File: mainFile, After: line}}, nil
+	}
+	return nil, &AnchorError{File: mainFile, Anchor: "routes registration call"}
+}
+
+// wrapNetHTTPHandler wraps the server's root handler. On first use it routes
+// the mux through a `handler` variable that each middleware then wraps. Every
+// wrapper records that indirection too, kept by Revert while any wrapper is
+// left, so removing the last one restores the original server literal.
+func wrapNetHTTPHandler(src *source, body *ast.BlockStmt, expr string) ([]Patch, error) {
+	line := fmt.Sprintf("handler = %s(handler)", expr)
+	if src.hasBlock([]string{line}) {
+		return nil, nil
+	}
+
+	if !hasHandlerVar(body) {
+		muxStmt, muxName := findAssignedCall(body, routesRegisterCall)
+		if muxStmt == nil {
+			return nil, &AnchorError{File: mainFile, Anchor: "routes registration call"}
+		}
+		field := findServerHandlerField(body, muxName)
+		if field == nil {
+			return nil, &AnchorError{File: mainFile, Anchor: "http.Server Handler field"}
+		}
+		value := field.Value
+		start := src.fset.Position(value.Pos()).Offset
+		end := src.fset.Position(value.End()).Offset
+		if err := src.splice(start, end, "handler"); err != nil {
+			return nil, err
+		}
+		body, _ = mainBody(src)
+		muxStmt, _ = findAssignedCall(body, routesRegisterCall)
+		if err := src.insertAfter(muxStmt, []string{fmt.Sprintf("var handler http.Handler = %s", muxName)}); err != nil {
+			return nil, err
+		}
+		body, _ = mainBody(src)
+	}
+
+	serverStmt := findServerAssign(body)
+	if serverStmt == nil {
+		return nil, &AnchorError{File: mainFile, Anchor: "http.Server declaration"}
+	}
+	patches := handlerVarPatches(body)
+	if err := src.insertBefore(serverStmt, []string{line}); err != nil {
+		return nil, err
+	}
+	return append(patches, Patch{File: mainFile, After: line}), nil
+}
+
+// handlerWrapper is contained in every line wrapping the `handler` variable.
+const handlerWrapper = "(handler)"
+
+// handlerVarPatches returns the patches undoing the `handler` indirection
+// wrapNetHTTPHandler sets up: `var handler http.Handler = mux` and the
+// server's `Handler: handler` field. It returns nil when the variable was not
+// declared that way, leaving a hand-written one alone.
+func handlerVarPatches(body *ast.BlockStmt) []Patch {
+	mux := handlerVarValue(body)
+	if mux == "" || findServerHandlerField(body, "handler") == nil {
+		return nil
+	}
+	return []Patch{
+		{File: mainFile, After: fmt.Sprintf("var handler http.Handler = %s", mux), KeepWhile: handlerWrapper},
+		{File: mainFile, Before: fmt.Sprintf("Handler: %s,", mux), After: "Handler: handler,", KeepWhile: handlerWrapper},
+	}
+}
+
+// shutdownFunc returns the function spec's graceful shutdown calls.
+func shutdownFunc(spec shared.Wiring) string {
+	if spec.Shutdown != "" {
+		return spec.Shutdown
+	}
+	return "server.WaitForShutdown"
+}
+
+func wireGracefulShutdown(src *source, modulePath, shutdown string, packageImports []string) (*Patch, error) {
+	if strings.Contains(string(src.src), shutdown+"(") {
+		return nil, nil
+	}
+	body, err := mainBody(src)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stmt := range body.List {
+		ifStmt, ok := stmt.(*ast.IfStmt)
+		if !ok {
+			continue
+		}
+		call := serveCall(ifStmt)
+		if call == nil {
+			continue
+		}
+		sel := call.Fun.(*ast.SelectorExpr)
+		receiver := src.nodeText(sel.X)
+		listener := src.nodeText(call.Args[0])
+
+		var lines, imports []string
+		switch sel.Sel.Name {
+		case "Serve":
+			lines = serveInBackground(receiver, listener)
+			lines = append(lines, fmt.Sprintf("%s(slog.Default(), 10*time.Second, %s.Shutdown)", shutdown, receiver))
+			imports = []string{"errors", "log/slog", "net/http", "time"}
+		case "RunListener":
+			lines = []string{
+				"srv := &http.Server{",
+				fmt.Sprintf("\tHandler:           %s,", receiver),
+				"\tReadHeaderTimeout: 5 * time.Second,",
+				"}",
+			}
+			lines = append(lines, serveInBackground("srv", listener)...)
+			lines = append(lines, shutdown+"(slog.Default(), 10*time.Second, srv.Shutdown)")
+			imports = []string{"errors", "log/slog", "net/http", "time"}
+		case "Listener":
+			lines = []string{
+				"go func() {",
+				fmt.Sprintf("\tif err := %s.Listener(%s); err != nil {", receiver, listener),
+				"\t\tlog.Fatal(err)",
+				"\t}",
+				"}()",
+				fmt.Sprintf("%s(slog.Default(), 10*time.Second, %s.ShutdownWithContext)", shutdown, receiver),
+			}
+			imports = []string{"log/slog", "time"}
+		}
+
+		before, err := src.replaceNode(ifStmt, lines)
+		if err != nil {
+			return nil, err
+		}
+		patch := &Patch{File: mainFile, Before: before, After: trimLines(strings.Join(lines, "\n"))}
+		if len(packageImports) == 0 {
+			packageImports = []string{"internal/server"}
+		}
+		if patch.Imports, err = ensureImports(src, modulePath, append(imports, packageImports...)); err != nil {
+			return nil, err
+		}
+		return patch, nil
+	}
+	return nil, &AnchorError{File: mainFile, Anchor: "blocking Serve call"}
+}
+
+func serveInBackground(receiver, listener string) []string {
+	return []string{
+		"go func() {",
+		fmt.Sprintf("\tif err := %s.Serve(%s); err != nil && !errors.Is(err, http.ErrServerClosed) {", receiver, listener),
+		"\t\tlog.Fatal(err)",
+		"\t}",
+		"}()",
+	}
+}
+
+// timeoutField is a field of http.Server or fiber.Config set by Timeouts.
+type timeoutField struct {
+	name  string
+	value string
+}
+
+func timeoutFields(timeouts shared.Timeouts) []timeoutField {
+	fields := make([]timeoutField, 0, 3)
+	for _, field := range []timeoutField{
+		{"ReadTimeout", timeouts.Read},
+		{"WriteTimeout", timeouts.Write},
+		{"IdleTimeout", timeouts.Idle},
+	} {
+		if field.value != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// wireTimeouts sets the server timeouts in main.go. Fields the server already
+// sets are kept, so the user's own values win.
+func wireTimeouts(src *source, framework string, timeouts shared.Timeouts, packageImports []string) (*Patch, error) {
+	body, err := mainBody(src)
+	if err != nil {
+		return nil, err
+	}
+	fields := timeoutFields(timeouts)
+
+	var patch *Patch
+	imports := []string{"time"}
+	if framework == "fiber" {
+		patch, err = wireFiberTimeouts(src, body, fields)
+	} else if stmt := findServerAssign(body); stmt != nil {
+		patch, err = addLiteralFields(src, stmt, serverLiteral(stmt.(*ast.AssignStmt).Rhs[0]), fields)
+	} else {
+		patch, err = serveWithTimeouts(src, body, fields)
+		imports = append(imports, "net/http")
+	}
+	if err != nil || patch == nil {
+		return nil, err
+	}
+	if patch.Imports, err = ensureImports(src, src.module, append(imports, packageImports...)); err != nil {
+		return nil, err
+	}
+	return patch, nil
+}
+
+// wireFiberTimeouts passes the timeouts to fiber.New in a fiber.Config.
+func wireFiberTimeouts(src *source, body *ast.BlockStmt, fields []timeoutField) (*Patch, error) {
+	for _, stmt := range body.List {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+			continue
+		}
+		call, ok := assign.Rhs[0].(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "New" {
+			continue
+		}
+		if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != "fiber" {
+			continue
+		}
+
+		switch len(call.Args) {
+		case 0:
+			lines := []string{fmt.Sprintf("%s %s fiber.New(fiber.Config{", src.nodeText(assign.Lhs[0]), assign.Tok)}
+			for _, field := range fields {
+				lines = append(lines, fmt.Sprintf("\t%s: %s,", field.name, field.value))
+			}
+			lines = append(lines, "})")
+			before, err := src.replaceNode(stmt, lines)
+			if err != nil {
+				return nil, err
+			}
+			return &Patch{File: mainFile, Before: before, After: trimLines(strings.Join(lines, "\n"))}, nil
+		case 1:
+			if lit, ok := call.Args[0].(*ast.CompositeLit); ok {
+				return addLiteralFields(src, stmt, lit, fields)
+			}
+		}
+		return nil, &AnchorError{File: mainFile, Anchor: "fiber.Config literal"}
+	}
+	return nil, &AnchorError{File: mainFile, Anchor: "fiber.New call"}
+}
+
+// serveWithTimeouts replaces gin's `if err := app.RunListener(l); ...` with
+// an http.Server, which unlike RunListener accepts timeouts.
+func serveWithTimeouts(src *source, body *ast.BlockStmt, fields []timeoutField) (*Patch, error) {
+	for _, stmt := range body.List {
+		ifStmt, ok := stmt.(*ast.IfStmt)
+		if !ok {
+			continue
+		}
+		call := serveCall(ifStmt)
+		if call == nil || call.Fun.(*ast.SelectorExpr).Sel.Name != "RunListener" {
+			continue
+		}
+		receiver := src.nodeText(call.Fun.(*ast.SelectorExpr).X)
+		listener := src.nodeText(call.Args[0])
+
+		lines := []string{
+			"srv := &http.Server{",
+			fmt.Sprintf("\tHandler:           %s,", receiver),
+			"\tReadHeaderTimeout: 5 * time.Second,",
+		}
+		for _, field := range fields {
+			lines = append(lines, fmt.Sprintf("\t%s: %s,", field.name, field.value))
+		}
+		lines = append(lines,
+			"}",
+			fmt.Sprintf("if err := srv.Serve(%s); err != nil {", listener),
+			"\tlog.Fatal(err)",
+			"}",
+		)
+		before, err := src.replaceNode(ifStmt, lines)
+		if err != nil {
+			return nil, err
+		}
+		return &Patch{File: mainFile, Before: before, After: trimLines(strings.Join(lines, "\n"))}, nil
+	}
+	return nil, &AnchorError{File: mainFile, Anchor: "http.Server declaration"}
+}
+
+// addLiteralFields adds the fields lit does not set yet, rewriting stmt with
+// one element per line. It returns nil when every field is already set.
+func addLiteralFields(src *source, stmt ast.Stmt, lit *ast.CompositeLit, fields []timeoutField) (*Patch, error) {
+	present := make(map[string]bool, len(lit.Elts))
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if key, ok := kv.Key.(*ast.Ident); ok {
+				present[key.Name] = true
+			}
+		}
+	}
+	var missing []string
+	for _, field := range fields {
+		if !present[field.name] {
+			missing = append(missing, fmt.Sprintf("\t%s: %s,", field.name, field.value))
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	offset := func(pos token.Pos) int { return src.fset.Position(pos).Offset }
+	lines := []string{string(src.src[offset(stmt.Pos()) : offset(lit.Lbrace)+1])}
+	for _, elt := range lit.Elts {
+		lines = append(lines, splitLines("\t"+src.nodeText(elt)+",")...)
+	}
+	lines = append(lines, missing...)
+	lines = append(lines, string(src.src[offset(lit.Rbrace):offset(stmt.End())]))
+	before, err := src.replaceNode(stmt, lines)
+	if err != nil {
+		return nil, err
+	}
+	return &Patch{File: mainFile, Before: before, After: trimLines(strings.Join(lines, "\n"))}, nil
+}
+
+func wireRoute(src *source, route shared.Route) (*Patch, error) {
+	var found *ast.ExprStmt
+	var call *ast.CallExpr
+	ast.Inspect(src.file, func(n ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		stmt, ok := n.(*ast.ExprStmt)
+		if !ok {
+			return true
+		}
+		c, ok := stmt.X.(*ast.CallExpr)
+		if !ok || len(c.Args) != 2 {
+			return true
+		}
+		sel, ok := c.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		switch sel.Sel.Name {
+		case "GET", "Get", "HandleFunc", "Handle":
+		default:
+			return true
+		}
+		lit, ok := c.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		if path, err := strconv.Unquote(lit.Value); err == nil && path == route.Path {
+			found, call = stmt, c
+			return false
+		}
+		return true
+	})
+	if found == nil {
+		return nil, &AnchorError{File: routesFile, Anchor: fmt.Sprintf("%s route", route.Path)}
+	}
+
+	sel := call.Fun.(*ast.SelectorExpr)
+	line := fmt.Sprintf("%s.%s(%q, %s)", src.nodeText(sel.X), sel.Sel.Name, route.Path, route.Handler)
+	if normalizeLine(src.nodeText(found)) == normalizeLine(line) {
+		return nil, nil
+	}
+	before, err := src.replaceNode(found, []string{line})
+	if err != nil {
+		return nil, err
+	}
+	return &Patch{File: routesFile, Before: before, After: line}, nil
+}
+
+// wireSettings inserts the Load statement into main.go above the first
+// declaration of a variable in settings.Values and replaces the values of
+// those declarations. Each edit is its own patch; the imports are recorded on
+// the Load patch, which is reverted last.
+func wireSettings(src *source, settings shared.Settings, packageImports []string) ([]Patch, error) {
+	load := splitLines(settings.Load)
+	if src.hasBlock(load) {
+		return nil, nil
+	}
+	body, err := mainBody(src)
+	if err != nil {
+		return nil, err
+	}
+
+	firstName := ""
+	found := make(map[string]bool, len(settings.Values))
+	for _, stmt := range body.List {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok || assign.Tok != token.DEFINE || len(assign.Lhs) != 1 {
+			continue
+		}
+		ident, ok := assign.Lhs[0].(*ast.Ident)
+		if !ok {
+			continue
+		}
+		if _, ok := settings.Values[ident.Name]; ok {
+			found[ident.Name] = true
+			if firstName == "" {
+				firstName = ident.Name
+			}
+		}
+	}
+	for _, name := range sortedKeys(settings.Values) {
+		if !found[name] {
+			return nil, &AnchorError{File: mainFile, Anchor: fmt.Sprintf("declaration of %s", name)}
+		}
+	}
+
+	// Every edit re-parses the file, so statements are looked up again.
+	patches := make([]Patch, 0, len(found)+1)
+	for _, name := range sortedKeys(settings.Values) {
+		body, err := mainBody(src)
+		if err != nil {
+			return nil, err
+		}
+		stmt := findDefine(body, name)
+		line := fmt.Sprintf("%s := %s", name, settings.Values[name])
+		before, err := src.replaceNode(stmt, []string{line})
+		if err != nil {
+			return nil, err
+		}
+		patches = append(patches, Patch{File: mainFile, Before: before, After: line})
+	}
+	body, err = mainBody(src)
+	if err != nil {
+		return nil, err
+	}
+	if err := src.insertBefore(findDefine(body, firstName), load); err != nil {
+		return nil, err
+	}
+	loadPatch := Patch{File: mainFile, After: trimLines(settings.Load)}
+	if loadPatch.Imports, err = ensureImports(src, src.module, packageImports); err != nil {
+		return nil, err
+	}
+	return append([]Patch{loadPatch}, patches...), nil
+}
+
+// findDefine finds `name := ...` in body.
+func findDefine(body *ast.BlockStmt, name string) ast.Stmt {
+	for _, stmt := range body.List {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok || assign.Tok != token.DEFINE || len(assign.Lhs) != 1 {
+			continue
+		}
+		if ident, ok := assign.Lhs[0].(*ast.Ident); ok && ident.Name == name {
+			return stmt
+		}
+	}
+	return nil
+}
+
+// wireField adds field.Decl after the last field of the struct type
+// field.Struct. A field of the same name is left as it is.
+func wireField(src *source, field shared.Field) (*Patch, error) {
+	relativePath := filepath.ToSlash(field.File)
+	var fields *ast.FieldList
+	for _, decl := range src.file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok || typeSpec.Name.Name != field.Struct {
+				continue
+			}
+			if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+				fields = structType.Fields
+			}
+		}
+	}
+	if fields == nil || len(fields.List) == 0 {
+		return nil, &AnchorError{File: relativePath, Anchor: fmt.Sprintf("fields of type %s", field.Struct)}
+	}
+
+	name := strings.Fields(field.Decl)[0]
+	for _, existing := range fields.List {
+		for _, ident := range existing.Names {
+			if ident.Name == name {
+				return nil, nil
+			}
+		}
+	}
+	if err := src.insertAfter(fields.List[len(fields.List)-1], []string{field.Decl}); err != nil {
+		return nil, err
+	}
+	return &Patch{File: relativePath, After: strings.TrimSpace(field.Decl)}, nil
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func mainBody(src *source) (*ast.BlockStmt, error) {
+	for _, decl := range src.file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if ok && fn.Recv == nil && fn.Name.Name == "main" && fn.Body != nil {
+			return fn.Body, nil
+		}
+	}
+	return nil, &AnchorError{File: mainFile, Anchor: "func main"}
+}
+
+// routesRegisterCall matches calls such as routes.RegisterGinRoutes(app, ...).
+func routesRegisterCall(expr ast.Expr) (*ast.CallExpr, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return nil, false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil, false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok || pkg.Name != "routes" || !strings.HasPrefix(sel.Sel.Name, "Register") {
+		return nil, false
+	}
+	return call, true
+}
+
+// findAssignedCall finds `name := call(...)` where match accepts the call.
+func findAssignedCall(body *ast.BlockStmt, match func(ast.Expr) (*ast.CallExpr, bool)) (ast.Stmt, string) {
+	for _, stmt := range body.List {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+			continue
+		}
+		ident, ok := assign.Lhs[0].(*ast.Ident)
+		if !ok {
+			continue
+		}
+		if _, ok := match(assign.Rhs[0]); ok {
+			return stmt, ident.Name
+		}
+	}
+	return nil, ""
+}
+
+func hasHandlerVar(body *ast.BlockStmt) bool {
+	for _, stmt := range body.List {
+		decl, ok := stmt.(*ast.DeclStmt)
+		if !ok {
+			continue
+		}
+		gen, ok := decl.Decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			if value, ok := spec.(*ast.ValueSpec); ok && len(value.Names) == 1 && value.Names[0].Name == "handler" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// handlerVarValue returns the name assigned by `var handler http.Handler =
+// name`, or "" when main declares no such variable.
+func handlerVarValue(body *ast.BlockStmt) string {
+	for _, stmt := range body.List {
+		decl, ok := stmt.(*ast.DeclStmt)
+		if !ok {
+			continue
+		}
+		gen, ok := decl.Decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR || len(gen.Specs) != 1 {
+			continue
+		}
+		spec, ok := gen.Specs[0].(*ast.ValueSpec)
+		if !ok || len(spec.Names) != 1 || spec.Names[0].Name != "handler" || len(spec.Values) != 1 {
+			continue
+		}
+		typ, ok := spec.Type.(*ast.SelectorExpr)
+		if !ok || typ.Sel.Name != "Handler" {
+			continue
+		}
+		if value, ok := spec.Values[0].(*ast.Ident); ok {
+			return value.Name
+		}
+	}
+	return ""
+}
+
+// findServerAssign finds `srv := &http.Server{...}`.
+func findServerAssign(body *ast.BlockStmt) ast.Stmt {
+	for _, stmt := range body.List {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok || len(assign.Rhs) != 1 {
+			continue
+		}
+		if serverLiteral(assign.Rhs[0]) != nil {
+			return stmt
+		}
+	}
+	return nil
+}
+
+func findServerHandlerField(body *ast.BlockStmt, value string) *ast.KeyValueExpr {
+	stmt := findServerAssign(body)
+	if stmt == nil {
+		return nil
+	}
+	lit := serverLiteral(stmt.(*ast.AssignStmt).Rhs[0])
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok || key.Name != "Handler" {
+			continue
+		}
+		if ident, ok := kv.Value.(*ast.Ident); ok && ident.Name == value {
+			return kv
+		}
+	}
+	return nil
+}
+
+func serverLiteral(expr ast.Expr) *ast.CompositeLit {
+	unary, ok := expr.(*ast.UnaryExpr)
+	if !ok || unary.Op != token.AND {
+		return nil
+	}
+	lit, ok := unary.X.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	sel, ok := lit.Type.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Server" {
+		return nil
+	}
+	return lit
+}
+
+// serveCall matches `if err := x.Serve(l); err != nil { ... }` and the gin
+// and fiber equivalents, returning the serve call.
+func serveCall(ifStmt *ast.IfStmt) *ast.CallExpr {
+	assign, ok := ifStmt.Init.(*ast.AssignStmt)
+	if !ok || len(assign.Rhs) != 1 {
+		return nil
+	}
+	call, ok := assign.Rhs[0].(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return nil
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	switch sel.Sel.Name {
+	case "Serve", "RunListener", "Listener":
+		return call
+	}
+	return nil
+}
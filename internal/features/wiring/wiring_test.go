@@ -0,0 +1,508 @@
+package wiring
+
+import (
+	"bytes"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	lalibelacli "github.com/naodEthiop/lalibela-cli"
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
+)
+
+var routeTemplates = map[string]string{
+	"gin":     "templates/routes/gin_routes.go.tmpl",
+	"echo":    "templates/routes/echo_routes.go.tmpl",
+	"fiber":   "templates/routes/fiber_routes.go.tmpl",
+	"nethttp": "templates/routes/nethttp_routes.go.tmpl",
+}
+
+// newProject renders the scaffold's main.go and routes.go for framework into
+// a temporary directory, formatted the way Apply leaves them.
+func newProject(t *testing.T, framework string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	data := map[string]string{
+		"ModuleName":  "demo",
+		"ProjectName": "demo",
+		"Framework":   framework,
+		"CLIVersion":  "dev",
+	}
+	render := func(templatePath, outputPath string) {
+		tmpl, err := template.ParseFS(lalibelacli.EmbeddedTemplates, templatePath)
+		if err != nil {
+			t.Fatalf("parse %s: %v", templatePath, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			t.Fatalf("execute %s: %v", templatePath, err)
+		}
+		formatted, err := format.Source(buf.Bytes())
+		if err != nil {
+			t.Fatalf("format %s: %v", templatePath, err)
+		}
+		fullPath := filepath.Join(root, outputPath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(fullPath, formatted, 0o644); err != nil {
+			t.Fatalf("write %s: %v", outputPath, err)
+		}
+	}
+	render("templates/main.go.tmpl", mainFile)
+	render(routeTemplates[framework], routesFile)
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module demo\n\ngo 1.25\n"), 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+	return root
+}
+
+func readFile(t *testing.T, root, relativePath string) string {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(relativePath)))
+	if err != nil {
+		t.Fatalf("read %s: %v", relativePath, err)
+	}
+	return string(raw)
+}
+
+func TestApplyMiddlewareIsIdempotentAndReversible(t *testing.T) {
+	t.Parallel()
+
+	specs := map[string]shared.Wiring{
+		"gin":     {Middleware: "server.CORSMiddleware()", Imports: []string{"internal/server"}},
+		"echo":    {Middleware: "echo.WrapMiddleware(server.CORSMiddleware)", Imports: []string{"internal/server"}},
+		"fiber":   {Middleware: "adaptor.HTTPMiddleware(server.CORSMiddleware)", Imports: []string{"github.com/gofiber/fiber/v2/middleware/adaptor", "internal/server"}},
+		"nethttp": {Middleware: "server.CORSMiddleware", Imports: []string{"internal/server"}},
+	}
+	for framework, spec := range specs {
+		t.Run(framework, func(t *testing.T) {
+			t.Parallel()
+
+			root := newProject(t, framework)
+			original := readFile(t, root, mainFile)
+
+			patches, err := Apply(root, framework, spec)
+			if err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+			// net/http also records routing the mux through a handler variable.
+			want := 1
+			if framework == "nethttp" {
+				want = 3
+			}
+			if len(patches) != want {
+				t.Fatalf("expected %d patches, got %+v", want, patches)
+			}
+			wired := readFile(t, root, mainFile)
+			if !strings.Contains(wired, spec.Middleware) || !strings.Contains(wired, `"demo/internal/server"`) {
+				t.Fatalf("middleware not wired into main.go:\n%s", wired)
+			}
+
+			again, err := Apply(root, framework, spec)
+			if err != nil {
+				t.Fatalf("second apply: %v", err)
+			}
+			if len(again) != 0 || readFile(t, root, mainFile) != wired {
+				t.Fatalf("expected second apply to be a no-op, got %+v", again)
+			}
+
+			stale, err := Revert(root, patches)
+			if err != nil {
+				t.Fatalf("revert: %v", err)
+			}
+			if len(stale) != 0 {
+				t.Fatalf("expected all patches to revert, stale=%+v", stale)
+			}
+			reverted := readFile(t, root, mainFile)
+			if reverted != original {
+				t.Fatalf("expected main.go to be restored:\n%s", reverted)
+			}
+			if strings.Contains(reverted, spec.Middleware) || strings.Contains(reverted, `"demo/internal/server"`) {
+				t.Fatalf("middleware still present after revert:\n%s", reverted)
+			}
+		})
+	}
+}
+
+func TestRevertKeepsHandlerVariableUntilLastWrapperIsRemoved(t *testing.T) {
+	t.Parallel()
+
+	cors := shared.Wiring{Middleware: "server.CORSMiddleware", Imports: []string{"internal/server"}}
+	rateLimit := shared.Wiring{Middleware: "server.RateLimitMiddleware", Imports: []string{"internal/server"}}
+	for name, firstRemoved := range map[string]int{"first wired removed first": 0, "last wired removed first": 1} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			root := newProject(t, "nethttp")
+			original := readFile(t, root, mainFile)
+			var wired [2][]Patch
+			for i, spec := range []shared.Wiring{cors, rateLimit} {
+				patches, err := Apply(root, "nethttp", spec)
+				if err != nil {
+					t.Fatalf("apply %s: %v", spec.Middleware, err)
+				}
+				wired[i] = patches
+			}
+
+			stale, err := Revert(root, wired[firstRemoved])
+			if err != nil || len(stale) != 0 {
+				t.Fatalf("revert first: stale=%+v err=%v", stale, err)
+			}
+			remaining := readFile(t, root, mainFile)
+			if !strings.Contains(remaining, "var handler http.Handler = mux") || !strings.Contains(remaining, "(handler)") {
+				t.Fatalf("expected the handler variable to stay for the other wrapper:\n%s", remaining)
+			}
+
+			stale, err = Revert(root, wired[1-firstRemoved])
+			if err != nil || len(stale) != 0 {
+				t.Fatalf("revert last: stale=%+v err=%v", stale, err)
+			}
+			if reverted := readFile(t, root, mainFile); reverted != original {
+				t.Fatalf("expected main.go to be restored once no wrapper is left:\n%s", reverted)
+			}
+		})
+	}
+}
+
+func TestApplyGracefulShutdown(t *testing.T) {
+	t.Parallel()
+
+	want := map[string]string{
+		"gin":     "server.WaitForShutdown(slog.Default(), 10*time.Second, srv.Shutdown)",
+		"echo":    "server.WaitForShutdown(slog.Default(), 10*time.Second, srv.Shutdown)",
+		"fiber":   "server.WaitForShutdown(slog.Default(), 10*time.Second, app.ShutdownWithContext)",
+		"nethttp": "server.WaitForShutdown(slog.Default(), 10*time.Second, srv.Shutdown)",
+	}
+	for framework, call := range want {
+		t.Run(framework, func(t *testing.T) {
+			t.Parallel()
+
+			root := newProject(t, framework)
+			original := readFile(t, root, mainFile)
+
+			patches, err := Apply(root, framework, shared.Wiring{GracefulShutdown: true})
+			if err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+			wired := readFile(t, root, mainFile)
+			if !strings.Contains(wired, call) || !strings.Contains(wired, `"log/slog"`) {
+				t.Fatalf("graceful shutdown not wired:\n%s", wired)
+			}
+
+			if _, err := Revert(root, patches); err != nil {
+				t.Fatalf("revert: %v", err)
+			}
+			if reverted := readFile(t, root, mainFile); reverted != original {
+				t.Fatalf("expected main.go to be restored:\n%s", reverted)
+			}
+		})
+	}
+}
+
+func TestApplyGracefulShutdownUsesConfiguredPackage(t *testing.T) {
+	t.Parallel()
+
+	root := newProject(t, "gin")
+	spec := shared.Wiring{GracefulShutdown: true, Shutdown: "platform.WaitForShutdown", Imports: []string{"demo/internal/platform"}}
+	if _, err := Apply(root, "gin", spec); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	wired := readFile(t, root, mainFile)
+	if !strings.Contains(wired, "platform.WaitForShutdown(slog.Default()") || !strings.Contains(wired, `"demo/internal/platform"`) {
+		t.Fatalf("expected the configured package to be wired:\n%s", wired)
+	}
+	if strings.Contains(wired, "internal/server") {
+		t.Fatalf("expected the default server package not to be imported:\n%s", wired)
+	}
+}
+
+func TestApplyTimeoutsIsIdempotentAndReversible(t *testing.T) {
+	t.Parallel()
+
+	spec := shared.Wiring{Timeouts: &shared.Timeouts{Read: "15 * time.Second", Write: "30 * time.Second", Idle: "60 * time.Second"}}
+	for _, framework := range []string{"gin", "echo", "fiber", "nethttp"} {
+		t.Run(framework, func(t *testing.T) {
+			t.Parallel()
+
+			root := newProject(t, framework)
+			original := readFile(t, root, mainFile)
+
+			patches, err := Apply(root, framework, spec)
+			if err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+			if len(patches) != 1 {
+				t.Fatalf("expected one patch, got %+v", patches)
+			}
+			wired := readFile(t, root, mainFile)
+			for _, field := range []string{"ReadTimeout:", "WriteTimeout:", "IdleTimeout:"} {
+				if !strings.Contains(wired, field) {
+					t.Fatalf("%s not wired into main.go:\n%s", field, wired)
+				}
+			}
+			if framework == "gin" && strings.Contains(wired, "RunListener") {
+				t.Fatalf("expected gin to serve with an http.Server:\n%s", wired)
+			}
+
+			again, err := Apply(root, framework, spec)
+			if err != nil {
+				t.Fatalf("second apply: %v", err)
+			}
+			if len(again) != 0 || readFile(t, root, mainFile) != wired {
+				t.Fatalf("expected second apply to be a no-op, got %+v", again)
+			}
+
+			stale, err := Revert(root, patches)
+			if err != nil {
+				t.Fatalf("revert: %v", err)
+			}
+			if len(stale) != 0 {
+				t.Fatalf("expected all patches to revert, stale=%+v", stale)
+			}
+			if reverted := readFile(t, root, mainFile); reverted != original {
+				t.Fatalf("expected main.go to be restored:\n%s", reverted)
+			}
+		})
+	}
+}
+
+func TestApplyTimeoutsKeepsExistingValues(t *testing.T) {
+	t.Parallel()
+
+	root := newProject(t, "echo")
+	if _, err := Apply(root, "echo", shared.Wiring{Timeouts: &shared.Timeouts{Write: "2 * time.Minute"}}); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if _, err := Apply(root, "echo", shared.Wiring{Timeouts: &shared.Timeouts{Read: "15 * time.Second", Write: "30 * time.Second"}}); err != nil {
+		t.Fatalf("second apply: %v", err)
+	}
+	wired := readFile(t, root, mainFile)
+	if !strings.Contains(wired, "2 * time.Minute") || strings.Contains(wired, "30 * time.Second") || !strings.Contains(wired, "15 * time.Second") {
+		t.Fatalf("expected the existing WriteTimeout to be kept and ReadTimeout added:\n%s", wired)
+	}
+}
+
+func TestApplyGracefulShutdownAfterTimeouts(t *testing.T) {
+	t.Parallel()
+
+	root := newProject(t, "gin")
+	if _, err := Apply(root, "gin", shared.Wiring{Timeouts: &shared.Timeouts{Read: "15 * time.Second"}}); err != nil {
+		t.Fatalf("apply timeouts: %v", err)
+	}
+	if _, err := Apply(root, "gin", shared.Wiring{GracefulShutdown: true}); err != nil {
+		t.Fatalf("apply graceful shutdown: %v", err)
+	}
+	wired := readFile(t, root, mainFile)
+	if !strings.Contains(wired, "ReadTimeout:") || !strings.Contains(wired, "server.WaitForShutdown(slog.Default(), 10*time.Second, srv.Shutdown)") {
+		t.Fatalf("expected timeouts and graceful shutdown on one http.Server:\n%s", wired)
+	}
+}
+
+func TestApplyRouteReplacesHandler(t *testing.T) {
+	t.Parallel()
+
+	root := newProject(t, "gin")
+	original := readFile(t, root, routesFile)
+	spec := shared.Wiring{Route: &shared.Route{
+		Path:    "/health",
+		Handler: "func(c *gin.Context) { server.WriteHealth(c.Writer) }",
+		Imports: []string{"internal/server"},
+	}}
+
+	patches, err := Apply(root, "gin", spec)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	wired := readFile(t, root, routesFile)
+	if !strings.Contains(wired, `r.GET("/health", func(c *gin.Context) { server.WriteHealth(c.Writer) })`) {
+		t.Fatalf("health route not replaced:\n%s", wired)
+	}
+
+	if _, err := Revert(root, patches); err != nil {
+		t.Fatalf("revert: %v", err)
+	}
+	if reverted := readFile(t, root, routesFile); reverted != original {
+		t.Fatalf("expected routes.go to be restored:\n%s", reverted)
+	}
+}
+
+func TestApplySettingsIsIdempotentAndReversible(t *testing.T) {
+	t.Parallel()
+
+	spec := shared.Wiring{
+		Settings: &shared.Settings{
+			Load: "cfg := config.MustLoad()",
+			Values: map[string]string{
+				"port":        "cfg.Server.Port",
+				"tlsSettings": "tlsSettings{CertFile: cfg.TLS.CertFile, KeyFile: cfg.TLS.KeyFile}",
+			},
+		},
+		Imports: []string{"internal/config"},
+	}
+	for _, framework := range []string{"gin", "echo", "fiber", "nethttp"} {
+		t.Run(framework, func(t *testing.T) {
+			t.Parallel()
+
+			root := newProject(t, framework)
+			original := readFile(t, root, mainFile)
+
+			patches, err := Apply(root, framework, spec)
+			if err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+			if len(patches) != 3 {
+				t.Fatalf("expected the load and two replaced values, got %+v", patches)
+			}
+			wired := readFile(t, root, mainFile)
+			load := strings.Index(wired, "cfg := config.MustLoad()")
+			port := strings.Index(wired, "port := cfg.Server.Port")
+			if load < 0 || port < load || !strings.Contains(wired, "tlsSettings := tlsSettings{CertFile: cfg.TLS.CertFile") || !strings.Contains(wired, `"demo/internal/config"`) {
+				t.Fatalf("settings not wired into main.go:\n%s", wired)
+			}
+			if strings.Contains(wired, "port := resolvePort()") {
+				t.Fatalf("expected the port to come from the configuration:\n%s", wired)
+			}
+
+			again, err := Apply(root, framework, spec)
+			if err != nil {
+				t.Fatalf("second apply: %v", err)
+			}
+			if len(again) != 0 || readFile(t, root, mainFile) != wired {
+				t.Fatalf("expected second apply to be a no-op, got %+v", again)
+			}
+
+			stale, err := Revert(root, patches)
+			if err != nil {
+				t.Fatalf("revert: %v", err)
+			}
+			if len(stale) != 0 {
+				t.Fatalf("expected all patches to revert, stale=%+v", stale)
+			}
+			if reverted := readFile(t, root, mainFile); reverted != original {
+				t.Fatalf("expected main.go to be restored:\n%s", reverted)
+			}
+		})
+	}
+}
+
+func TestApplyFieldIsIdempotentAndReversible(t *testing.T) {
+	t.Parallel()
+
+	root := newProject(t, "gin")
+	const configFile = "internal/config/config.go"
+	original := "package config\n\ntype Config struct {\n\tApp AppConfig `yaml:\"app\"`\n}\n\ntype AppConfig struct{}\n"
+	if err := os.MkdirAll(filepath.Join(root, "internal", "config"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(configFile)), []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := shared.Wiring{Field: &shared.Field{File: configFile, Struct: "Config", Decl: "Redis RedisConfig `yaml:\"redis\"`"}}
+	patches, err := Apply(root, "gin", spec)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if len(patches) != 1 || patches[0].File != configFile {
+		t.Fatalf("expected one patch to %s, got %+v", configFile, patches)
+	}
+	wired := readFile(t, root, configFile)
+	if !strings.Contains(wired, "\tApp   AppConfig   `yaml:\"app\"`\n\tRedis RedisConfig `yaml:\"redis\"`\n}") {
+		t.Fatalf("field not added to Config:\n%s", wired)
+	}
+
+	again, err := Apply(root, "gin", spec)
+	if err != nil {
+		t.Fatalf("second apply: %v", err)
+	}
+	if len(again) != 0 || readFile(t, root, configFile) != wired {
+		t.Fatalf("expected second apply to be a no-op, got %+v", again)
+	}
+
+	if stale, err := Revert(root, patches); err != nil || len(stale) != 0 {
+		t.Fatalf("revert: stale=%+v err=%v", stale, err)
+	}
+	if reverted := readFile(t, root, configFile); reverted != original {
+		t.Fatalf("expected config.go to be restored:\n%s", reverted)
+	}
+
+	_, err = Apply(root, "gin", shared.Wiring{Field: &shared.Field{File: configFile, Struct: "Settings", Decl: "Redis RedisConfig"}})
+	if !IsAnchorError(err) {
+		t.Fatalf("expected anchor error for a missing struct, got %v", err)
+	}
+}
+
+func TestApplyLeavesFilesUnchangedOnAnchorError(t *testing.T) {
+	t.Parallel()
+
+	root := newProject(t, "gin")
+	const configFile = "internal/config/config.go"
+	original := "package config\n\ntype Config struct {\n\tApp AppConfig `yaml:\"app\"`\n}\n\ntype AppConfig struct{}\n"
+	if err := os.MkdirAll(filepath.Join(root, "internal", "config"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(configFile)), []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, mainFile), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := shared.Wiring{
+		Middleware: "server.RateLimitMiddleware(cfg.RateLimit.RPS, cfg.RateLimit.Burst)",
+		Field:      &shared.Field{File: configFile, Struct: "Config", Decl: "RateLimit RateLimitConfig `yaml:\"rate_limit\"`"},
+	}
+	if _, err := Apply(root, "gin", spec); !IsAnchorError(err) {
+		t.Fatalf("expected anchor error, got %v", err)
+	}
+	if got := readFile(t, root, configFile); got != original {
+		t.Fatalf("expected config.go to be left unchanged:\n%s", got)
+	}
+}
+
+func TestApplyReportsMissingAnchor(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module demo\n"), 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, mainFile), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+
+	_, err := Apply(root, "gin", shared.Wiring{GracefulShutdown: true})
+	if !IsAnchorError(err) {
+		t.Fatalf("expected anchor error, got %v", err)
+	}
+	if steps := Instructions("gin", shared.Wiring{GracefulShutdown: true}); len(steps) == 0 {
+		t.Fatal("expected manual wiring instructions")
+	}
+}
+
+func TestRevertReportsChangedCode(t *testing.T) {
+	t.Parallel()
+
+	root := newProject(t, "echo")
+	patches, err := Apply(root, "echo", shared.Wiring{GracefulShutdown: true})
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	edited := strings.Replace(readFile(t, root, mainFile), "10*time.Second", "30*time.Second", 1)
+	if err := os.WriteFile(filepath.Join(root, mainFile), []byte(edited), 0o644); err != nil {
+		t.Fatalf("edit main.go: %v", err)
+	}
+
+	stale, err := Revert(root, patches)
+	if err != nil {
+		t.Fatalf("revert: %v", err)
+	}
+	if len(stale) != 1 {
+		t.Fatalf("expected edited wiring to be reported as stale, got %+v", stale)
+	}
+}
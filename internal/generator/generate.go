@@ -12,6 +12,7 @@ import (
 
 	lalibelacli "github.com/naodEthiop/lalibela-cli"
 	"github.com/naodEthiop/lalibela-cli/internal/features"
+	"github.com/naodEthiop/lalibela-cli/internal/features/shared"
 	"github.com/naodEthiop/lalibela-cli/internal/modules"
 	"github.com/naodEthiop/lalibela-cli/internal/utils"
 )
@@ -110,6 +111,9 @@ type TemplateData struct {
 	Framework   string
 	CLIVersion  string
 	Features    FeatureSet
+	// Env holds the environment variables the scaffold reads, rendered into
+	// .env and documented in .env.example.
+	Env []shared.EnvVar
 }
 
 // TemplateInfo describes a template asset and which frameworks/features it
@@ -228,9 +232,11 @@ func FeatureSetFromNames(features []string) FeatureSet {
 func TemplateCatalog() []TemplateInfo {
 	return []TemplateInfo{
 		{TemplatePath: "templates/env.tmpl", Frameworks: []string{"all"}, Features: []string{"base"}},
+		{TemplatePath: "templates/gitignore.tmpl", Frameworks: []string{"all"}, Features: []string{"base"}},
 		{TemplatePath: "index.html", Frameworks: []string{"all"}, Features: []string{"base"}},
 		{TemplatePath: "lalibela2.webp", Frameworks: []string{"all"}, Features: []string{"base"}},
 		{TemplatePath: "templates/startup.go.tmpl", Frameworks: []string{"all"}, Features: []string{"base"}},
+		{TemplatePath: "templates/tls.go.tmpl", Frameworks: []string{"all"}, Features: []string{"base"}},
 		{TemplatePath: "templates/main.go.tmpl", Frameworks: Frameworks(), Features: []string{"base"}},
 		{TemplatePath: "templates/routes/gin_routes.go.tmpl", Frameworks: []string{FrameworkGin}, Features: []string{"base"}},
 		{TemplatePath: "templates/routes/echo_routes.go.tmpl", Frameworks: []string{FrameworkEcho}, Features: []string{"base"}},
@@ -260,7 +266,32 @@ func BuildTemplateData(projectName, framework, cliVersion string, features []str
 		Framework:   framework,
 		CLIVersion:  normalizedVersion,
 		Features:    FeatureSetFromNames(features),
+		Env:         ScaffoldEnvVars(FeatureSetFromNames(features)),
+	}
+}
+
+// ScaffoldEnvVars returns the environment variables read by the scaffold for
+// the selected features. Features installed afterwards add their own.
+func ScaffoldEnvVars(set FeatureSet) []shared.EnvVar {
+	vars := []shared.EnvVar{
+		{Name: "PORT", Default: "8080", Description: "HTTP listen port"},
+		{Name: "TLS_CERT_FILE", Description: "PEM certificate to serve HTTPS with; HTTPS is enabled when it and TLS_KEY_FILE are set"},
+		{Name: "TLS_KEY_FILE", Description: "PEM private key of TLS_CERT_FILE"},
+		{Name: "TLS_REDIRECT_ADDR", Description: "Address of a plain HTTP listener redirecting to HTTPS, for example :80"},
+	}
+	if set.PostgreSQL {
+		vars = append(vars,
+			shared.EnvVar{Name: "DB_HOST", Default: "localhost", Description: "PostgreSQL host"},
+			shared.EnvVar{Name: "DB_PORT", Default: "5432", Description: "PostgreSQL port"},
+			shared.EnvVar{Name: "DB_USER", Default: "postgres", Description: "PostgreSQL user"},
+			shared.EnvVar{Name: "DB_PASSWORD", Description: "PostgreSQL password", Secret: true},
+			shared.EnvVar{Name: "DB_NAME", Default: "mydb", Description: "PostgreSQL database name"},
+		)
+	}
+	if set.JWT {
+		vars = append(vars, shared.EnvVar{Name: "JWT_SECRET", Description: "Secret used to sign and verify JWTs", Secret: true, Generate: true})
 	}
+	return vars
 }
 
 func getRootDir() (string, error) {
@@ -433,7 +464,9 @@ func generateBaseTemplates(ctx *generationContext) error {
 		outputPath   string
 	}{
 		{templatePath: "templates/env.tmpl", outputPath: ".env"},
+		{templatePath: "templates/gitignore.tmpl", outputPath: ".gitignore"},
 		{templatePath: "templates/startup.go.tmpl", outputPath: "startup.go"},
+		{templatePath: "templates/tls.go.tmpl", outputPath: "tls.go"},
 	}
 
 	for _, item := range base {
@@ -441,6 +474,13 @@ func generateBaseTemplates(ctx *generationContext) error {
 			return err
 		}
 	}
+	if _, err := shared.MergeEnvFiles(ctx.projectPath, ctx.data.Env); err != nil {
+		return err
+	}
+	// .env holds the generated secrets.
+	if err := os.Chmod(filepath.Join(ctx.projectPath, ".env"), 0o600); err != nil {
+		return err
+	}
 
 	if err := copyProjectAsset(ctx, "index.html", filepath.Join("templates", "index.html")); err != nil {
 		return err
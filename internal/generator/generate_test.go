@@ -7,6 +7,8 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/naodEthiop/lalibela-cli/internal/audit"
 )
 
 func TestRenderTemplate(t *testing.T) {
@@ -154,10 +156,13 @@ func TestGenerateProjectUsesEmbeddedTemplates(t *testing.T) {
 
 	expectedFiles := []string{
 		filepath.Join(tempDir, projectName, ".env"),
+		filepath.Join(tempDir, projectName, ".env.example"),
+		filepath.Join(tempDir, projectName, ".gitignore"),
 		filepath.Join(tempDir, projectName, "templates", "index.html"),
 		filepath.Join(tempDir, projectName, "templates", "lalibela2.webp"),
 		filepath.Join(tempDir, projectName, "main.go"),
 		filepath.Join(tempDir, projectName, "startup.go"),
+		filepath.Join(tempDir, projectName, "tls.go"),
 		filepath.Join(tempDir, projectName, "internal", "routes", "routes.go"),
 		filepath.Join(tempDir, projectName, "config", "logger.go"),
 	}
@@ -168,6 +173,78 @@ func TestGenerateProjectUsesEmbeddedTemplates(t *testing.T) {
 	}
 }
 
+func TestGenerateProjectWritesRandomSecrets(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(originalWD)
+	}()
+
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	jwtSecret := func(projectName string) string {
+		t.Helper()
+		err := GenerateProject(Options{
+			ProjectName: projectName,
+			Framework:   FrameworkNetHTTP,
+			Features:    []string{FeatureJWT},
+			Runner: func(string, string, ...string) error {
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("GenerateProject: %v", err)
+		}
+		envPath := filepath.Join(tempDir, projectName, ".env")
+		info, err := os.Stat(envPath)
+		if err != nil {
+			t.Fatalf("stat .env: %v", err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Fatalf("expected .env to be private, got %v", perm)
+		}
+		example, err := os.ReadFile(filepath.Join(tempDir, projectName, ".env.example"))
+		if err != nil {
+			t.Fatalf("read .env.example: %v", err)
+		}
+		if !strings.Contains(string(example), "\nJWT_SECRET=\n") {
+			t.Fatalf("expected a blank JWT_SECRET placeholder in .env.example, got:\n%s", example)
+		}
+		gitignore, err := os.ReadFile(filepath.Join(tempDir, projectName, ".gitignore"))
+		if err != nil {
+			t.Fatalf("read .gitignore: %v", err)
+		}
+		if !strings.Contains(string(gitignore), "\n.env\n") {
+			t.Fatalf("expected .env in .gitignore, got:\n%s", gitignore)
+		}
+		if !strings.HasSuffix(string(gitignore), "\nkeys/*.pem\ncerts/*-key.pem\n") {
+			t.Fatalf("expected the certificate keys in the secrets block at the end of .gitignore, got:\n%s", gitignore)
+		}
+
+		env, err := os.ReadFile(envPath)
+		if err != nil {
+			t.Fatalf("read .env: %v", err)
+		}
+		for _, line := range strings.Split(string(env), "\n") {
+			if value, ok := strings.CutPrefix(line, "JWT_SECRET="); ok {
+				return value
+			}
+		}
+		t.Fatalf("JWT_SECRET missing from .env:\n%s", env)
+		return ""
+	}
+
+	first, second := jwtSecret("secrets-one"), jwtSecret("secrets-two")
+	if len(first) < 32 || first == second {
+		t.Fatalf("expected distinct random secrets of at least 32 bytes, got %q and %q", first, second)
+	}
+}
+
 func TestGenerateProjectRollbackOnFailure(t *testing.T) {
 	tempDir := t.TempDir()
 	originalWD, err := os.Getwd()
@@ -220,3 +297,71 @@ func TestGenerateProjectRollbackOnFailure(t *testing.T) {
 		t.Fatalf("expected project directory to be removed, statErr=%v", statErr)
 	}
 }
+
+func TestScaffoldEnvVarsFollowSelectedFeatures(t *testing.T) {
+	t.Parallel()
+
+	names := func(set FeatureSet) []string {
+		var out []string
+		for _, envVar := range ScaffoldEnvVars(set) {
+			out = append(out, envVar.Name)
+		}
+		return out
+	}
+	if got := strings.Join(names(FeatureSet{Logger: true}), ","); got != "PORT,TLS_CERT_FILE,TLS_KEY_FILE,TLS_REDIRECT_ADDR" {
+		t.Fatalf("expected only the server variables without PostgreSQL or JWT, got %s", got)
+	}
+	vars := ScaffoldEnvVars(FeatureSet{PostgreSQL: true, JWT: true})
+	secrets := map[string]bool{}
+	for _, envVar := range vars {
+		secrets[envVar.Name] = envVar.Secret
+		if envVar.Secret && envVar.Default != "" {
+			t.Fatalf("secret %s has the fixed default %q; generate it or leave it blank", envVar.Name, envVar.Default)
+		}
+	}
+	if len(vars) != 10 || !secrets["DB_PASSWORD"] || !secrets["JWT_SECRET"] || secrets["DB_HOST"] {
+		t.Fatalf("unexpected env vars for PostgreSQL and JWT: %+v", vars)
+	}
+}
+
+func TestGenerateProjectTLSRedirectSetsTimeouts(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(originalWD)
+	}()
+
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	projectName := "tls-demo"
+	err = GenerateProject(Options{
+		ProjectName: projectName,
+		Framework:   FrameworkEcho,
+		Features:    []string{FeatureLogger},
+		Runner: func(string, string, ...string) error {
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("GenerateProject: %v", err)
+	}
+	root := filepath.Join(tempDir, projectName)
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module tls-demo\n"), 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+
+	report, err := audit.Run(root)
+	if err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+	for _, finding := range report.Findings {
+		if finding.File == "tls.go" {
+			t.Fatalf("unexpected finding in tls.go: %+v", finding)
+		}
+	}
+}
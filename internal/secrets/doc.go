@@ -0,0 +1,3 @@
+// Package secrets generates the random secrets and JWT signing keys written
+// into scaffolded projects.
+package secrets
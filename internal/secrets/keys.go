@@ -0,0 +1,197 @@
+package secrets
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// JWT signing algorithms of the keys NewKey generates.
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Algorithms lists the algorithms NewKey supports.
+func Algorithms() []string {
+	return []string{AlgRS256, AlgEdDSA}
+}
+
+// rsaBits is the size of generated RSA keys.
+const rsaBits = 3072
+
+// Key is a private JWT signing key.
+type Key struct {
+	// ID is the key's RFC 7638 thumbprint, used as the JWT kid.
+	ID      string
+	Alg     string
+	Private crypto.Signer
+}
+
+// NewKey generates a signing key for alg.
+func NewKey(alg string) (Key, error) {
+	var private crypto.Signer
+	var err error
+	switch alg {
+	case AlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaBits)
+	case AlgEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return Key{}, fmt.Errorf("unsupported algorithm %q: must be RS256 or EdDSA", alg)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("generating %s key: %w", alg, err)
+	}
+	return newKey(private)
+}
+
+func newKey(private crypto.Signer) (Key, error) {
+	key := Key{Private: private}
+	switch private.(type) {
+	case *rsa.PrivateKey:
+		key.Alg = AlgRS256
+	case ed25519.PrivateKey:
+		key.Alg = AlgEdDSA
+	default:
+		return Key{}, fmt.Errorf("unsupported key type %T", private)
+	}
+	key.ID = key.JWK().thumbprint()
+	return key, nil
+}
+
+// WritePrivateKey writes key to path as a PKCS #8 PEM block. The file is
+// readable by its owner only, also when it already existed.
+func WritePrivateKey(path string, key Key) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key.Private)
+	if err != nil {
+		return fmt.Errorf("encoding private key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := file.Chmod(0o600); err != nil {
+		file.Close()
+		return err
+	}
+	if err := pem.Encode(file, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		file.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// ReadPrivateKey reads a key written by WritePrivateKey.
+func ReadPrivateKey(path string) (Key, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return Key{}, fmt.Errorf("%s: no PKCS #8 private key", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return Key{}, fmt.Errorf("%s: %w", path, err)
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return Key{}, fmt.Errorf("%s: unsupported key type %T", path, parsed)
+	}
+	return newKey(private)
+}
+
+// JWK is the public half of a signing key as a JSON Web Key.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWK returns the public key of k.
+func (k Key) JWK() JWK {
+	encode := base64.RawURLEncoding.EncodeToString
+	jwk := JWK{Kid: k.ID, Alg: k.Alg, Use: "sig"}
+	switch private := k.Private.(type) {
+	case *rsa.PrivateKey:
+		jwk.Kty = "RSA"
+		jwk.N = encode(private.N.Bytes())
+		jwk.E = encode(big.NewInt(int64(private.E)).Bytes())
+	case ed25519.PrivateKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = encode(private.Public().(ed25519.PublicKey))
+	}
+	return jwk
+}
+
+// thumbprint returns the RFC 7638 thumbprint of the key: the SHA-256 of its
+// required members in lexicographic order.
+func (j JWK) thumbprint() string {
+	var members string
+	switch j.Kty {
+	case "RSA":
+		members = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, j.E, j.N)
+	case "OKP":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, j.Crv, j.X)
+	}
+	sum := sha256.Sum256([]byte(members))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// UpdateJWKS writes the JWKS file at path with the public key of current
+// first, followed by the keys already in the file whose IDs are in keep.
+// Keeping the key current replaces lets tokens it signed verify until they
+// expire.
+func UpdateJWKS(path string, current Key, keep ...string) error {
+	var set struct {
+		Keys []JWK `json:"keys"`
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(raw, &set); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	keys := []JWK{current.JWK()}
+	for _, jwk := range set.Keys {
+		if jwk.Kid != current.ID && slices.Contains(keep, jwk.Kid) {
+			keys = append(keys, jwk)
+		}
+	}
+	set.Keys = keys
+	out, err := json.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(out, '\n'), 0o644)
+}
@@ -0,0 +1,22 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// SecretBytes is the number of random bytes in a generated secret. Encoded,
+// a secret is 43 characters long, above the 32 bytes the generated code
+// requires of JWT and cookie secrets.
+const SecretBytes = 32
+
+// Generate returns a random secret read from crypto/rand, base64url encoded
+// without padding so it can be written to .env unquoted.
+func Generate() (string, error) {
+	raw := make([]byte, SecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("generating secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
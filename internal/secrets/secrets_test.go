@@ -0,0 +1,96 @@
+package secrets
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	first, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	second, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(first) != 43 || first == second {
+		t.Fatalf("expected distinct 43-character secrets, got %q and %q", first, second)
+	}
+}
+
+func TestWriteAndReadPrivateKey(t *testing.T) {
+	dir := t.TempDir()
+	for _, alg := range Algorithms() {
+		key, err := NewKey(alg)
+		if err != nil {
+			t.Fatalf("NewKey(%s): %v", alg, err)
+		}
+		path := filepath.Join(dir, "keys", alg+".pem")
+		// An existing, world-readable file is made private.
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := WritePrivateKey(path, key); err != nil {
+			t.Fatalf("WritePrivateKey(%s): %v", alg, err)
+		}
+		info, err := os.Stat(path)
+		if err != nil || info.Mode().Perm() != 0o600 {
+			t.Fatalf("%s: expected a 0600 key file, got %v, %v", alg, info, err)
+		}
+
+		read, err := ReadPrivateKey(path)
+		if err != nil {
+			t.Fatalf("ReadPrivateKey(%s): %v", alg, err)
+		}
+		if read.ID != key.ID || read.Alg != alg || len(key.ID) != 43 {
+			t.Fatalf("%s: read key %s/%s, want %s/%s", alg, read.Alg, read.ID, alg, key.ID)
+		}
+	}
+	if _, err := NewKey("HS256"); err == nil {
+		t.Fatal("NewKey accepted a symmetric algorithm")
+	}
+}
+
+func TestUpdateJWKSKeepsNamedKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	keys := make([]Key, 3)
+	for i := range keys {
+		key, err := NewKey(AlgEdDSA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = key
+	}
+	for i, key := range keys {
+		var keep []string
+		if i > 0 {
+			keep = []string{keys[i-1].ID}
+		}
+		if err := UpdateJWKS(path, key, keep...); err != nil {
+			t.Fatalf("UpdateJWKS: %v", err)
+		}
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var set struct {
+		Keys []JWK `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &set); err != nil {
+		t.Fatalf("parse JWKS: %v", err)
+	}
+	if len(set.Keys) != 2 || set.Keys[0].Kid != keys[2].ID || set.Keys[1].Kid != keys[1].ID {
+		t.Fatalf("expected the current and previous keys only, got %+v", set.Keys)
+	}
+	if jwk := set.Keys[0]; jwk.Kty != "OKP" || jwk.Crv != "Ed25519" || jwk.Alg != AlgEdDSA || jwk.Use != "sig" || jwk.X == "" {
+		t.Fatalf("unexpected JWK %+v", jwk)
+	}
+}